@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// analysisBoard opens a private analysis board on the live position. Either side
+// can move on it, 'u' or Backspace takes a move back, 'x' plays the mirror
+// image of the last move for the other side, 'e' puts the last move in the
+// input line to edit and play, 'H' shades the squares by which side
+// controls them, and Esc returns to the live game. Nothing played here is sent to the opponent; moves that arrive
+// in the live game meanwhile are reported on the message bar.
+func (g *Game) analysisBoard() {
+	g.lock.Lock()
+	g.analysing = true
+	a := NewGame()
+	a.copyPosition(g)
+	g.lock.Unlock()
+	defer func() {
+		g.lock.Lock()
+		g.analysing = false
+		g.lock.Unlock()
+	}()
+
+	// A finished game can be played on from where it stopped.
+	a.gameOver, a.result, a.termination = false, resultOngoing, ""
+	a.analysis = true
+	a.glyphs = g.glyphs
+	a.pieceStyle = g.pieceStyle
+	a.prefs = g.prefs
+	a.currentThemeIndex = g.currentThemeIndex
+	a.flipped = g.flipped
+	a.wrapCursor = g.wrapCursor
+	a.keyboardOnly = g.keyboardOnly
+	a.strict = g.strict
+	a.freestyle = g.freestyle
+	a.rules = g.rules
+	a.showControl = g.showControl
+	a.cursorX, a.cursorY = g.cursorX, g.cursorY
+	a.message = tr(txtAnalysisWelcome)
+
+	var undo []*Game
+	for {
+		a.drawBoard()
+		before := &Game{}
+		before.copyPosition(a)
+
+		switch ev := termbox.PollEvent(); ev.Type {
+		case termbox.EventInterrupt:
+			if shuttingDown.Load() {
+				return
+			}
+			g.lock.Lock()
+			a.message = tr(txtLiveGame, g.message)
+			g.lock.Unlock()
+		case termbox.EventKey:
+			switch {
+			case a.inputMode != modeNormal:
+				a.handleKey(ev, io.Discard, a.currentPlayer)
+			case ev.Key == termbox.KeyEsc:
+				g.currentThemeIndex, g.flipped = a.currentThemeIndex, a.flipped
+				if a.analysisLink != "" {
+					g.analysisLink = a.analysisLink
+				}
+				return
+			case ev.Ch == 'u' || ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2:
+				if len(undo) == 0 {
+					a.message = tr(txtNothingToTakeBack)
+					break
+				}
+				a.copyPosition(undo[len(undo)-1])
+				undo = undo[:len(undo)-1]
+				a.selectedX, a.selectedY = -1, -1
+				a.legalMoves = make(map[string]moveKind)
+				a.message = tr(txtTookBack)
+			case ev.Ch == 'x' || ev.Ch == 'X':
+				a.mirrorLastMove()
+			case ev.Ch == 'e' || ev.Ch == 'E':
+				a.editLastMove()
+			case ev.Ch == 'H':
+				a.toggleHeatmap()
+			case ev.Ch == 'a' || ev.Ch == 'A':
+				// Already analysing.
+			default:
+				a.handleKey(ev, io.Discard, a.currentPlayer)
+			}
+		case termbox.EventMouse:
+			if a.wheelTheme(ev) {
+				break
+			}
+			a.cursorX, a.cursorY = a.screenToSquare(ev.MouseX, ev.MouseY)
+			if ev.Key == termbox.MouseLeft {
+				a.handleMouseClick(a.currentPlayer)
+			}
+		case termbox.EventError:
+			panic(ev.Err)
+		}
+
+		if a.quit {
+			return
+		}
+		if len(a.moveHistory) != len(before.moveHistory) {
+			undo = append(undo, before)
+		}
+	}
+}
+
+// copyPosition copies everything the rules depend on from src, so g carries
+// on the game from src's position.
+func (g *Game) copyPosition(src *Game) {
+	g.board = src.board
+	g.startFEN = src.startFEN
+	g.currentPlayer = src.currentPlayer
+	g.castling = src.castling
+	g.enPassant = src.enPassant
+	g.halfmoveClock = src.halfmoveClock
+	g.fullmoveNumber = src.fullmoveNumber
+	g.hash = src.hash
+	g.repetitions = maps.Clone(src.repetitions)
+	g.checks = maps.Clone(src.checks)
+	g.moveHistory = slices.Clone(src.moveHistory)
+	g.sanHistory = slices.Clone(src.sanHistory)
+	g.events = slices.Clone(src.events)
+	g.gameOver = src.gameOver
+	g.result = src.result
+	g.termination = src.termination
+}
+
+// lastMove returns the last move played, or "" before the first.
+func (g *Game) lastMove() string {
+	if len(g.moveHistory) == 0 {
+		return ""
+	}
+	return g.moveHistory[len(g.moveHistory)-1]
+}
+
+// mirrorMove reflects a move in wire format across the middle of the
+// board, e.g. "g1f3" becomes "g8f6".
+func mirrorMove(moveStr string) string {
+	b := []byte(moveStr)
+	b[1], b[3] = '1'+'8'-b[1], '1'+'8'-b[3]
+	return string(b)
+}
+
+// mirrorLastMove plays the mirror image of the last move for the side to
+// move, which makes symmetric positions quick to set up.
+func (g *Game) mirrorLastMove() {
+	last := g.lastMove()
+	if last == "" {
+		g.message = tr(txtNoMirror)
+		return
+	}
+	if err := g.ApplyAlgebraic(mirrorMove(last), g.currentPlayer); err != nil {
+		g.message = tr(txtCannotMirror, err)
+	}
+}
+
+// editLastMove opens the input line with the last move filled in, to be
+// edited and played for the side to move. SAN works too, with the piece
+// letters of the language in use or English ones.
+func (g *Game) editLastMove() {
+	g.startTextEntry(tr(txtPlayPrompt), func(text string) {
+		if moveStr, ok := g.parseLocalSAN(text); ok {
+			text = moveStr
+		}
+		if err := g.ApplyAlgebraic(strings.ToLower(text), g.currentPlayer); err != nil {
+			g.message = tr(txtCannotPlay, err)
+		}
+	})
+	g.inputText = g.lastMove()
+	g.message = g.inputPrompt + g.inputText
+}
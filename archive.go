@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// archivedGame is a saved game as resume lists it: its file and what its
+// tags and moves say about it.
+type archivedGame struct {
+	path        string
+	date        string
+	white       string
+	black       string
+	result      string
+	termination string
+	plies       int
+}
+
+// String describes the game on one line, e.g.
+// "2026.10.16  alice vs ?  1-0 (checkmate), 34 moves  game-20261016-101500.pgn".
+func (a archivedGame) String() string {
+	result := "in progress"
+	if a.result != "" && a.result != resultOngoing {
+		result = a.result
+	}
+	if a.termination != "" {
+		result += " (" + a.termination + ")"
+	}
+	return fmt.Sprintf("%s  %s vs %s  %s, %d moves  %s", a.date, a.white, a.black, result, (a.plies+1)/2, filepath.Base(a.path))
+}
+
+// listArchive reads the headers of every PGN file in dir, oldest first.
+// Saved games are named by when they were saved, so a game keeps its place
+// in the list as more are added. A missing directory is an empty archive.
+func listArchive(dir string) ([]archivedGame, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pgn"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	var games []archivedGame
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Removed since the glob
+		}
+		if err != nil {
+			return nil, err
+		}
+		tags, moves, result, err := readPGN(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if result == "" {
+			result = tags["Result"]
+		}
+		games = append(games, archivedGame{
+			path:        path,
+			date:        tags["Date"],
+			white:       tags["White"],
+			black:       tags["Black"],
+			result:      result,
+			termination: tags["Termination"],
+			plies:       len(moves),
+		})
+	}
+	return games, nil
+}
+
+// pickArchivedGame asks which of games to open and what to do with it:
+// view, continue or analyze. It returns "" for the action if the player
+// gives up.
+func pickArchivedGame(games []archivedGame) (archivedGame, string) {
+	for i, game := range games {
+		fmt.Printf("%3d  %s\n", i+1, game)
+	}
+	var id int
+	fmt.Print("Game number (Enter to quit): ")
+	if _, err := fmt.Scanln(&id); err != nil || id < 1 || id > len(games) {
+		return archivedGame{}, ""
+	}
+	fmt.Print("(v)iew, (c)ontinue or (a)nalyze? ")
+	var choice string
+	fmt.Scanln(&choice)
+	for _, action := range []string{"view", "continue", "analyze"} {
+		if choice != "" && strings.HasPrefix(action, strings.ToLower(choice)) {
+			return games[id-1], action
+		}
+	}
+	return archivedGame{}, ""
+}
+
+// openArchivedGame does action with a saved game: replays it, hosts it for
+// an opponent to continue, or opens an analysis board on its final
+// position.
+func (s *setup) openArchivedGame(game archivedGame, action string) {
+	switch action {
+	case "view":
+		s.replay(game.path)
+	case "continue":
+		var err error
+		if s.resume, err = continuePGN(game.path); err != nil {
+			fmt.Println("Cannot continue game:", err)
+			return
+		}
+		conn, player, err := hostGame()
+		if err != nil {
+			fmt.Println("Failed to host game:", err)
+			return
+		}
+		s.playNetworked(conn, player, true)
+	case "analyze":
+		f, err := os.Open(game.path)
+		if err != nil {
+			fmt.Println("Cannot open game:", err)
+			return
+		}
+		loaded, _, _, err := loadPGN(f, nil)
+		f.Close()
+		if err != nil {
+			fmt.Println("Cannot open game:", err)
+			return
+		}
+		g := s.newGame()
+		g.copyPosition(loaded)
+		defer g.printAnalysisLink()
+		startTerminal(s.inputMode())
+		defer termbox.Close()
+		g.analysisBoard()
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"strings"
+)
+
+// Move is a move in the wire format: the from and to squares, e.g. "e2e4".
+// Castling is the king's two-square move, "e1g1". A pawn reaching the last
+// rank becomes a queen, or the piece a fifth letter names: "e7e8n" for a
+// knight.
+type Move string
+
+// Position is what a Bot is shown when it is its turn. The game can be
+// rebuilt exactly, repetitions included, by loading Start (or the standard
+// starting position when it is empty) and playing History under the rules
+// Variant and Freestyle name.
+type Position struct {
+	FEN       string // The position to move in
+	Turn      string // The side to move, "white" or "black"
+	Legal     []Move // Every legal move, never empty
+	Start     string // FEN the game started from, empty for the standard start
+	History   []Move // The moves played so far
+	Variant   string // The variant played, e.g. "koth", empty for standard chess
+	Freestyle bool   // Freestyle rules are played (see Game.freestyle)
+}
+
+// Bot chooses moves for one side of a game. SelectMove must return one of
+// pos.Legal; an error or any other move forfeits the game for the bot's
+// side. It is called from its own goroutine and may take its time.
+type Bot interface {
+	SelectMove(pos Position) (Move, error)
+}
+
+// bots are the built-in bots, by the name the bot command takes. A third
+// party bot is one more entry.
+var bots = map[string]func() Bot{
+	"random":  func() Bot { return randomBot{} },
+	"minimax": func() Bot { return minimaxBot{depth: 2} },
+}
+
+// botNames lists the built-in bots for usage and errors.
+func botNames() string {
+	names := make([]string, 0, len(bots))
+	for name := range bots {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// errBadBotMove reports a bot choosing a move that is not legal.
+var errBadBotMove = errors.New("bot chose a move that is not legal")
+
+// position is what a bot sees of g. The caller holds g.lock.
+func (g *Game) position() Position {
+	pos := Position{FEN: g.FEN(), Turn: g.currentPlayer, Start: g.startFEN, Variant: variantName(g.rules), Freestyle: g.freestyle}
+	for _, moveStr := range g.moveHistory {
+		pos.History = append(pos.History, Move(moveStr))
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := g.board[y][x]
+			if piece == nil || piece.color != g.currentPlayer {
+				continue
+			}
+			moves := g.movesFrom(y, x)
+			for ty := 0; ty < 8; ty++ {
+				for tx := 0; tx < 8; tx++ {
+					if moves[squareKey(tx, ty)] == moveNone {
+						continue
+					}
+					for _, promotion := range g.promotionsOf(y, x, ty) {
+						pos.Legal = append(pos.Legal, Move(formatMove(y, x, ty, tx)+promotionLetter(promotion)))
+					}
+				}
+			}
+		}
+	}
+	return pos
+}
+
+// positionGame rebuilds the game a Position describes, for bots that want
+// the rules engine.
+func positionGame(pos Position) (*Game, error) {
+	g := NewGame()
+	g.freestyle = pos.Freestyle
+	g.rules = variants[pos.Variant]
+	if pos.Start != "" {
+		if err := g.loadFEN(pos.Start); err != nil {
+			return nil, err
+		}
+	}
+	for _, move := range pos.History {
+		if err := g.ApplyAlgebraic(string(move), g.currentPlayer); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// randomBot plays any legal move.
+type randomBot struct{}
+
+func (randomBot) SelectMove(pos Position) (Move, error) {
+	return pos.Legal[rand.Intn(len(pos.Legal))], nil
+}
+
+// minimaxBot searches depth plies ahead with alpha-beta pruning and scores
+// what it finds with Evaluate. Moves that score the same are picked from
+// at random, so it does not play the same game every time.
+type minimaxBot struct {
+	depth int
+}
+
+// mateScore outweighs any material balance. A mate found sooner scores
+// higher, so the bot mates as fast as it can.
+const mateScore = 1000000
+
+func (b minimaxBot) SelectMove(pos Position) (Move, error) {
+	g, err := positionGame(pos)
+	if err != nil {
+		return "", err
+	}
+	moves := slices.Clone(pos.Legal)
+	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] })
+	best, alpha := moves[0], -2*mateScore
+	for _, move := range moves {
+		score := -b.search(g.after(move), b.depth-1, -2*mateScore, -alpha)
+		if score > alpha {
+			best, alpha = move, score
+		}
+	}
+	return best, nil
+}
+
+// search scores g for the side to move, looking depth plies further.
+func (b minimaxBot) search(g *Game, depth, alpha, beta int) int {
+	if g.gameOver {
+		if g.termination == "checkmate" {
+			return -mateScore - depth // The side to move is mated
+		}
+		return 0
+	}
+	if depth <= 0 {
+		score := g.Evaluate()
+		if g.currentPlayer == "black" {
+			score = -score
+		}
+		return score
+	}
+	for _, move := range g.position().Legal {
+		score := -b.search(g.after(move), depth-1, -beta, -alpha)
+		if score >= beta {
+			return beta
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
+
+// after returns a copy of g with move played.
+func (g *Game) after(move Move) *Game {
+	next := NewGame()
+	next.copyPosition(g)
+	next.freestyle = g.freestyle
+	next.rules = g.rules
+	next.ApplyAlgebraic(string(move), next.currentPlayer)
+	return next
+}
+
+// askBot gets the bot's move in g and checks it is legal.
+func askBot(bot Bot, g *Game) (Move, error) {
+	g.lock.Lock()
+	pos := g.position()
+	g.lock.Unlock()
+	move, err := bot.SelectMove(pos)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(pos.Legal, move) {
+		return "", fmt.Errorf("%q: %w", move, errBadBotMove)
+	}
+	return move, nil
+}
+
+// playBot plays color's side of a game over conn with bot choosing the
+// moves, speaking the same protocol as a joiner. It is the opponent in a
+// game against a bot: the player hosts on one end of a local connection
+// and the bot joins on the other, agreeing to the host's freestyle and
+// variant. It returns when the connection closes.
+func playBot(conn net.Conn, color string, bot Bot, freestyle bool, variant string) {
+	defer conn.Close()
+	peer, err := handshake(conn, gameCapabilities(freestyle, variant))
+	if err == nil {
+		peer.variant, err = settleVariant(variant, peer.variant, false)
+	}
+	if err != nil {
+		return
+	}
+	go sendHeartbeats(conn)
+	g := NewGame()
+	g.freestyle = freestyle
+	g.rules = variants[peer.variant]
+	g.sendAcks = peer.acks
+	g.headless = true
+	g.playerColor = color
+	g.settingUp = true
+	reader := newLineReader(conn)
+	for {
+		g.lock.Lock()
+		if g.readyCheck && !g.ready[color] {
+			g.applyReady(color, ctrlReady)
+			sendMessage(conn, message{kind: msgControl, arg: ctrlReady})
+		}
+		move := !g.gameOver && g.currentPlayer == color && g.readyToPlay()
+		g.lock.Unlock()
+		if move {
+			choice, err := askBot(bot, g)
+			if err != nil {
+				// Give the game up rather than leave the player waiting.
+				sendMessage(conn, message{kind: msgControl, arg: ctrlResign})
+				return
+			}
+			g.ApplyAlgebraic(string(choice), color)
+			sendMessage(conn, message{kind: msgMove, arg: string(choice)})
+			continue
+		}
+
+		line, err := reader.readLine()
+		if err == errLineTooLong {
+			g.lock.Lock()
+			g.message = tr(txtIgnoredOversized)
+			g.lock.Unlock()
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if m, err := decodeMessage(line); err == nil {
+			g.dispatch(conn, m)
+		}
+	}
+}
+
+// selfPlay plays a game between two bots on g and returns when it is over.
+// A bot that fails loses the game. A clock on g may end the game while a
+// bot thinks, so its state is read under the lock.
+func selfPlay(g *Game, white, black Bot) {
+	players := map[string]Bot{"white": white, "black": black}
+	for {
+		g.lock.Lock()
+		over, color := g.gameOver, g.currentPlayer
+		g.lock.Unlock()
+		if over {
+			return
+		}
+		move, err := askBot(players[color], g)
+		if err != nil {
+			g.lock.Lock()
+			if !g.gameOver {
+				g.endGame(winResult(opponent(color)), "forfeit", tr(txtBotFailed, colorName(color), err))
+			}
+			g.lock.Unlock()
+			return
+		}
+		g.ApplyAlgebraic(string(move), color) // Refused if the flag fell meanwhile
+	}
+}
+
+// localPair returns the two ends of a connection on the loopback
+// interface. Unlike net.Pipe it buffers, so both ends can send their
+// handshake before reading the other's.
+func localPair() (net.Conn, net.Conn, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ln.Close()
+	dialed := make(chan net.Conn, 1)
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			conn = nil
+		}
+		dialed <- conn
+	}()
+	accepted, err := ln.Accept()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := <-dialed
+	if conn == nil {
+		accepted.Close()
+		return nil, nil, errors.New("cannot connect to the local bot")
+	}
+	return accepted, conn, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRandomSelfPlay(t *testing.T) {
+	for range 10 {
+		g := newTestGame(t, "")
+		selfPlay(g, randomBot{}, randomBot{})
+		if g.result == resultOngoing || g.termination == "forfeit" {
+			t.Fatalf("game ended %q by %q: %s", g.result, g.termination, g.message)
+		}
+		// Every move must replay from the start as a legal one.
+		pos := g.position()
+		replayed, err := positionGame(pos)
+		if err != nil {
+			t.Fatalf("replaying %v: %v", pos.History, err)
+		}
+		if replayed.FEN() != g.FEN() || replayed.result != g.result {
+			t.Errorf("replay ended at %s %q, the game at %s %q", replayed.FEN(), replayed.result, g.FEN(), g.result)
+		}
+	}
+}
+
+// botFunc makes a Bot of a function.
+type botFunc func(pos Position) (Move, error)
+
+func (f botFunc) SelectMove(pos Position) (Move, error) { return f(pos) }
+
+func TestBadBotForfeits(t *testing.T) {
+	tests := []struct {
+		name string
+		bot  Bot
+	}{
+		{"illegal move", botFunc(func(Position) (Move, error) { return "e2e5", nil })},
+		{"error", botFunc(func(Position) (Move, error) { return "", errors.New("out of ideas") })},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, "")
+		selfPlay(g, randomBot{}, tt.bot)
+		if g.result != resultWhiteWins || g.termination != "forfeit" || len(g.moveHistory) != 1 {
+			t.Errorf("%s: result %q, termination %q after %d moves, want white to win by forfeit after 1", tt.name, g.result, g.termination, len(g.moveHistory))
+		}
+	}
+}
+
+func TestBotSeesLegalMoves(t *testing.T) {
+	g := newTestGame(t, "")
+	playMoves(t, g, "e2e4")
+	var seen Position
+	selfPlay(g, randomBot{}, botFunc(func(pos Position) (Move, error) {
+		seen = pos
+		return "", errors.New("stop")
+	}))
+	if seen.Turn != "black" || len(seen.Legal) != 20 || !slices.Equal(seen.History, []Move{"e2e4"}) {
+		t.Errorf("bot saw turn %q, %d legal moves, history %v", seen.Turn, len(seen.Legal), seen.History)
+	}
+}
+
+func TestMinimaxTakesMate(t *testing.T) {
+	// Ra8 mates; the bot must prefer it to taking the knight.
+	g := newTestGame(t, "6k1/5ppp/8/8/8/8/8/R3n1K1 w - - 0 1")
+	move, err := askBot(minimaxBot{depth: 2}, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move != "a1a8" {
+		t.Errorf("minimax played %s, want a1a8", move)
+	}
+}
+
+func TestSelfPlayKeepsVariant(t *testing.T) {
+	s := &setup{opts: &options{variant: "koth"}}
+	g := s.selfPlayGame()
+	// scripted plays one side's moves in turn; white walks its king to d4.
+	scripted := func(moves ...Move) Bot {
+		return botFunc(func(pos Position) (Move, error) {
+			return moves[len(pos.History)/2], nil
+		})
+	}
+	selfPlay(g, scripted("e2e4", "e1e2", "e2e3", "e3d4"), scripted("a7a6", "a6a5", "a5a4", "h7h6"))
+	if g.result != resultWhiteWins || g.termination != "king of the hill" {
+		t.Errorf("game ended %q by %q: %s", g.result, g.termination, g.message)
+	}
+}
+
+func TestPositionKeepsRules(t *testing.T) {
+	g := variantGame(t, "koth", "")
+	playMoves(t, g, "e2e4", "a7a6", "e1e2", "a6a5", "e2e3", "a5a4")
+	replayed, err := positionGame(g.position())
+	if err != nil {
+		t.Fatal(err)
+	}
+	playMoves(t, replayed, "e3d4")
+	if replayed.termination != "king of the hill" {
+		t.Errorf("replayed koth game ended by %q: %s", replayed.termination, replayed.message)
+	}
+
+	g = newTestGame(t, "")
+	g.freestyle = true
+	playMoves(t, g, "e1e5")
+	if replayed, err = positionGame(g.position()); err != nil {
+		t.Fatalf("replaying a freestyle game: %v", err)
+	}
+	if !replayed.freestyle || replayed.FEN() != g.FEN() {
+		t.Errorf("replayed freestyle %v at %s, want freestyle at %s", replayed.freestyle, replayed.FEN(), g.FEN())
+	}
+}
+
+func TestBotAgreesToHostRules(t *testing.T) {
+	host, botConn, err := localPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	go playBot(botConn, "black", randomBot{}, true, "")
+	if _, err := handshake(host, gameCapabilities(true, "")); err != nil {
+		t.Errorf("freestyle handshake with the bot: %v", err)
+	}
+}
@@ -0,0 +1,791 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// subcommands are the modes that can be started directly, e.g.
+// "chessGo join 192.168.1.5", each with its own flags. Without one the
+// interactive menu runs.
+var subcommands = map[string]func(args []string){
+	"host":    hostCommand,
+	"join":    joinCommand,
+	"serve":   serveCommand,
+	"watch":   watchCommand,
+	"replay":  replayCommand,
+	"demo":    demoCommand,
+	"analyze": analyzeCommand,
+	"train":   trainCommand,
+	"resume":  resumeCommand,
+	"bot":     botCommand,
+	"puzzle":  puzzleCommand,
+}
+
+// options are the command-line settings shared by the modes that draw a
+// board. Each mode registers the ones it uses.
+type options struct {
+	pieceSet           string
+	pieceStyle         string
+	lang               string
+	wrapCursor         bool
+	mouse              string
+	soundTheme         string
+	soundCmd           string
+	autosaveDir        string
+	name               string
+	printMoves         bool
+	strict             bool
+	ackTimeout         time.Duration
+	sendDelay          time.Duration
+	maxPremoves        int
+	touchMove          bool
+	idleTimeout        time.Duration
+	idleDraw           bool
+	readyCheck         bool
+	maxMoves           int
+	maxMovesByMaterial bool
+	clockBase          time.Duration
+	clockBonus         time.Duration
+	clockMode          string
+	freestyle          bool
+	variant            string
+	watchAddr          string
+	joinRetry          time.Duration
+}
+
+// boardFlags registers the flags that change how the board is shown.
+func (o *options) boardFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.pieceSet, "pieces", "", "piece glyphs to draw: unicode or ascii (default: detected from the locale)")
+	fs.StringVar(&o.pieceStyle, "piece-style", "glyph", "how pieces are drawn: "+pieceStyleNames()+"; art draws pictures several cells across, on squares big enough")
+	fs.BoolVar(&o.wrapCursor, "wrap-cursor", false, "wrap the keyboard cursor around the board edges instead of stopping")
+	fs.StringVar(&o.mouse, "mouse", "auto", "mouse input: on, off, or auto to use it where the terminal is likely to support it")
+	fs.StringVar(&o.lang, "lang", "", "language of the messages and piece letters: "+languageNames()+" (default: the last one used, or en)")
+}
+
+// playFlags registers the board flags and those for playing a game.
+func (o *options) playFlags(fs *flag.FlagSet) {
+	o.boardFlags(fs)
+	fs.StringVar(&o.soundTheme, "sound", "", "sound theme: bell or command (default: the last one used, or bell)")
+	fs.StringVar(&o.soundCmd, "sound-cmd", "", "command run for each sound with -sound=command; {cue} is replaced by move, capture, castle, promote, check, checkmate, stalemate or gameover")
+	fs.StringVar(&o.autosaveDir, "autosave", "", "save every finished game as a timestamped PGN file in this directory")
+	fs.StringVar(&o.name, "name", os.Getenv("USER"), "your name, as recorded in saved games")
+	fs.BoolVar(&o.printMoves, "print-moves", false, "print the game's moves in SAN after quitting")
+	fs.BoolVar(&o.strict, "strict", false, "development aid: check the rules engine after every move and panic on any inconsistency")
+	fs.DurationVar(&o.ackTimeout, "ack-timeout", 0, "warn when the opponent has not confirmed a move within this time (0 disables)")
+	fs.IntVar(&o.maxPremoves, "premoves", 1, "how many premoves can be queued at once, played one a turn")
+	fs.BoolVar(&o.touchMove, "touch-move", false, "touch-move rule: once you select a piece that can move, you must move it")
+	fs.DurationVar(&o.sendDelay, "send-delay", 0, "hold each move this long before sending it, so Backspace can take it back unseen (0 sends at once)")
+	o.variantFlags(fs)
+}
+
+// variantFlags registers the flags that change the rules of the game.
+func (o *options) variantFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.freestyle, "freestyle", false, "freestyle rules: any piece may move to any square not held by its own side; both players must choose it")
+	fs.Func("variant", "variant to play: "+variantNames()+"; when joining or watching, the host's variant is played without it (default standard chess)", func(name string) error {
+		if _, ok := variants[name]; !ok {
+			return fmt.Errorf("unknown variant %q, want one of %s", name, variantNames())
+		}
+		o.variant = name
+		return nil
+	})
+}
+
+// checkVariant reports whether the rule flags can be played together.
+func (o *options) checkVariant() error {
+	if o.freestyle && o.variant != "" {
+		return errors.New("-freestyle and -variant cannot be used together")
+	}
+	return nil
+}
+
+// idleFlags registers the flags for adjudicating idle or overlong games
+// and for the ready check, used when hosting or serving.
+func (o *options) idleFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&o.idleTimeout, "idle-timeout", 0, "when hosting or serving, adjudicate a game once the side to move has been idle this long (0 disables)")
+	fs.BoolVar(&o.idleDraw, "idle-draw", false, "adjudicate idle games as draws instead of losses for the idle side")
+	fs.IntVar(&o.maxMoves, "max-moves", defaultMaxMoves, "when hosting or serving, adjudicate a game still going after this many moves (0 never)")
+	fs.BoolVar(&o.maxMovesByMaterial, "max-moves-material", false, "adjudicate games that reach -max-moves by material instead of as draws")
+	fs.BoolVar(&o.readyCheck, "ready-check", false, "when hosting or serving, wait for both players to press 'g' before the first move")
+}
+
+// clockFlags registers the flags for the time control, used when hosting
+// or serving.
+func (o *options) clockFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&o.clockBase, "clock", 0, "when hosting or serving, give each player this much time for the game (0 plays without clocks)")
+	fs.DurationVar(&o.clockBonus, "increment", 0, "the increment or delay per move, applied as -time-mode says")
+	o.clockMode = "increment"
+	fs.Func("time-mode", "how -increment is applied: increment (Fischer), bronstein or delay (simple US delay) (default increment)", func(mode string) error {
+		if _, ok := timeStrategies[mode]; !ok {
+			return fmt.Errorf("unknown time mode %q", mode)
+		}
+		o.clockMode = mode
+		return nil
+	})
+}
+
+// timeControl returns the time control the clock flags ask for.
+func (o *options) timeControl() timeControl {
+	return timeControl{base: o.clockBase, bonus: o.clockBonus, mode: o.clockMode}
+}
+
+// joinFlags registers the flags for joining a game.
+func (o *options) joinFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&o.joinRetry, "retry", 0, "when joining, keep trying to connect for this long if the host is not listening yet (0 tries once)")
+}
+
+// serverOptions returns the rules for games run by this process.
+func (o *options) serverOptions() serverOptions {
+	return serverOptions{strict: o.strict, idleTimeout: o.idleTimeout, idleDraw: o.idleDraw, readyCheck: o.readyCheck, maxMoves: o.maxMoves, maxMovesByMaterial: o.maxMovesByMaterial, clock: o.timeControl(), freestyle: o.freestyle, variant: o.variant, watchAddr: o.watchAddr}
+}
+
+// setup is everything resolved from the options and saved preferences that
+// a new Game needs.
+type setup struct {
+	opts   *options
+	mouse  bool // Request mouse events; otherwise play by keyboard only
+	glyphs map[rune]rune
+	style  pieceStyle
+	prefs  preferences
+	sound  soundPlayer
+	resume *Game // Game to continue when hosting; nil starts a new one
+
+	// handicap describes the material handicap resume was set up with,
+	// empty when it is a game being continued.
+	handicap string
+}
+
+// setup resolves the options, loading the preferences and saving any sound
+// settings given on the command line.
+func (o *options) setup() (*setup, error) {
+	if o.pieceSet == "" {
+		o.pieceSet = defaultGlyphSet()
+	}
+	glyphs, ok := glyphSets[o.pieceSet]
+	if !ok {
+		return nil, fmt.Errorf("unknown piece set %q", o.pieceSet)
+	}
+	style, ok := pieceStyles[o.pieceStyle]
+	if !ok {
+		return nil, fmt.Errorf("unknown piece style %q, want one of %s", o.pieceStyle, pieceStyleNames())
+	}
+	if err := o.checkVariant(); err != nil {
+		return nil, err
+	}
+	var mouse bool
+	switch o.mouse {
+	case "on":
+		mouse = true
+	case "off":
+	case "auto", "":
+		mouse = mouseSupported()
+	default:
+		return nil, fmt.Errorf("unknown -mouse setting %q, want on, off or auto", o.mouse)
+	}
+
+	prefs, err := loadPreferences()
+	if err != nil {
+		fmt.Println("Cannot read preferences:", err)
+	}
+	if o.lang != "" {
+		if _, ok := languages[o.lang]; !ok {
+			return nil, fmt.Errorf("unknown language %q, want one of %s", o.lang, languageNames())
+		}
+		if o.lang != prefs.Lang {
+			prefs.Lang = o.lang
+			if err := prefs.save(); err != nil {
+				fmt.Println("Could not save preferences:", err)
+			}
+		}
+	}
+	if prefs.Lang != "" {
+		if err := setLanguage(prefs.Lang); err != nil {
+			fmt.Println("Preferences:", err)
+		}
+	}
+	if err := loadTexts(); err != nil {
+		fmt.Println("Cannot read messages:", err)
+	}
+	if err := setPieceValues(prefs.PieceValues); err != nil {
+		return nil, fmt.Errorf("preferences: %v", err)
+	}
+	if o.soundTheme != "" || o.soundCmd != "" {
+		if o.soundTheme != "" {
+			prefs.Sound = o.soundTheme
+		}
+		if o.soundCmd != "" {
+			prefs.SoundCmd = o.soundCmd
+		}
+		if err := prefs.save(); err != nil {
+			fmt.Println("Could not save preferences:", err)
+		}
+	}
+	sound, err := newSoundPlayer(prefs.Sound, prefs.SoundCmd)
+	if err != nil {
+		return nil, fmt.Errorf("sound: %v", err)
+	}
+	return &setup{opts: o, mouse: mouse, glyphs: glyphs, style: style, prefs: prefs, sound: sound}, nil
+}
+
+// newGame returns a game configured from the setup.
+func (s *setup) newGame() *Game {
+	g := NewGame()
+	g.ackTimeout = s.opts.ackTimeout
+	g.sendDelay = s.opts.sendDelay
+	g.maxPremoves = max(s.opts.maxPremoves, 1)
+	g.touchMove = s.opts.touchMove
+	g.glyphs = s.glyphs
+	g.pieceStyle = s.style
+	g.wrapCursor = s.opts.wrapCursor
+	g.sound = s.sound
+	g.applyPreferences(&s.prefs)
+	g.autosaveDir = s.opts.autosaveDir
+	g.localName = s.opts.name
+	g.strict = s.opts.strict
+	g.freestyle = s.opts.freestyle
+	g.rules = variants[s.opts.variant]
+	if s.resume != nil {
+		g.copyPosition(s.resume)
+		g.message = tr(txtContinuing, len(g.moveHistory), colorName(g.currentPlayer))
+		if s.handicap != "" {
+			g.message = s.handicap
+		}
+	}
+	if !s.mouse {
+		g.keyboardOnly = true
+		g.message = tr(txtKeyboardHint)
+	}
+	return g
+}
+
+// selfPlayGame returns a game configured from the setup for two bots to
+// play, with nothing drawn or sounded. It keeps the move limit and the
+// clock as a host would.
+func (s *setup) selfPlayGame() *Game {
+	g := s.newGame()
+	g.headless = true
+	g.sound = nil
+	g.hosting = true
+	g.maxMoves, g.maxMovesByMaterial = s.opts.maxMoves, s.opts.maxMovesByMaterial
+	if tc := s.opts.timeControl(); tc.base > 0 {
+		g.startClock(tc)
+	}
+	return g
+}
+
+// playNetworked plays a game over conn as player once the two sides agree
+// on the protocol. A host continuing an earlier game sends it to the
+// joiner first; a joiner accepts such a game from its host.
+func (s *setup) playNetworked(conn net.Conn, player string, hosting bool) {
+	capabilities := gameCapabilities(s.opts.freestyle, s.opts.variant)
+	if s.opts.ackTimeout > 0 {
+		capabilities = append(capabilities, capabilityAck)
+	}
+	peer, err := handshake(conn, capabilities)
+	if err == nil {
+		peer.variant, err = settleVariant(s.opts.variant, peer.variant, hosting)
+	}
+	if err != nil {
+		fmt.Println("Cannot start game:", err)
+		conn.Close()
+		return
+	}
+
+	game := s.newGame()
+	game.rules = variants[peer.variant]
+	game.sendAcks = peer.acks
+	game.settingUp = !hosting
+	game.hosting = hosting
+	if hosting {
+		if s.resume != nil {
+			game.sendSetup(conn)
+		}
+		game.idleTimeout = s.opts.idleTimeout
+		game.idleDraw = s.opts.idleDraw
+		game.onAdjudicate = func(verb string) {
+			sendMessage(conn, message{kind: msgControl, arg: verb})
+		}
+		game.lock.Lock()
+		game.maxMoves, game.maxMovesByMaterial = s.opts.maxMoves, s.opts.maxMovesByMaterial
+		sendMessage(conn, message{kind: msgControl, arg: game.moveLimitVerb()})
+		if tc := s.opts.timeControl(); tc.base > 0 {
+			game.startClock(tc)
+			sendMessage(conn, message{kind: msgControl, arg: ctrlClock + " " + tc.String()})
+		}
+		if s.opts.readyCheck {
+			game.startReadyCheck()
+			sendMessage(conn, message{kind: msgControl, arg: ctrlReadyCheck})
+		}
+		game.armIdleTimer()
+		game.lock.Unlock()
+	}
+	if s.opts.printMoves {
+		// Deferred before termbox.Close so it prints to the restored terminal.
+		defer func() { fmt.Println(game.moveList()) }()
+	}
+	defer game.printAnalysisLink()
+	startTerminal(s.inputMode())
+	defer termbox.Close()
+	game.play(conn, player)
+}
+
+// watch follows a served game over conn as a spectator, drawn from black's
+// side if black is set. If follow names a player, the board turns whenever
+// they turn theirs.
+func (s *setup) watch(conn net.Conn, black bool, follow string) {
+	peer, err := handshake(conn, gameCapabilities(s.opts.freestyle, s.opts.variant))
+	if err == nil {
+		peer.variant, err = settleVariant(s.opts.variant, peer.variant, false)
+	}
+	if err != nil {
+		fmt.Println("Cannot watch game:", err)
+		conn.Close()
+		return
+	}
+
+	game := s.newGame()
+	game.rules = variants[peer.variant]
+	game.spectating = true
+	game.settingUp = true
+	game.flipped = black
+	game.follow = follow
+	game.message = tr(txtWatching)
+	if follow != "" {
+		game.message = tr(txtWatchingFollow, colorName(follow))
+	}
+	defer game.printAnalysisLink()
+	startTerminal(s.inputMode())
+	defer termbox.Close()
+	game.play(readOnlyConn{conn}, "")
+}
+
+// replay shows a saved game in the replay viewer.
+func (s *setup) replay(path string) {
+	frames, err := loadReplay(path)
+	if err != nil {
+		fmt.Println("Failed to load replay:", err)
+		return
+	}
+	startTerminal(termbox.InputEsc)
+	defer termbox.Close()
+	viewer := NewGame()
+	viewer.glyphs = s.glyphs
+	viewer.pieceStyle = s.style
+	viewer.applyPreferences(&s.prefs)
+	runReplay(viewer, frames)
+}
+
+// mouseSupported guesses whether the terminal reports mouse clicks. Terminal
+// emulators generally do; the Linux console and dumb or serial terminals
+// don't, and there is no way to ask.
+func mouseSupported() bool {
+	switch os.Getenv("TERM") {
+	case "", "dumb", "linux", "vt100", "vt102", "vt220":
+		return false
+	}
+	return true
+}
+
+// inputMode is the termbox input mode for a playable board.
+func (s *setup) inputMode() termbox.InputMode {
+	if s.mouse {
+		return termbox.InputEsc | termbox.InputMouse
+	}
+	return termbox.InputEsc
+}
+
+// startTerminal takes over the terminal for drawing the board.
+func startTerminal(mode termbox.InputMode) {
+	if err := termbox.Init(); err != nil {
+		panic(err)
+	}
+	termbox.SetOutputMode(termbox.Output256)
+	termbox.SetInputMode(mode)
+	watchSignals()
+}
+
+// newFlagSet returns the flag set for a subcommand, with a usage line
+// naming its arguments.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n", os.Args[0], name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// hostCommand hosts a game and waits for an opponent to join.
+func hostCommand(args []string) {
+	var o options
+	fs := newFlagSet("host", "")
+	o.playFlags(fs)
+	o.idleFlags(fs)
+	o.clockFlags(fs)
+	resume := fs.String("continue", "", "continue the game in this PGN file from its final position")
+	handicap := fs.String("handicap", "", "start with a material handicap: "+handicapNames()+", or a FEN; you play white")
+	fs.Parse(args)
+	if *resume != "" && *handicap != "" {
+		fmt.Println("-continue and -handicap cannot be used together")
+		return
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *resume != "" {
+		if s.resume, err = continuePGN(*resume); err != nil {
+			fmt.Println("Cannot continue game:", err)
+			return
+		}
+	}
+	if *handicap != "" {
+		if s.resume, s.handicap, err = handicapGame(*handicap); err != nil {
+			fmt.Println("Invalid handicap:", err)
+			return
+		}
+	}
+	conn, player, err := hostGame()
+	if err != nil {
+		fmt.Println("Failed to host game:", err)
+		return
+	}
+	s.playNetworked(conn, player, true)
+}
+
+// joinCommand joins the game hosted or served at the given address.
+func joinCommand(args []string) {
+	var o options
+	fs := newFlagSet("join", "<host>")
+	o.playFlags(fs)
+	o.joinFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	conn, player, err := joinGame(fs.Arg(0), o.joinRetry)
+	if err != nil {
+		fmt.Println("Failed to join game:", err)
+		return
+	}
+	s.playNetworked(conn, player, false)
+}
+
+// serveCommand runs the headless game server.
+func serveCommand(args []string) {
+	var o options
+	fs := newFlagSet("serve", "")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	gameLogPath := fs.String("game-log", "", "append every game's moves and result to this JSON lines file")
+	fs.BoolVar(&o.strict, "strict", false, "development aid: check the rules engine after every move and panic on any inconsistency")
+	fs.StringVar(&o.watchAddr, "watch-addr", ":"+watchPort, "address spectators connect to (empty disables watching)")
+	o.idleFlags(fs)
+	o.clockFlags(fs)
+	o.variantFlags(fs)
+	fs.Parse(args)
+	if err := o.checkVariant(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	serveGames(*addr, *gameLogPath, o.serverOptions())
+}
+
+// watchCommand watches a game running on the server at the given address,
+// on the spectator port unless the address names another.
+func watchCommand(args []string) {
+	var o options
+	fs := newFlagSet("watch", "<host>[:<port>]")
+	o.boardFlags(fs)
+	o.variantFlags(fs)
+	id := fs.Int("game", 0, "number of the game to watch (default: the latest one started)")
+	black := fs.Bool("black", false, "view the board from black's side")
+	follow := fs.String("follow", "", "turn the board whenever white or black turns theirs, to see it as they do")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *follow != "" && *follow != "white" && *follow != "black" {
+		fmt.Println("-follow must be white or black")
+		return
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	conn, err := watchGame(fs.Arg(0), *id)
+	if err != nil {
+		fmt.Println("Failed to watch game:", err)
+		return
+	}
+	s.watch(conn, *black, *follow)
+}
+
+// serveGames runs the game server, logging games to gameLogPath if set.
+func serveGames(addr, gameLogPath string, opts serverOptions) {
+	var log *gameLog
+	if gameLogPath != "" {
+		var err error
+		if log, err = openGameLog(gameLogPath); err != nil {
+			fmt.Println("Failed to open game log:", err)
+			return
+		}
+		defer log.Close()
+	}
+	if err := serve(addr, log, opts); err != nil {
+		fmt.Printf("Server stopped: %v\n", err)
+	}
+}
+
+// replayCommand replays a saved game: a PGN file, or a file of moves in
+// wire format, one per line.
+func replayCommand(args []string) {
+	var o options
+	fs := newFlagSet("replay", "<file>")
+	o.boardFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s.replay(fs.Arg(0))
+}
+
+// demoCommand auto-plays a famous game, or the one in the given PGN file,
+// as a showcase.
+func demoCommand(args []string) {
+	var o options
+	fs := newFlagSet("demo", "[file.pgn]")
+	o.boardFlags(fs)
+	delay := fs.Duration("delay", 1500*time.Millisecond, "how long each position is shown")
+	loop := fs.Bool("loop", false, "start the game over at the end instead of quitting")
+	fs.Parse(args)
+	if fs.NArg() > 1 || *delay <= 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s.demo(fs.Arg(0), *delay, *loop)
+}
+
+// trainCommand drills the opening lines in a file (see loadOpeningLines).
+func trainCommand(args []string) {
+	var o options
+	fs := newFlagSet("train", "<file>")
+	o.boardFlags(fs)
+	black := fs.Bool("black", false, "play black's moves; the book plays white's")
+	shuffle := fs.Bool("shuffle", false, "drill the lines in a random order")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	lines, err := loadOpeningLines(fs.Arg(0))
+	if err != nil {
+		fmt.Println("Cannot load lines:", err)
+		return
+	}
+	color := "white"
+	if *black {
+		color = "black"
+	}
+	g := s.newGame()
+	startTerminal(s.inputMode())
+	defer termbox.Close()
+	runTrainer(g, lines, color, *shuffle)
+}
+
+// puzzleCommand sets the puzzles in a file (see readPuzzles), or the
+// bundled ones, to solve.
+func puzzleCommand(args []string) {
+	var o options
+	fs := newFlagSet("puzzle", "[<file>]")
+	o.boardFlags(fs)
+	shuffle := fs.Bool("shuffle", false, "set the puzzles in a random order")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	puzzles, err := loadPuzzles(fs.Arg(0))
+	if err != nil {
+		fmt.Println("Cannot load puzzles:", err)
+		return
+	}
+	g := s.newGame()
+	startTerminal(s.inputMode())
+	defer termbox.Close()
+	runPuzzles(g, puzzles, *shuffle)
+}
+
+// botCommand plays against a built-in bot, or has two bots play each other
+// and prints the game as PGN.
+func botCommand(args []string) {
+	var o options
+	fs := newFlagSet("bot", "")
+	o.playFlags(fs)
+	o.idleFlags(fs)
+	o.clockFlags(fs)
+	white := fs.String("white", "", "the bot that plays white: "+botNames()+" (default: you play white)")
+	black := fs.String("black", "", "the bot that plays black (default: minimax, unless -white is set)")
+	fs.Parse(args)
+	if *white == "" && *black == "" {
+		*black = "minimax"
+	}
+	players := make(map[string]Bot)
+	for color, name := range map[string]string{"white": *white, "black": *black} {
+		if name == "" {
+			continue
+		}
+		newBot, ok := bots[name]
+		if !ok {
+			fmt.Printf("Unknown bot %q, want one of %s.\n", name, botNames())
+			return
+		}
+		players[color] = newBot()
+	}
+
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(players) == 2 {
+		g := s.selfPlayGame()
+		selfPlay(g, players["white"], players["black"])
+		g.writePGN(os.Stdout, time.Now())
+		fmt.Println(g.outcome() + ".")
+		return
+	}
+	player, botColor := "black", "white"
+	if players["white"] == nil {
+		player, botColor = "white", "black"
+	}
+	conn, botConn, err := localPair()
+	if err != nil {
+		fmt.Println("Cannot start the bot:", err)
+		return
+	}
+	go playBot(botConn, botColor, players[botColor], o.freestyle, o.variant)
+	s.playNetworked(conn, player, true)
+}
+
+// resumeCommand lists the saved games in the archive, the -autosave
+// directory, and views, continues or analyzes the one picked. The game and
+// action can be given as arguments, e.g. "resume 3 analyze"; without them
+// the player is asked.
+func resumeCommand(args []string) {
+	var o options
+	fs := newFlagSet("resume", "[<game> [view|continue|analyze]]")
+	o.playFlags(fs)
+	o.idleFlags(fs)
+	o.clockFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dir := o.autosaveDir
+	if dir == "" {
+		dir = "."
+	}
+	games, err := listArchive(dir)
+	if err != nil {
+		fmt.Println("Cannot read saved games:", err)
+		return
+	}
+	if len(games) == 0 {
+		fmt.Printf("No saved games in %s.\n", dir)
+		return
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if fs.NArg() == 0 {
+		game, action := pickArchivedGame(games)
+		if action != "" {
+			s.openArchivedGame(game, action)
+		}
+		return
+	}
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || id < 1 || id > len(games) {
+		fmt.Printf("No saved game %q; there are %d in %s.\n", fs.Arg(0), len(games), dir)
+		return
+	}
+	action := "view"
+	if fs.NArg() == 2 {
+		action = fs.Arg(1)
+	}
+	if action != "view" && action != "continue" && action != "analyze" {
+		fmt.Printf("Unknown action %q, want view, continue or analyze.\n", action)
+		return
+	}
+	s.openArchivedGame(games[id-1], action)
+}
+
+// analyzeCommand opens an analysis board on a position given as FEN. The
+// FEN may be passed as one quoted argument or as separate fields.
+func analyzeCommand(args []string) {
+	var o options
+	fs := newFlagSet("analyze", "<fen>")
+	o.boardFlags(fs)
+	o.variantFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g := s.newGame()
+	if err := g.loadFEN(strings.Join(fs.Args(), " ")); err != nil {
+		fmt.Println("Invalid position:", err)
+		return
+	}
+	defer g.printAnalysisLink()
+	startTerminal(s.inputMode())
+	defer termbox.Close()
+	g.analysisBoard()
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// The host or server sets the time control for both players and sends it
+// before the game starts, e.g. "clock 5m0s 3s bronstein". Every side then
+// runs both clocks from the moves as they are played. Only the host or
+// server decides when a flag falls, and tells the players with the side
+// whose time has run out, e.g. "flag black". The joiner's own clock flags
+// do not matter.
+const (
+	ctrlClock = "clock"
+	ctrlFlag  = "flag"
+)
+
+// timeStrategy is how a time control charges a player for their moves.
+// bonus is the time control's increment or delay.
+type timeStrategy interface {
+	// charged is what a move that took used costs the main clock, once it
+	// is played. It is negative when the move gives time back.
+	charged(used, bonus time.Duration) time.Duration
+	// running is how much of elapsed, the time spent so far on the move
+	// being thought about, the main clock already shows as gone.
+	running(elapsed, bonus time.Duration) time.Duration
+	// delayLeft is how much of a delay is left to run before the main
+	// clock starts, for time controls that have one.
+	delayLeft(elapsed, bonus time.Duration) time.Duration
+}
+
+// fischer adds the increment after every move.
+type fischer struct{}
+
+func (fischer) charged(used, bonus time.Duration) time.Duration { return used - bonus }
+func (fischer) running(elapsed, _ time.Duration) time.Duration  { return elapsed }
+func (fischer) delayLeft(_, _ time.Duration) time.Duration      { return 0 }
+
+// bronstein gives back the time a move used, up to the delay, so the clock
+// never gains time.
+type bronstein struct{}
+
+func (bronstein) charged(used, bonus time.Duration) time.Duration { return used - min(used, bonus) }
+func (bronstein) running(elapsed, _ time.Duration) time.Duration  { return elapsed }
+func (bronstein) delayLeft(_, _ time.Duration) time.Duration      { return 0 }
+
+// simpleDelay waits for the delay to run out before the main clock starts
+// ticking on each move, as in US delay.
+type simpleDelay struct{}
+
+func (simpleDelay) charged(used, bonus time.Duration) time.Duration {
+	return max(used-bonus, 0)
+}
+func (simpleDelay) running(elapsed, bonus time.Duration) time.Duration {
+	return max(elapsed-bonus, 0)
+}
+func (simpleDelay) delayLeft(elapsed, bonus time.Duration) time.Duration {
+	return max(bonus-elapsed, 0)
+}
+
+// timeStrategies are the time control modes, by the name -time-mode and
+// the clock control message use.
+var timeStrategies = map[string]timeStrategy{
+	"increment": fischer{},
+	"bronstein": bronstein{},
+	"delay":     simpleDelay{},
+}
+
+// timeControl is the time each player starts with and the increment or
+// delay, applied as mode says. A zero base means the game has no clock.
+type timeControl struct {
+	base  time.Duration
+	bonus time.Duration
+	mode  string // A key of timeStrategies
+}
+
+// String formats tc as it is sent in the clock control message, e.g.
+// "5m0s 3s bronstein".
+func (tc timeControl) String() string {
+	return fmt.Sprintf("%s %s %s", tc.base, tc.bonus, tc.mode)
+}
+
+// parseTimeControl parses a time control formatted by String.
+func parseTimeControl(s string) (timeControl, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return timeControl{}, fmt.Errorf("bad time control %q", s)
+	}
+	base, err := time.ParseDuration(fields[0])
+	if err != nil || base <= 0 {
+		return timeControl{}, fmt.Errorf("bad time control %q", s)
+	}
+	bonus, err := time.ParseDuration(fields[1])
+	if err != nil || bonus < 0 {
+		return timeControl{}, fmt.Errorf("bad time control %q", s)
+	}
+	if _, ok := timeStrategies[fields[2]]; !ok {
+		return timeControl{}, fmt.Errorf("unknown time mode %q", fields[2])
+	}
+	return timeControl{base: base, bonus: bonus, mode: fields[2]}, nil
+}
+
+// timeSource tells clocks the time and sets the timer that watches for a
+// flag to fall. realTime is the wall clock; a test can put in a source it
+// advances by hand, to check every time control without sleeping.
+type timeSource interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) timer
+}
+
+// timer is a call waiting in a timeSource, as *time.Timer is.
+type timer interface {
+	Stop() bool
+}
+
+// realTime is the wall clock.
+type realTime struct{}
+
+func (realTime) Now() time.Time {
+	return time.Now()
+}
+
+func (realTime) AfterFunc(d time.Duration, f func()) timer {
+	return time.AfterFunc(d, f)
+}
+
+// Clock is a pair of chess clocks run under one time control. White's
+// clock starts once white has made the first move, so neither side loses
+// time before both are playing.
+type Clock struct {
+	control  timeControl
+	strategy timeStrategy
+	source   timeSource
+	left     map[string]time.Duration // Each side's main clock, as of the start of the current move
+	used     map[string]time.Duration // Time each side has spent on its moves, as of the start of the current move
+	turn     string                   // Side whose clock is running, "" before the first move and once stopped
+	since    time.Time                // When the running clock started
+	before   *Clock                   // The clocks as they were before the last Press, for Unpress
+}
+
+// newClock returns stopped clocks for tc, both showing its base time,
+// reading the time from source, or the wall clock if it is nil.
+func newClock(tc timeControl, source timeSource) *Clock {
+	if source == nil {
+		source = realTime{}
+	}
+	return &Clock{
+		control:  tc,
+		strategy: timeStrategies[tc.mode],
+		source:   source,
+		left:     map[string]time.Duration{"white": tc.base, "black": tc.base},
+		used:     map[string]time.Duration{"white": 0, "black": 0},
+	}
+}
+
+// Remaining is how much time color has left on the main clock now.
+func (c *Clock) Remaining(color string) time.Duration {
+	left := c.left[color]
+	if c.turn == color {
+		left -= c.strategy.running(c.source.Now().Sub(c.since), c.control.bonus)
+	}
+	return left
+}
+
+// Used is how much time color has spent thinking over the game so far,
+// whatever the increments or delays gave back.
+func (c *Clock) Used(color string) time.Duration {
+	used := c.used[color]
+	if c.turn == color {
+		used += c.source.Now().Sub(c.since)
+	}
+	return used
+}
+
+// DelayLeft is how much of the current move's delay is left, for time
+// controls with a delay before the main clock runs.
+func (c *Clock) DelayLeft() time.Duration {
+	if c.turn == "" {
+		return 0
+	}
+	return c.strategy.delayLeft(c.source.Now().Sub(c.since), c.control.bonus)
+}
+
+// Running reports whether either clock is running.
+func (c *Clock) Running() bool {
+	return c.turn != ""
+}
+
+// Press ends color's move: their clock is charged for it under the time
+// control, and the opponent's clock starts.
+func (c *Clock) Press(color string) {
+	c.before = &Clock{left: maps.Clone(c.left), used: maps.Clone(c.used), turn: c.turn, since: c.since}
+	now := c.source.Now()
+	if c.turn == color {
+		c.left[color] -= c.strategy.charged(now.Sub(c.since), c.control.bonus)
+		c.used[color] += now.Sub(c.since)
+	}
+	c.turn, c.since = opponent(color), now
+}
+
+// Unpress undoes the last Press, as if the move had never been made: the
+// mover's clock has been running all along and the opponent's has not.
+func (c *Clock) Unpress() {
+	if c.before != nil {
+		c.left, c.used, c.turn, c.since = c.before.left, c.before.used, c.before.turn, c.before.since
+		c.before = nil
+	}
+}
+
+// Switch moves the running clock to color without finishing a move, as
+// when one is taken back: the time spent so far is charged, but no
+// increment or delay is given back for it.
+func (c *Clock) Switch(color string) {
+	c.Stop()
+	c.turn, c.since = color, c.source.Now()
+}
+
+// Stop stops the running clock, keeping the time it showed.
+func (c *Clock) Stop() {
+	if c.turn != "" {
+		c.left[c.turn], c.used[c.turn] = c.Remaining(c.turn), c.Used(c.turn)
+	}
+	c.turn = ""
+}
+
+// untilFlag is how long until the running clock shows zero, counting any
+// delay still to run.
+func (c *Clock) untilFlag() time.Duration {
+	return c.Remaining(c.turn) + c.DelayLeft()
+}
+
+// formatClock shows a clock reading as m:ss, or h:mm:ss, with tenths in
+// the last ten seconds.
+func formatClock(d time.Duration) string {
+	d = max(d, 0)
+	switch {
+	case d < 10*time.Second:
+		return fmt.Sprintf("0:%04.1f", d.Truncate(100*time.Millisecond).Seconds())
+	case d < time.Hour:
+		d = d.Truncate(time.Second)
+		return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+// clockStatus is the info bar's clock reading, e.g. "White 4:32, Black
+// 5:00 (delay 0:02.0)", or "" in a game without clocks. The caller holds
+// g.lock.
+func (g *Game) clockStatus() string {
+	if g.clock == nil {
+		return ""
+	}
+	status := tr(txtClockStatus, formatClock(g.clock.Remaining("white")), formatClock(g.clock.Remaining("black")))
+	if delay := g.clock.DelayLeft(); delay > 0 {
+		status += " " + tr(txtClockDelay, formatClock(delay))
+	}
+	return status
+}
+
+// startClock gives the game clocks for tc, as the host or server does
+// before the first move. The caller holds g.lock.
+func (g *Game) startClock(tc timeControl) {
+	g.clock = newClock(tc, g.timeSource)
+}
+
+// pressClock ends mover's move on the clock and, on the host or server,
+// watches for the opponent's flag to fall. The moves of a continued game
+// are replayed before the clock control message arrives, so they are never
+// timed. The caller holds g.lock.
+func (g *Game) pressClock(mover string) {
+	if g.clock == nil {
+		return
+	}
+	g.clock.Press(mover)
+	g.armClockTimer()
+}
+
+// armClockTimer restarts the timer that ends the game when the running
+// clock reaches zero. Only the host or server keeps one. The caller holds
+// g.lock.
+func (g *Game) armClockTimer() {
+	if !g.hosting || g.clock == nil || !g.clock.Running() || g.gameOver {
+		return
+	}
+	if g.clockTimer != nil {
+		g.clockTimer.Stop()
+	}
+	g.clockSeq++
+	seq := g.clockSeq
+	g.clockTimer = g.clock.source.AfterFunc(g.clock.untilFlag(), func() { g.clockExpired(seq) })
+}
+
+// clockExpired ends the game on time against the side to move, unless a
+// move has been made since the timer was armed.
+func (g *Game) clockExpired(seq int) {
+	g.lock.Lock()
+	if g.gameOver || seq != g.clockSeq || !g.clock.Running() {
+		g.lock.Unlock()
+		return
+	}
+	if g.clock.untilFlag() > 0 {
+		// The timer fired a little early; wait out the rest.
+		g.armClockTimer()
+		g.lock.Unlock()
+		return
+	}
+	loser := g.currentPlayer
+	g.flagFall(loser)
+	notify := g.onAdjudicate
+	g.lock.Unlock()
+
+	if notify != nil {
+		notify(ctrlFlag + " " + loser)
+	}
+	if !g.headless {
+		termbox.Interrupt() // Wake the event loop to show the result
+	}
+}
+
+// flagFall ends the game as a loss for loser, whose time has run out, or
+// as a draw if the opponent has only a king left and so could never have
+// won. The caller holds g.lock.
+func (g *Game) flagFall(loser string) {
+	if g.loneKing(opponent(loser)) && !g.freestyle {
+		g.endGame(resultDraw, "time forfeit", tr(txtTimeLoneKing, colorName(loser)))
+		return
+	}
+	message := tr(txtTimeRanOut, colorName(loser))
+	switch loser {
+	case g.playerColor:
+		message = tr(txtTimeYouLose)
+	case opponent(g.playerColor):
+		message = tr(txtTimeOpponentLost)
+	}
+	g.endGame(winResult(opponent(loser)), "time forfeit", message)
+}
+
+// applyClock acts on the clock and flag control messages, which only come
+// from the host or server. The caller holds g.lock.
+func (g *Game) applyClock(verb, arg string) error {
+	if g.hosting {
+		return fmt.Errorf("%q: %w", verb, errNotAuthority)
+	}
+	if verb == ctrlFlag {
+		if g.clock == nil && !g.spectating || arg != "white" && arg != "black" {
+			return fmt.Errorf("%q: %w", verb, errUnknownControl)
+		}
+		g.flagFall(arg)
+		return nil
+	}
+	if !g.settingUp {
+		return fmt.Errorf("%q: %w", verb, errGameStarted)
+	}
+	tc, err := parseTimeControl(arg)
+	if err != nil {
+		return err
+	}
+	g.startClock(tc)
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeControls(t *testing.T) {
+	// Both sides start with a minute and a 2s bonus. Black spends 5s on a
+	// move, then 1s, less than the bonus, then is three seconds into a
+	// third. White moves instantly.
+	tests := []struct {
+		mode     string
+		slow     time.Duration // Black's clock after the 5s move
+		during   time.Duration // Black's clock one second into the next
+		delay    time.Duration // Delay left then
+		fast     time.Duration // Black's clock after the 1s move
+		thinking time.Duration // Black's clock three seconds into the third
+	}{
+		{"increment", 57 * time.Second, 56 * time.Second, 0, 58 * time.Second, 55 * time.Second},
+		{"bronstein", 57 * time.Second, 56 * time.Second, 0, 57 * time.Second, 54 * time.Second},
+		{"delay", 57 * time.Second, 57 * time.Second, time.Second, 57 * time.Second, 56 * time.Second},
+	}
+	for _, tt := range tests {
+		ft := &fakeTime{now: time.Unix(0, 0)}
+		c := newClock(timeControl{base: time.Minute, bonus: 2 * time.Second, mode: tt.mode}, ft)
+		c.Press("white") // White's first move is not timed
+		ft.advance(5 * time.Second)
+		c.Press("black")
+		if got := c.Remaining("black"); got != tt.slow {
+			t.Errorf("%s: black has %v after a 5s move, want %v", tt.mode, got, tt.slow)
+		}
+		c.Press("white")
+		ft.advance(time.Second)
+		if got := c.Remaining("black"); got != tt.during {
+			t.Errorf("%s: black's clock shows %v one second in, want %v", tt.mode, got, tt.during)
+		}
+		if got := c.DelayLeft(); got != tt.delay {
+			t.Errorf("%s: delay left %v one second in, want %v", tt.mode, got, tt.delay)
+		}
+		c.Press("black")
+		if got := c.Remaining("black"); got != tt.fast {
+			t.Errorf("%s: black has %v after a 1s move, want %v", tt.mode, got, tt.fast)
+		}
+		c.Press("white")
+		ft.advance(3 * time.Second)
+		if got := c.Remaining("black"); got != tt.thinking {
+			t.Errorf("%s: black's clock shows %v three seconds in, want %v", tt.mode, got, tt.thinking)
+		}
+		if got := c.Used("black"); got != 9*time.Second {
+			t.Errorf("%s: black used %v, want 9s", tt.mode, got)
+		}
+	}
+}
+
+func TestFlagNamesLoser(t *testing.T) {
+	tests := []struct {
+		verb   string
+		result string
+		err    error
+	}{
+		{"flag black", resultWhiteWins, nil},
+		{"flag white", resultBlackWins, nil},
+		{"flag", resultOngoing, errUnknownControl},
+		{"flag green", resultOngoing, errUnknownControl},
+	}
+	for _, tt := range tests {
+		// White to move, but the host saw black's flag fall first.
+		g := newTestGame(t, "")
+		g.playerColor = "black"
+		g.startClock(timeControl{base: time.Minute, mode: "increment"})
+		playMoves(t, g, "e2e4", "e7e5")
+		g.lock.Lock()
+		if err := g.applyControl("white", tt.verb); !errors.Is(err, tt.err) {
+			t.Errorf("%q: got %v, want %v", tt.verb, err, tt.err)
+		}
+		if g.result != tt.result {
+			t.Errorf("%q: result %q, want %q", tt.verb, g.result, tt.result)
+		}
+		g.lock.Unlock()
+	}
+
+	// Only the host or server says whose flag fell.
+	host := newTestGame(t, "")
+	host.playerColor, host.hosting = "white", true
+	host.startClock(timeControl{base: time.Minute, mode: "increment"})
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	if err := host.applyControl("black", "flag white"); !errors.Is(err, errNotAuthority) {
+		t.Errorf("host took a flag from the joiner: %v", err)
+	}
+}
+
+func TestFlagAgainstLoneKingDraws(t *testing.T) {
+	g := newTestGame(t, "4k3/8/8/8/8/8/8/Q3K3 b - - 0 1")
+	g.playerColor = "white"
+	g.startClock(timeControl{base: time.Minute, mode: "increment"})
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	// White runs out with a queen against black's lone king: black could
+	// never have mated, so it is a draw.
+	if err := g.applyControl("black", "flag white"); err != nil {
+		t.Fatal(err)
+	}
+	if g.result != resultDraw || g.termination != "time forfeit" {
+		t.Errorf("result %q, termination %q, want a draw on time", g.result, g.termination)
+	}
+}
+
+func TestHostFlagFall(t *testing.T) {
+	host, ft, sent := hostWithClock(t, timeControl{base: 10 * time.Second, mode: "increment"})
+	playMoves(t, host, "e2e4")
+	ft.advance(10 * time.Second)
+	verbs := *sent
+	if len(verbs) != 1 || verbs[0] != "flag black" {
+		t.Fatalf("host sent %q, want [flag black]", verbs)
+	}
+	if host.result != resultWhiteWins || host.message != tr(txtTimeOpponentLost) {
+		t.Errorf("host: result %q, message %q", host.result, host.message)
+	}
+
+	// The joiner ends the game the same way from the message.
+	joiner := newTestGame(t, "")
+	joiner.playerColor = "black"
+	joiner.startClock(timeControl{base: 10 * time.Second, mode: "increment"})
+	playMoves(t, joiner, "e2e4")
+	joiner.lock.Lock()
+	defer joiner.lock.Unlock()
+	if err := joiner.applyControl("white", verbs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if joiner.result != resultWhiteWins || joiner.message != tr(txtTimeYouLose) {
+		t.Errorf("joiner: result %q, message %q", joiner.result, joiner.message)
+	}
+}
+
+// hostWithClock is a host playing white under tc, with time standing
+// still until the test advances it, and the flag verbs it sends.
+func hostWithClock(t *testing.T, tc timeControl) (*Game, *fakeTime, *[]string) {
+	ft := &fakeTime{now: time.Unix(0, 0)}
+	host := newTestGame(t, "")
+	host.playerColor, host.hosting, host.timeSource = "white", true, ft
+	verbs := &[]string{}
+	host.onAdjudicate = func(verb string) { *verbs = append(*verbs, verb) }
+	host.startClock(tc)
+	return host, ft, verbs
+}
+
+func TestClockTimerFiringEarlyRearms(t *testing.T) {
+	host, ft, verbs := hostWithClock(t, timeControl{base: 10 * time.Second, mode: "increment"})
+	playMoves(t, host, "e2e4")
+	ft.advance(9 * time.Second)
+	// The timer goes off a second early, as a real one may.
+	host.clockExpired(host.clockSeq)
+	if host.gameOver || len(*verbs) != 0 {
+		t.Fatalf("flag fell with a second left: %q", *verbs)
+	}
+	ft.advance(time.Second)
+	if !host.gameOver || len(*verbs) != 1 || (*verbs)[0] != "flag black" {
+		t.Errorf("the re-armed timer sent %q, want [flag black]", *verbs)
+	}
+}
+
+func TestClockTimerOutlivedByMove(t *testing.T) {
+	host, ft, verbs := hostWithClock(t, timeControl{base: 10 * time.Second, mode: "increment"})
+	playMoves(t, host, "e2e4")
+	stale := host.clockSeq
+	ft.advance(5 * time.Second)
+	playMoves(t, host, "e7e5")
+	// The timer armed for black's move fires after black has moved.
+	host.clockExpired(stale)
+	if host.gameOver || len(*verbs) != 0 {
+		t.Errorf("a stale timer ended the game: %q", *verbs)
+	}
+}
+
+func TestDelayCountsTowardFlag(t *testing.T) {
+	host, ft, verbs := hostWithClock(t, timeControl{base: 10 * time.Second, bonus: 3 * time.Second, mode: "delay"})
+	playMoves(t, host, "e2e4")
+	if got := host.clock.untilFlag(); got != 13*time.Second {
+		t.Errorf("untilFlag = %v at the start of the move, want 13s", got)
+	}
+	ft.advance(2 * time.Second)
+	if got := host.clock.untilFlag(); got != 11*time.Second {
+		t.Errorf("untilFlag = %v two seconds in, want 11s", got)
+	}
+	ft.advance(10 * time.Second)
+	if host.gameOver {
+		t.Fatalf("flag fell after 12s with a 3s delay and 10s on the clock")
+	}
+	ft.advance(time.Second)
+	if len(*verbs) != 1 || (*verbs)[0] != "flag black" {
+		t.Errorf("after 13s the host sent %q, want [flag black]", *verbs)
+	}
+}
+
+func TestClockUnpressAndSwitch(t *testing.T) {
+	ft := &fakeTime{now: time.Unix(0, 0)}
+	c := newClock(timeControl{base: time.Minute, bonus: 2 * time.Second, mode: "increment"}, ft)
+	c.Press("white")
+	ft.advance(5 * time.Second)
+	c.Press("black")
+	ft.advance(time.Second)
+
+	// Taking back black's move puts black back on the move, with the five
+	// seconds spent and the one since still running.
+	c.Unpress()
+	if left, used := c.Remaining("black"), c.Used("black"); left != 54*time.Second || used != 6*time.Second {
+		t.Errorf("after Unpress black has %v left and used %v, want 54s and 6s", left, used)
+	}
+	if left, used := c.Remaining("white"), c.Used("white"); left != time.Minute || used != 0 {
+		t.Errorf("after Unpress white has %v left and used %v, want 1m0s and 0s", left, used)
+	}
+	c.Unpress() // Only the last Press can be undone
+	if c.turn != "black" {
+		t.Errorf("a second Unpress moved the clock to %q", c.turn)
+	}
+
+	// Switching charges the time spent but gives no increment for it.
+	c.Switch("white")
+	ft.advance(4 * time.Second)
+	if left, used := c.Remaining("black"), c.Used("black"); left != 54*time.Second || used != 6*time.Second {
+		t.Errorf("after Switch black has %v left and used %v, want 54s and 6s", left, used)
+	}
+	if left, used := c.Remaining("white"), c.Used("white"); left != 56*time.Second || used != 4*time.Second {
+		t.Errorf("white's clock shows %v left and %v used four seconds after Switch, want 56s and 4s", left, used)
+	}
+}
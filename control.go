@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Control verbs, the payload of a CTRL message. A player may resign or
+// abort, or make an offer that the opponent answers, e.g. "draw offer"
+// followed by "draw accept" or "draw decline". An unanswered offer lapses
+// when either side moves.
+const (
+	ctrlResign   = "resign"
+	ctrlAbort    = "abort"    // End the game with no result, allowed while abortable
+	ctrlDraw     = "draw"     // Offer to end the game as a draw by agreement
+	ctrlTakeback = "takeback" // Ask to take back your own last move
+	ctrlLeave    = "leave"    // Sent just before closing the connection on purpose
+
+	ctrlOffer   = "offer"
+	ctrlAccept  = "accept"
+	ctrlDecline = "decline"
+)
+
+// abortPlies is how many moves into the game it can still be aborted:
+// until both players have made their first move, leaving is a no-contest
+// rather than a loss.
+const abortPlies = 2
+
+// heartbeatInterval is how often an HB message is sent on an idle
+// connection.
+const heartbeatInterval = 15 * time.Second
+
+// Errors returned for control messages that cannot be acted on.
+var (
+	errUnknownControl    = errors.New("unknown control message")
+	errNoOffer           = errors.New("no such offer is pending")
+	errNothingToTakeBack = errors.New("only the player who just moved can take it back")
+	errTooLateToAbort    = errors.New("too late to abort; resign instead")
+	errNotAuthority      = errors.New("only the host or server can send that")
+)
+
+// applyControl acts on a control verb sent by the player of color from.
+// Both clients and the server run every control message through it, so
+// they agree on offers and results, and it logs every one it accepts. The
+// caller holds g.lock.
+func (g *Game) applyControl(from, verb string) (err error) {
+	defer func() {
+		if err == nil {
+			g.logControl(from, verb)
+		}
+	}()
+	if verb == ctrlLeave {
+		// Leaving is allowed at any time, the game over or not.
+		if from != g.playerColor {
+			g.opponentQuit = true
+		}
+		return nil
+	}
+	if g.gameOver {
+		return fmt.Errorf("%q: %w", verb, ErrGameOver)
+	}
+	switch verb {
+	case ctrlResign:
+		g.endGame(winResult(opponent(from)), "resignation", g.byWhom(from, tr(txtResignedYou), tr(txtResignedOpponent)))
+		return nil
+	case ctrlAbort:
+		if !g.abortable() {
+			return fmt.Errorf("%q: %w", verb, errTooLateToAbort)
+		}
+		g.endGame(resultOngoing, "aborted", g.byWhom(from, tr(txtAbortedYou), tr(txtAbortedOpponent)))
+		return nil
+	case ctrlReady, ctrlReadyCheck:
+		return g.applyReady(from, verb)
+	}
+	offer, action, _ := strings.Cut(verb, " ")
+	if offer == ctrlClock || offer == ctrlFlag {
+		return g.applyClock(offer, action)
+	}
+	if offer == ctrlMaxMoves {
+		return g.applyMoveLimit(offer, action)
+	}
+	if offer == ctrlSpectators {
+		return g.applySpectators(verb, action)
+	}
+	if offer == ctrlView {
+		return g.applyView(from, verb, action)
+	}
+	if offer == ctrlAdjudicate {
+		// The verdict stands as long as it is one the host could reach:
+		// the idle side loses or the game is drawn.
+		idle, result, _ := strings.Cut(action, " ")
+		switch {
+		case g.hosting:
+			return fmt.Errorf("%q: %w", verb, errNotAuthority)
+		case idle != "white" && idle != "black", result != resultDraw && result != winResult(opponent(idle)):
+			return fmt.Errorf("%q: %w", verb, errUnknownControl)
+		}
+		g.adjudicate(idle, result)
+		return nil
+	}
+	if offer != ctrlDraw && offer != ctrlTakeback {
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+
+	switch action {
+	case ctrlOffer:
+		if offer == ctrlTakeback && (len(g.moveHistory) == 0 || g.currentPlayer == from) {
+			return fmt.Errorf("%q: %w", verb, errNothingToTakeBack)
+		}
+		g.offer, g.offerFrom = offer, from
+		if offer == ctrlDraw {
+			g.message = g.byWhom(from, tr(txtDrawOfferYou), tr(txtDrawOfferOpponent))
+		} else {
+			g.message = g.byWhom(from, tr(txtTakebackYou), tr(txtTakebackOpponent))
+		}
+	case ctrlAccept, ctrlDecline:
+		if g.offer != offer || g.offerFrom != opponent(from) {
+			return fmt.Errorf("%q: %w", verb, errNoOffer)
+		}
+		g.offer, g.offerFrom = "", ""
+		switch {
+		case action == ctrlDecline:
+			g.message = g.byWhom(from, tr(txtDeclinedYou), tr(txtDeclinedOpponent))
+		case offer == ctrlDraw:
+			g.endGame(resultDraw, "agreement", tr(txtDrawAgreed))
+		default:
+			g.takeBack()
+			g.message = tr(txtTakenBack)
+		}
+	default:
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+	return nil
+}
+
+// abortable reports whether the game is still early enough to abort, so
+// that leaving it counts for neither side. The caller holds g.lock.
+func (g *Game) abortable() bool {
+	return len(g.moveHistory) < abortPlies
+}
+
+// quitVerb is what leaving the game now amounts to: an abort while the
+// game is abortable, a resignation after that. The caller holds g.lock.
+func (g *Game) quitVerb() string {
+	if g.abortable() {
+		return ctrlAbort
+	}
+	return ctrlResign
+}
+
+// byWhom picks the message for an action by this client's player or by
+// the opponent.
+func (g *Game) byWhom(from, mine, theirs string) string {
+	if from == g.playerColor {
+		return mine
+	}
+	return theirs
+}
+
+// takeBack undoes the last move by replaying the game without it. The
+// caller holds g.lock.
+func (g *Game) takeBack() {
+	r := NewGame()
+	r.freestyle = g.freestyle
+	r.rules = g.rules
+	if g.startFEN != "" {
+		r.loadFEN(g.startFEN)
+	}
+	for _, moveStr := range g.moveHistory[:len(g.moveHistory)-1] {
+		r.ApplyAlgebraic(moveStr, r.currentPlayer)
+	}
+	events := g.events // The log keeps the move taken back
+	g.copyPosition(r)
+	g.events = events
+	g.premoves = nil
+	g.selectedX, g.selectedY = -1, -1
+	g.legalMoves = make(map[string]moveKind)
+	if g.clock != nil && g.clock.Running() {
+		g.clock.Switch(g.currentPlayer)
+		g.armClockTimer()
+	}
+	g.armIdleTimer()
+}
+
+// sendControl plays a control verb for this client's player and, if the
+// game accepts it, sends it to the opponent.
+func (g *Game) sendControl(conn io.Writer, verb string) {
+	if g.analysis {
+		g.message = tr(txtNotOnAnalysis)
+		return
+	}
+	if g.spectating {
+		g.message = tr(txtSpectatorAction)
+		return
+	}
+	g.lock.Lock()
+	disconnected := g.disconnected
+	g.lock.Unlock()
+	if disconnected {
+		g.message = tr(txtOpponentGone)
+		return
+	}
+	g.flushHeldMove(conn)
+	g.lock.Lock()
+	err := g.applyControl(g.playerColor, verb)
+	if err != nil {
+		g.message = tr(txtCannotDo, err)
+	}
+	g.lock.Unlock()
+	if err == nil {
+		sendMessage(conn, message{kind: msgControl, arg: verb})
+	}
+}
+
+// respond offers a draw or takeback, or accepts the same offer if the
+// opponent has already made it.
+func (g *Game) respond(conn io.Writer, offer string) {
+	action := ctrlOffer
+	if g.offer == offer && g.offerFrom != g.playerColor {
+		action = ctrlAccept
+	}
+	g.sendControl(conn, offer+" "+action)
+}
+
+// declineOffer declines the opponent's pending offer.
+func (g *Game) declineOffer(conn io.Writer) {
+	if g.offer == "" || g.offerFrom == g.playerColor {
+		g.message = tr(txtNoOffer)
+		return
+	}
+	g.sendControl(conn, g.offer+" "+ctrlDecline)
+}
+
+// chat asks for a line of text and sends it to the opponent.
+func (g *Game) chat(conn io.Writer) {
+	if g.analysis {
+		g.message = tr(txtNotOnAnalysis)
+		return
+	}
+	if g.spectating {
+		g.message = tr(txtSpectatorChat)
+		return
+	}
+	g.startTextEntry(tr(txtChatPrompt), func(text string) {
+		if text == "" {
+			g.message = tr(txtCancelled)
+			return
+		}
+		sendMessage(conn, message{kind: msgChat, arg: text})
+		g.message = tr(txtChatYou, text)
+	})
+}
+
+// sendHeartbeats sends an HB message every heartbeatInterval until a
+// write fails, which means the connection is gone.
+func sendHeartbeats(conn io.Writer) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if sendMessage(conn, message{kind: msgHeartbeat}) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// immortalGame is the game the demo plays when given no file.
+const immortalGame = `[Event "London"]
+[Site "London ENG"]
+[Date "1851.06.21"]
+[White "Adolf Anderssen"]
+[Black "Lionel Kieseritzky"]
+[Result "1-0"]
+
+1. e4 e5 2. f4 exf4 3. Bc4 Qh4+ 4. Kf1 b5 5. Bxb5 Nf6 6. Nf3 Qh6 7. d3 Nh5
+8. Nh4 Qg5 9. Nf5 c6 10. g4 Nf6 11. Rg1 cxb5 12. h4 Qg6 13. h5 Qg5 14. Qf3
+Ng8 15. Bxf4 Qf6 16. Nc3 Bc5 17. Nd5 Qxb2 18. Bd6 Bxg1 19. e5 Qxa1+ 20. Ke2
+Na6 21. Nxg7+ Kd8 22. Qf6+ Nxf6 23. Be7# 1-0
+`
+
+// loadDemo reads the game the demo plays from the PGN file at path, or the
+// Immortal Game when path is empty, and returns its positions with a
+// caption naming the players.
+func loadDemo(path string) ([]replayFrame, string, error) {
+	var r io.Reader = strings.NewReader(immortalGame)
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	frames, tags, err := pgnReplay(r)
+	if err != nil {
+		return nil, "", err
+	}
+	return frames, demoTitle(tags), nil
+}
+
+// demoTitle names a game by its players and year, e.g. "Adolf Anderssen -
+// Lionel Kieseritzky, 1851", or by its event when the players are not
+// recorded.
+func demoTitle(tags map[string]string) string {
+	var parts []string
+	if tags["White"] != "" || tags["Black"] != "" {
+		parts = append(parts, tags["White"]+" - "+tags["Black"])
+	} else if tags["Event"] != "" {
+		parts = append(parts, tags["Event"])
+	}
+	if year, _, _ := strings.Cut(tags["Date"], "."); year != "" && !strings.Contains(year, "?") {
+		parts = append(parts, year)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runDemo plays the frames on g one every delay, captioning each move in
+// SAN. At the end it starts over if loop is set, or returns after showing
+// the final position a little longer. Esc or 'q' quits at any time.
+func runDemo(g *Game, frames []replayFrame, title string, delay time.Duration, loop bool) {
+	ticker := time.NewTicker(delay)
+	defer ticker.Stop()
+	go func() {
+		for range ticker.C {
+			termbox.Interrupt()
+		}
+	}()
+
+	frame, lingered := 0, false
+	for {
+		g.board = frames[frame].board
+		var caption []string
+		if title != "" {
+			caption = append(caption, title)
+		}
+		if san := frames[frame].san; san != "" {
+			caption = append(caption, localSAN(san))
+		}
+		g.message = strings.Join(append(caption, frames[frame].message), " | ")
+		g.drawBoard()
+
+		switch ev := termbox.PollEvent(); ev.Type {
+		case termbox.EventInterrupt:
+			if shuttingDown.Load() {
+				return
+			}
+			switch {
+			case frame < len(frames)-1:
+				frame++
+			case !lingered:
+				lingered = true // Give the final position one more tick
+			case loop:
+				frame, lingered = 0, false
+			default:
+				return
+			}
+		case termbox.EventKey:
+			if ev.Key == termbox.KeyEsc || ev.Ch == 'q' || ev.Ch == 'Q' {
+				return
+			}
+		case termbox.EventError:
+			panic(ev.Err)
+		}
+	}
+}
+
+// demo plays a famous game, or the one in the PGN file at path, without
+// network or input beyond quitting.
+func (s *setup) demo(path string, delay time.Duration, loop bool) {
+	frames, title, err := loadDemo(path)
+	if err != nil {
+		fmt.Println("Failed to load demo game:", err)
+		return
+	}
+	startTerminal(termbox.InputEsc)
+	defer termbox.Close()
+	viewer := NewGame()
+	viewer.glyphs = s.glyphs
+	viewer.pieceStyle = s.style
+	viewer.applyPreferences(&s.prefs)
+	runDemo(viewer, frames, title, delay, loop)
+}
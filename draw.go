@@ -0,0 +1,102 @@
+package main
+
+import "strings"
+
+// Thresholds for warning on the message bar that a draw rule is near.
+const (
+	fiftyMoveLimit     = 100 // Halfmoves without a capture or pawn move
+	fiftyMoveWarnAfter = 80
+)
+
+// Limits at which the game is drawn without anyone claiming it, as under
+// the FIDE rules. The fifty-move rule and threefold repetition must be
+// claimed, which the game has no way to do yet, so games that pass them,
+// common enough in PGN files, carry on until these.
+const (
+	seventyFiveMoveLimit = 150 // Halfmoves without a capture or pawn move
+	fivefoldRepetition   = 5
+)
+
+// drawRule returns the termination and message if the position just
+// reached is a draw by rule: a dead position, the seventy-five-move rule
+// or fivefold repetition. The termination is empty otherwise. It only
+// counts once checkmate and stalemate are ruled out, since mate on the
+// last move allowed still wins. Freestyle has none of these rules, since
+// any piece can take the king there. The caller holds g.lock.
+func (g *Game) drawRule() (termination, message string) {
+	switch {
+	case g.freestyle:
+		return "", ""
+	case g.insufficientMaterial():
+		return "insufficient material", tr(txtDeadPosition)
+	case g.halfmoveClock >= seventyFiveMoveLimit:
+		return "seventy-five-move rule", tr(txtSeventyFiveMoves)
+	case g.repetitions[g.hash] >= fivefoldRepetition:
+		return "fivefold repetition", tr(txtFivefold)
+	}
+	return "", ""
+}
+
+// insufficientMaterial reports whether neither side can ever checkmate:
+// only kings are left, or kings and one knight or bishop, or kings and
+// bishops that all stand on squares of one color.
+func (g *Game) insufficientMaterial() bool {
+	minors, knights := 0, 0
+	bishopColors := map[int]bool{}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := g.board[y][x]
+			if piece == nil {
+				continue
+			}
+			switch pieceKind(piece) {
+			case "king":
+			case "knight":
+				minors++
+				knights++
+			case "bishop":
+				minors++
+				bishopColors[(x+y)%2] = true
+			default:
+				return false // A pawn, rook or queen can always mate
+			}
+		}
+	}
+	return minors <= 1 || (knights == 0 && len(bishopColors) == 1)
+}
+
+// loneKing reports whether color has nothing left but the king, and so can
+// never checkmate whatever the opponent does.
+func (g *Game) loneKing(color string) bool {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && piece.color == color && pieceKind(piece) != "king" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// recordPosition counts one more occurrence of the current position, which
+// g.hash identifies: the placement of every piece, the side to move,
+// castling rights and the en passant square when a pawn can take there.
+func (g *Game) recordPosition() {
+	if g.repetitions == nil {
+		g.repetitions = make(map[uint64]int)
+	}
+	g.repetitions[g.hash]++
+}
+
+// drawStatus warns when a draw rule is getting close: a high halfmove clock
+// or a repeated position. It is empty the rest of the time.
+func (g *Game) drawStatus() string {
+	var status []string
+	if g.halfmoveClock >= fiftyMoveWarnAfter {
+		status = append(status, tr(txtHalfmoveClock, g.halfmoveClock, fiftyMoveLimit))
+	}
+	if n := g.repetitions[g.hash]; n >= 2 {
+		status = append(status, tr(txtPositionRepeated, n))
+	}
+	return strings.Join(status, ", ")
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestGameEndOrdering(t *testing.T) {
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	var repeat []string
+	for range 4 {
+		repeat = append(repeat, shuffle...)
+	}
+	tests := []struct {
+		name        string
+		fen         string
+		moves       []string
+		result      string
+		termination string
+	}{
+		// Qxa1+ leaves white a lone king, but black can still mate.
+		{"capture to a lone king", "k7/8/8/8/8/8/1q6/N6K b - - 0 1", []string{"b2a1"}, resultOngoing, ""},
+		// Bxf6 leaves king and bishop against a king.
+		{"bishop takes the last knight", "k7/8/5n2/8/8/8/8/2B4K w - - 0 1", []string{"c1h6", "a8b8", "h6g7", "b8a8", "g7f6"}, resultDraw, "insufficient material"},
+		// Bc4 stalemates with too little material to mate: stalemate
+		// comes first.
+		{"stalemate with a lone bishop", "7k/8/6K1/8/8/8/8/5B2 w - - 0 1", []string{"f1c4"}, resultDraw, "stalemate"},
+		// Mate on the last move the seventy-five-move rule allows wins.
+		{"mate on the seventy-fifth move", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 149 80", []string{"a1a8"}, resultWhiteWins, "checkmate"},
+		{"seventy-five moves", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 149 80", []string{"a1a2"}, resultDraw, "seventy-five-move rule"},
+		{"fourfold repetition", "", repeat[:12], resultOngoing, ""},
+		{"fivefold repetition", "", repeat, resultDraw, "fivefold repetition"},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, tt.fen)
+		playMoves(t, g, tt.moves...)
+		if g.result != tt.result || g.termination != tt.termination {
+			t.Errorf("%s: result %q, termination %q, want %q, %q", tt.name, g.result, g.termination, tt.result, tt.termination)
+		}
+	}
+}
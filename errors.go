@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned when a move cannot be played. They are wrapped with the
+// offending move, so test for them with errors.Is.
+var (
+	ErrMalformedMove = errors.New("malformed move")
+	ErrNullMove      = errors.New("a move must leave its square")
+	ErrGameOver      = errors.New("the game is over")
+	ErrNotYourTurn   = errors.New("not your turn")
+	ErrNoPieceThere  = errors.New("no piece on that square")
+	ErrWrongColor    = errors.New("that piece belongs to the opponent")
+	ErrIllegalMove   = errors.New("illegal move")
+	ErrNotPromotion  = errors.New("only a pawn reaching the last rank promotes")
+)
+
+// Reasons a move is illegal. They are wrapped together with ErrIllegalMove,
+// so errors.Is matches either.
+var (
+	ErrOwnPiece          = errors.New("your own piece is on that square")
+	ErrNotPieceMove      = errors.New("not that piece's move")
+	ErrPathBlocked       = errors.New("path blocked")
+	ErrPawnCapture       = errors.New("a pawn moves diagonally only to capture")
+	ErrNoCastlingRights  = errors.New("castling rights already lost")
+	ErrCastleInCheck     = errors.New("cannot castle out of or through check")
+	ErrLeavesKingInCheck = errors.New("would leave the king in check")
+)
+
+// validateMove checks that color may play moveStr, given in wire format
+// (e.g. "e2e4", or "e7e8n" naming the promotion), and returns its
+// coordinates and the piece it promotes to, or noPromotion.
+func (g *Game) validateMove(moveStr, color string) (fromRow, fromCol, toRow, toCol int, promotion string, err error) {
+	fromRow, fromCol, toRow, toCol, promotion, ok := parseMove(moveStr)
+	switch {
+	case !ok:
+		err = ErrMalformedMove
+	case fromRow == toRow && fromCol == toCol:
+		// Caught before anything looks at the board, so no later rule
+		// can mistake it for a move that changes nothing.
+		err = ErrNullMove
+	case g.gameOver:
+		err = ErrGameOver
+	case !g.settingUp && !g.spectating && !g.readyToPlay():
+		err = ErrNotReady
+	case g.currentPlayer != color:
+		err = ErrNotYourTurn
+	case g.board[fromRow][fromCol] == nil:
+		err = ErrNoPieceThere
+	case g.board[fromRow][fromCol].color != color:
+		err = ErrWrongColor
+	case g.board[toRow][toCol] != nil && g.board[toRow][toCol].color == color:
+		// Checked here as well as by move generation, so no bug there can
+		// ever let a move take one of the mover's own pieces.
+		err = fmt.Errorf("%w: %w", ErrIllegalMove, ErrOwnPiece)
+	case g.movesFrom(fromRow, fromCol)[squareKey(toCol, toRow)] == moveNone:
+		err = fmt.Errorf("%w: %w", ErrIllegalMove, g.whyIllegal(fromRow, fromCol, toRow, toCol))
+	case promotion != noPromotion && !g.promotes(fromRow, fromCol, toRow):
+		err = ErrNotPromotion
+	}
+	if err != nil {
+		return 0, 0, 0, 0, noPromotion, fmt.Errorf("%q: %w", moveStr, err)
+	}
+	return fromRow, fromCol, toRow, toCol, promotion, nil
+}
+
+// promotes reports whether moving the piece at (fromRow, fromCol) to toRow
+// promotes it, as applyMove decides.
+func (g *Game) promotes(fromRow, fromCol, toRow int) bool {
+	piece := g.board[fromRow][fromCol]
+	return piece.symbol == pieces[piece.color+"_pawn"] && (toRow == 0 || toRow == 7) && !g.freestyle
+}
+
+// promotionKinds are the pieces a pawn may promote to, best first.
+var promotionKinds = []string{"queen", "rook", "bishop", "knight"}
+
+// promotionsOf is the promotion pieces moving the piece at (fromRow,
+// fromCol) to toRow may name: every one of promotionKinds for a pawn
+// reaching the last rank, and just noPromotion for any other move.
+func (g *Game) promotionsOf(fromRow, fromCol, toRow int) []string {
+	if g.promotes(fromRow, fromCol, toRow) {
+		return promotionKinds
+	}
+	return []string{noPromotion}
+}
+
+// IsLegalMove reports whether color may play moveStr now.
+func (g *Game) IsLegalMove(moveStr, color string) bool {
+	_, _, _, _, _, err := g.validateMove(moveStr, color)
+	return err == nil
+}
+
+// ApplyAlgebraic plays moveStr, given in wire format (e.g. "e2e4"), for
+// color. If the move cannot be played the game is left unchanged and the
+// error says why.
+func (g *Game) ApplyAlgebraic(moveStr, color string) error {
+	fromRow, fromCol, toRow, toCol, promotion, err := g.validateMove(moveStr, color)
+	if err != nil {
+		return err
+	}
+	g.applyMove(fromRow, fromCol, toRow, toCol, promotion)
+	return nil
+}
+
+// whyIllegal explains why the piece at (fromY, fromX) cannot move to
+// (toY, toX), a move movesFrom has already ruled out.
+func (g *Game) whyIllegal(fromY, fromX, toY, toX int) error {
+	piece := g.board[fromY][fromX]
+	if target := g.board[toY][toX]; target != nil && target.color == piece.color {
+		return ErrOwnPiece
+	}
+	kind := pieceKind(piece)
+	if kind == "king" && fromX == 4 && fromY == toY && (toX == 2 || toX == 6) {
+		return g.whyNoCastle(fromY, fromX, toX)
+	}
+
+	// Alone on the board, could the piece make this move at all?
+	var alone [8][8]*Piece
+	alone[fromY][fromX] = piece
+	shape := controlledSquares(&alone, fromY, fromX)[toY][toX]
+	if kind == "pawn" {
+		dir, startRow := -1, 6
+		if piece.color == "black" {
+			dir, startRow = 1, 1
+		}
+		push := toX == fromX && (toY == fromY+dir || (fromY == startRow && toY == fromY+2*dir))
+		switch {
+		case push:
+			if g.board[toY][toX] != nil || g.board[fromY+dir][fromX] != nil {
+				return ErrPathBlocked
+			}
+		case shape && g.board[toY][toX] == nil && squareName(toX, toY) != g.enPassant:
+			return ErrPawnCapture
+		case shape:
+			// A capture, so only king safety can rule it out.
+		default:
+			return ErrNotPieceMove
+		}
+	} else if !shape {
+		return ErrNotPieceMove
+	} else if !controlledSquares(&g.board, fromY, fromX)[toY][toX] {
+		return ErrPathBlocked
+	}
+	return ErrLeavesKingInCheck
+}
+
+// whyNoCastle explains why the king on (y, x) cannot castle toward toX.
+func (g *Game) whyNoCastle(y, x, toX int) error {
+	color := g.board[y][x].color
+	right, rookX, between := 'K', 7, []int{5, 6}
+	if toX < x {
+		right, rookX, between = 'Q', 0, []int{1, 2, 3}
+	}
+	if color == "black" {
+		right += 'a' - 'A'
+	}
+	if !strings.ContainsRune(g.castling, right) || g.board[y][rookX] == nil {
+		return ErrNoCastlingRights
+	}
+	for _, bx := range between {
+		if g.board[y][bx] != nil {
+			return ErrPathBlocked
+		}
+	}
+	passX := (x + toX) / 2
+	if inCheck(&g.board, color) || isSquareAttacked(&g.board, y, passX, opponent(color)) {
+		return ErrCastleInCheck
+	}
+	return ErrLeavesKingInCheck
+}
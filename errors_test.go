@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWhyIllegal(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		moves []string // Played first, by whichever side is to move
+		move  string
+		want  error
+	}{
+		{name: "pawn onto an empty diagonal", move: "e2d3", want: ErrPawnCapture},
+		{name: "pawn blocked", fen: "4k3/8/8/8/8/4n3/4P3/4K3 w - - 0 1", move: "e2e3", want: ErrPathBlocked},
+		{name: "pinned pawn capture", fen: "4r1k1/8/8/8/8/3b4/4P3/4K3 w - - 0 1", move: "e2d3", want: ErrLeavesKingInCheck},
+		{name: "knight shape", move: "g1g3", want: ErrNotPieceMove},
+		{name: "bishop blocked", move: "f1c4", want: ErrPathBlocked},
+		{name: "king castles without rights", fen: "4k3/8/8/8/8/8/8/4K2R w - - 0 1", move: "e1g1", want: ErrNoCastlingRights},
+		{name: "king castles through check", fen: "4kr2/8/8/8/8/8/8/4K2R w K - 0 1", move: "e1g1", want: ErrCastleInCheck},
+		{name: "rook onto own pawn", move: "a1a2", want: ErrOwnPiece},
+		{name: "knight onto own pawn", move: "g1e2", want: ErrOwnPiece},
+		{name: "king onto own rook", fen: "4k3/8/8/8/8/8/8/4KR2 w - - 0 1", move: "e1f1", want: ErrOwnPiece},
+		{name: "pinned knight", fen: "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", move: "e2c3", want: ErrLeavesKingInCheck},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, tt.fen)
+		playMoves(t, g, tt.moves...)
+		err := g.ApplyAlgebraic(tt.move, g.currentPlayer)
+		if !errors.Is(err, tt.want) || !errors.Is(err, ErrIllegalMove) {
+			t.Errorf("%s: %s got %v, want %v", tt.name, tt.move, err, tt.want)
+		}
+	}
+}
+
+func TestNetworkedOwnPieceCaptureIgnored(t *testing.T) {
+	white, black := connectedGames(t, "")
+	// A peer that skips validation tries to take its own pawn.
+	sendMessage(white.conn, message{kind: msgMove, arg: "d1d2"})
+	sendMessage(white.conn, message{kind: msgMove, arg: "d2d4"})
+	waitFor(t, "the legal move", func() bool { return moveCount(black.Game) == 1 })
+	black.lock.Lock()
+	defer black.lock.Unlock()
+	if piece := black.board[6][3]; piece != nil {
+		t.Errorf("d2 holds %v after d2d4, want it empty", piece)
+	}
+	if piece := black.board[7][3]; piece == nil || piece.symbol != pieces["white_queen"] {
+		t.Errorf("the white queen left d1: %v", piece)
+	}
+}
+
+func TestNullMove(t *testing.T) {
+	tests := []struct {
+		fen  string
+		move string
+	}{
+		{"", "e2e2"},
+		{"", "g1g1"},
+		{"", "e4e4"}, // An empty square
+		{"", "e7e7"}, // The opponent's piece
+		{"4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7a7q"},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, tt.fen)
+		before := g.FEN()
+		err := g.ApplyAlgebraic(tt.move, g.currentPlayer)
+		if !errors.Is(err, ErrNullMove) || errors.Is(err, ErrIllegalMove) {
+			t.Errorf("%s: got %v, want %v alone", tt.move, err, ErrNullMove)
+		}
+		if g.FEN() != before || len(g.moveHistory) != 0 {
+			t.Errorf("%s changed the game to %s", tt.move, g.FEN())
+		}
+	}
+}
+
+func TestNetworkedNullMoveIgnored(t *testing.T) {
+	white, black := connectedGames(t, "")
+	// A peer that skips validation passes by moving a pawn onto itself.
+	sendMessage(white.conn, message{kind: msgMove, arg: "e2e2"})
+	waitFor(t, "the null move to be reported", func() bool {
+		black.lock.Lock()
+		defer black.lock.Unlock()
+		return strings.Contains(black.message, ErrNullMove.Error())
+	})
+	black.lock.Lock()
+	if len(black.moveHistory) != 0 || black.currentPlayer != "white" {
+		t.Errorf("e2e2 counted as a move: %d played, %s to move", len(black.moveHistory), black.currentPlayer)
+	}
+	black.lock.Unlock()
+	white.move(t, "e2e4")
+	waitFor(t, "the legal move", func() bool { return moveCount(black.Game) == 1 })
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fenLetters maps FEN piece letters to piece names.
+var fenLetters = map[rune]string{
+	'K': "white_king", 'Q': "white_queen", 'R': "white_rook", 'B': "white_bishop", 'N': "white_knight", 'P': "white_pawn",
+	'k': "black_king", 'q': "black_queen", 'r': "black_rook", 'b': "black_bishop", 'n': "black_knight", 'p': "black_pawn",
+}
+
+// loadFEN sets up the position described by a FEN string, e.g.
+// "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1". The
+// halfmove and fullmove fields may be left off. The position is checked
+// with ValidatePosition.
+func (g *Game) loadFEN(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 || len(fields) > 6 {
+		return fmt.Errorf("FEN needs 4 to 6 fields, got %d", len(fields))
+	}
+
+	var board [8][8]*Piece
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("FEN placement needs 8 ranks, got %d", len(ranks))
+	}
+	for y, rank := range ranks {
+		x := 0
+		for _, r := range rank {
+			switch {
+			case r >= '1' && r <= '8':
+				x += int(r - '0')
+			case fenLetters[r] != "":
+				if x < 8 {
+					color, kind, _ := strings.Cut(fenLetters[r], "_")
+					board[y][x] = newPiece(color, kind)
+				}
+				x++
+			default:
+				return fmt.Errorf("unexpected %q in FEN rank %d", r, 8-y)
+			}
+		}
+		if x != 8 {
+			return fmt.Errorf("FEN rank %d has %d squares, want 8", 8-y, x)
+		}
+	}
+
+	var player string
+	switch fields[1] {
+	case "w":
+		player = "white"
+	case "b":
+		player = "black"
+	default:
+		return fmt.Errorf("FEN side to move must be w or b, got %q", fields[1])
+	}
+
+	castling := fields[2]
+	if castling == "-" {
+		castling = ""
+	}
+	halfmoves := 0
+	if len(fields) > 4 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return fmt.Errorf("bad FEN halfmove clock %q", fields[4])
+		}
+		halfmoves = n
+	}
+	fullmoves := 1
+	if len(fields) > 5 {
+		n, err := strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return fmt.Errorf("bad FEN fullmove number %q", fields[5])
+		}
+		fullmoves = n
+	}
+
+	g.board = board
+	g.currentPlayer = player
+	g.castling = castling
+	g.enPassant = fields[3]
+	g.halfmoveClock = halfmoves
+	g.fullmoveNumber = fullmoves
+	g.hash = g.computeHash()
+	g.repetitions = nil
+	g.checks = nil
+	g.recordPosition()
+	g.startFEN = fen
+	if g.freestyle {
+		return nil // Any setup goes
+	}
+	return g.ValidatePosition()
+}
+
+// FEN describes the current position as a FEN string, the inverse of
+// loadFEN.
+func (g *Game) FEN() string {
+	letters := make(map[rune]rune, len(fenLetters))
+	for letter, name := range fenLetters {
+		letters[pieces[name]] = letter
+	}
+
+	var sb strings.Builder
+	for y := 0; y < 8; y++ {
+		if y > 0 {
+			sb.WriteByte('/')
+		}
+		empty := 0
+		for x := 0; x < 8; x++ {
+			piece := g.board[y][x]
+			if piece == nil {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteRune(letters[piece.symbol])
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+	}
+
+	castling := g.castling
+	if castling == "" {
+		castling = "-"
+	}
+	fmt.Fprintf(&sb, " %c %s %s %d %d", g.currentPlayer[0], castling, g.enPassant, g.halfmoveClock, g.fullmoveNumber)
+	return sb.String()
+}
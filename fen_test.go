@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// randomGame plays up to plies random legal moves from the start, the same
+// every run for a given seed, and returns the game.
+func randomGame(t testing.TB, seed int64, plies int) *Game {
+	t.Helper()
+	r := rand.New(rand.NewSource(seed))
+	g := newTestGame(t, "")
+	for i := 0; i < plies && !g.gameOver; i++ {
+		moves := legalMoves(g)
+		playMoves(t, g, string(moves[r.Intn(len(moves))]))
+	}
+	return g
+}
+
+// samePosition reports whether a and b hold the same position, down to
+// the move counters.
+func samePosition(a, b *Game) bool {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			pa, pb := a.board[y][x], b.board[y][x]
+			if (pa == nil) != (pb == nil) || pa != nil && *pa != *pb {
+				return false
+			}
+		}
+	}
+	return a.currentPlayer == b.currentPlayer && a.castling == b.castling && a.enPassant == b.enPassant &&
+		a.halfmoveClock == b.halfmoveClock && a.fullmoveNumber == b.fullmoveNumber
+}
+
+func FuzzFEN(f *testing.F) {
+	f.Add("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	f.Add("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	f.Add("8/8/8/8/8/8/8/8 w - - 0 1")
+	f.Add("4k3/8/8/8/8/8/8/4K3 w - -")
+	for seed := int64(1); seed <= 20; seed++ {
+		for _, plies := range []int{10, 40, 120} {
+			f.Add(randomGame(f, seed, plies).FEN())
+		}
+	}
+	f.Fuzz(func(t *testing.T, fen string) {
+		g := NewGame()
+		if err := g.loadFEN(fen); err != nil {
+			return // Any input may be refused, as long as it does not panic
+		}
+		exported := g.FEN()
+		again := NewGame()
+		if err := again.loadFEN(exported); err != nil {
+			t.Fatalf("%q exported as %q, which does not load: %v", fen, exported, err)
+		}
+		if !samePosition(g, again) {
+			t.Errorf("%q exported as %q, which loads as another position", fen, exported)
+		}
+		if again.FEN() != exported {
+			t.Errorf("%q exports as %q, then as %q", fen, exported, again.FEN())
+		}
+	})
+}
+
+func TestSelfPlayPGNRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		g := randomGame(t, seed, 200)
+		var sb strings.Builder
+		if err := g.writePGN(&sb, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		loaded, _, result, err := loadPGN(strings.NewReader(sb.String()), nil)
+		if err != nil {
+			t.Fatalf("seed %d: %v\n%s", seed, err, sb.String())
+		}
+		if !slices.Equal(loaded.moveHistory, g.moveHistory) || !samePosition(loaded, g) || result != g.result {
+			t.Errorf("seed %d: PGN reloads as another game\n%s", seed, sb.String())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// gameLog records the lifecycle of every game a server relays as JSON lines,
+// one object per event, so operators can audit results and disputed moves.
+// A nil *gameLog records nothing.
+type gameLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// logEntry is one line of the game log. Event is "start", "move",
+// "rejected", "refused" or "end"; the other fields are set as they apply.
+type logEntry struct {
+	Game        int       `json:"game"`
+	Time        time.Time `json:"time"`
+	Event       string    `json:"event"`
+	White       string    `json:"white,omitempty"` // Player addresses, on "start"
+	Black       string    `json:"black,omitempty"`
+	Color       string    `json:"color,omitempty"` // Side that sent a move or control message
+	Move        string    `json:"move,omitempty"`  // In wire format, e.g. "e2e4"
+	SAN         string    `json:"san,omitempty"`
+	Control     string    `json:"control,omitempty"` // Control verb, e.g. "resign"
+	Result      string    `json:"result,omitempty"`  // PGN result token, on "end"
+	Termination string    `json:"termination,omitempty"`
+	Reason      string    `json:"reason,omitempty"` // Why a player was refused
+}
+
+// openGameLog appends to the log file at path, creating it if needed.
+func openGameLog(path string) (*gameLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &gameLog{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Close closes the log file.
+func (l *gameLog) Close() error {
+	return l.file.Close()
+}
+
+// record writes e with the current time. Write errors are ignored so a full
+// disk cannot stop games being relayed.
+func (l *gameLog) record(e logEntry) {
+	if l == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(e)
+}
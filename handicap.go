@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// handicap is a preset material handicap: the pieces the side giving odds
+// leaves off the standard starting position.
+type handicap struct {
+	description textKey
+	squares     []string // Squares emptied from the starting position
+}
+
+// handicaps are the presets -handicap accepts, by name. White gives odds
+// except in pawn and move, where black gives up the f-pawn and white keeps
+// the first move. The host plays white, so a stronger host picks one of
+// the first three and a weaker host pawn and move.
+var handicaps = map[string]handicap{
+	"knight":        {description: txtHandicapKnight, squares: []string{"b1"}},
+	"rook":          {description: txtHandicapRook, squares: []string{"a1"}},
+	"queen":         {description: txtHandicapQueen, squares: []string{"d1"}},
+	"pawn-and-move": {description: txtHandicapPawnAndMove, squares: []string{"f7"}},
+}
+
+// handicapNames lists the presets for error messages and usage.
+func handicapNames() string {
+	names := make([]string, 0, len(handicaps))
+	for name := range handicaps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// handicapGame returns a game set up for spec, the name of a preset or a
+// FEN for any other handicap, and a message describing it. The position is
+// checked to be legal either way.
+func handicapGame(spec string) (*Game, string, error) {
+	g := NewGame()
+	if strings.Contains(spec, "/") {
+		if err := g.loadFEN(spec); err != nil {
+			return nil, "", err
+		}
+		return g, tr(txtHandicapCustom), nil
+	}
+	h, ok := handicaps[spec]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown handicap %q, want a FEN or one of %s", spec, handicapNames())
+	}
+	for _, square := range h.squares {
+		x, y, _ := parseSquare(square)
+		g.board[y][x] = nil
+	}
+	// A missing rook takes its castling right with it.
+	g.castling = strings.Map(func(right rune) rune {
+		homes := castlingHomes[right]
+		color := "white"
+		if right == 'k' || right == 'q' {
+			color = "black"
+		}
+		if !g.hasPieceOn(homes[1], color+"_rook") {
+			return -1
+		}
+		return right
+	}, g.castling)
+	if err := g.loadFEN(g.FEN()); err != nil {
+		return nil, "", err
+	}
+	return g, tr(h.description), nil
+}
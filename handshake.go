@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// protocolVersion is bumped whenever the wire format changes in a way an
+// older build cannot read. Version 2 frames every message with a kind
+// token (see protocol.go); version 3 lets a promotion name its piece;
+// version 4 names the side an adjudication or a fallen flag is against.
+const protocolVersion = 4
+
+// protocolCapabilities are the optional wire features this build speaks.
+// Each one changes what can appear on the wire, so both sides must list the
+// same set: "castling" sends a castle as the king's two-square move.
+var protocolCapabilities = []string{"castling"}
+
+// capabilityFreestyle is listed by a side playing freestyle, whose moves
+// only standard rules would reject; both sides must agree to play it.
+const capabilityFreestyle = "freestyle"
+
+// capabilityAck is listed by a side that wants an ACK for every move it
+// sends, to warn when one is late (-ack-timeout). Like a variant it need
+// not be listed by both sides: only a peer that lists it is sent ACKs.
+const capabilityAck = "ack"
+
+// variantPrefix starts the capability naming the variant a game is played
+// under, e.g. "variant=koth". Unlike the others it need not be listed by
+// both sides: a joiner, spectator or served player who lists none plays
+// whatever variant the host or server names.
+const variantPrefix = "variant="
+
+// gameCapabilities are the capabilities to announce for a game, which
+// depend on the rules it is played under: freestyle or not, and the name
+// of its variant, empty for standard chess.
+func gameCapabilities(freestyle bool, variant string) []string {
+	capabilities := slices.Clone(protocolCapabilities)
+	if freestyle {
+		capabilities = append(capabilities, capabilityFreestyle)
+	}
+	if variant != "" {
+		capabilities = append(capabilities, variantPrefix+variant)
+	}
+	return capabilities
+}
+
+// oneSided reports whether capability c may be listed by one side only.
+func oneSided(c string) bool {
+	return c == capabilityAck || strings.HasPrefix(c, variantPrefix)
+}
+
+// peerHello is what the peer's hello asked for.
+type peerHello struct {
+	variant string // The variant it named, "" for none
+	acks    bool   // It wants an ACK for every move it sends
+}
+
+// listedVariant is the variant named in capabilities, or "" for none.
+func listedVariant(capabilities []string) string {
+	for _, c := range capabilities {
+		if name, ok := strings.CutPrefix(c, variantPrefix); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// settleVariant decides the variant to play once the handshake is over,
+// from ours and the peer's. The side that hosts, or the server, decides;
+// the other side takes its variant, and must not ask for another one.
+func settleVariant(ours, theirs string, hosting bool) (string, error) {
+	switch {
+	case ours == theirs:
+		return ours, nil
+	case hosting && ours == "":
+		return "", fmt.Errorf("opponent asked for the %s variant; it is played only when the host chooses it", theirs)
+	case hosting:
+		return ours, nil
+	case ours != "":
+		return "", fmt.Errorf("the host is not playing the %s variant", ours)
+	}
+	if _, ok := variants[theirs]; !ok {
+		return "", fmt.Errorf("the host plays the %s variant, which this build does not know", theirs)
+	}
+	return theirs, nil
+}
+
+// handshakeTimeout bounds the wait for the peer's hello, so a build that
+// predates the handshake is refused instead of hanging the game.
+const handshakeTimeout = 10 * time.Second
+
+// helloMessage announces this build's protocol and the game's capabilities,
+// e.g. "hello 4 castling,variant=koth" for a king of the hill game.
+func helloMessage(capabilities []string) string {
+	return fmt.Sprintf("hello %d %s", protocolVersion, strings.Join(capabilities, ","))
+}
+
+// handshake exchanges hello messages with the peer before any move is sent
+// and fails with a readable reason if the two builds cannot play together.
+// It returns what the peer asked for; its variant is for settleVariant.
+func handshake(conn net.Conn, capabilities []string) (peerHello, error) {
+	if _, err := fmt.Fprintln(conn, helloMessage(capabilities)); err != nil {
+		return peerHello{}, err
+	}
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	line, err := readLine(conn)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return peerHello{}, errors.New("opponent sent no handshake; they may be running an older version")
+	}
+	if err != nil {
+		return peerHello{}, err
+	}
+	return checkHello(line, capabilities)
+}
+
+// checkHello reports why a peer's hello is incompatible with ours, which
+// lists capabilities, or returns what the peer asked for if the two can
+// play. Variants are left to settleVariant.
+func checkHello(line string, capabilities []string) (peerHello, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "hello" || len(fields) > 3 {
+		return peerHello{}, fmt.Errorf("unexpected handshake %q; the opponent may be running an older version", line)
+	}
+	if len(fields) < 2 {
+		return peerHello{}, fmt.Errorf("handshake %q has no protocol version", line)
+	}
+	version, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return peerHello{}, fmt.Errorf("handshake %q has a bad protocol version", line)
+	}
+	if version != protocolVersion {
+		return peerHello{}, fmt.Errorf("opponent speaks protocol version %d, this build speaks version %d", version, protocolVersion)
+	}
+	var theirs []string
+	if len(fields) == 3 {
+		theirs = strings.Split(fields[2], ",")
+	}
+	if ours, their := slices.Contains(capabilities, capabilityFreestyle), slices.Contains(theirs, capabilityFreestyle); ours != their {
+		if their {
+			return peerHello{}, errors.New("opponent is playing freestyle; both sides need -freestyle")
+		}
+		return peerHello{}, errors.New("opponent is playing standard chess; both sides need -freestyle for freestyle")
+	}
+	for _, c := range capabilities {
+		if !slices.Contains(theirs, c) && !oneSided(c) {
+			return peerHello{}, fmt.Errorf("opponent does not support %s", c)
+		}
+	}
+	for _, c := range theirs {
+		if !slices.Contains(capabilities, c) && !oneSided(c) {
+			return peerHello{}, fmt.Errorf("opponent uses %s, which this build does not support", c)
+		}
+	}
+	return peerHello{variant: listedVariant(theirs), acks: slices.Contains(theirs, capabilityAck)}, nil
+}
+
+// readLine reads one line from r without its newline, failing once it
+// passes maxLineLength. It reads a byte at a time so nothing past the line
+// is buffered away from the move reader in play.
+func readLine(r io.Reader) (string, error) {
+	var sb strings.Builder
+	buf := make([]byte, 1)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			return "", err
+		}
+		if buf[0] == '\n' {
+			return strings.TrimSpace(sb.String()), nil
+		}
+		if sb.Len() == maxLineLength {
+			return "", errLineTooLong
+		}
+		sb.WriteByte(buf[0])
+	}
+}
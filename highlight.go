@@ -0,0 +1,100 @@
+package main
+
+import "github.com/nsf/termbox-go"
+
+// boardHighlights is what the square highlights are worked out from, taken
+// once per frame so the layers don't recompute it for every square.
+type boardHighlights struct {
+	selectedX, selectedY int
+	legalMoves           map[string]moveKind
+	control              [8][8]bool // Squares the selected piece controls, when shown
+	hanging              [8][8]bool // Our attacked, undefended pieces, when shown
+	premove              [8][8]int  // Link of the premove chain, from 1, that starts or ends on each square
+	heatmap              bool       // Whether the control heatmap is shown
+	net                  [8][8]int  // netControl of the board, for the heatmap
+}
+
+// heatmapColors shade a square by net control, from black's strongest hold
+// to white's: reds where black has more pieces on the square, greens where
+// white has. A square both sides control equally keeps its own color.
+var heatmapColors = [...]termbox.Attribute{
+	termbox.Attribute(125), // Dark red: black by 3 or more
+	termbox.Attribute(168), // Red
+	termbox.Attribute(211), // Pink: black by 1
+	0,
+	termbox.Attribute(158), // Pale green: white by 1
+	termbox.Attribute(115), // Green
+	termbox.Attribute(29),  // Dark green: white by 3 or more
+}
+
+// highlightLayer is one source of square highlighting. bg returns the
+// layer's background for square (x, y) and whether the layer covers it.
+type highlightLayer struct {
+	name string
+	bg   func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool)
+}
+
+// highlightLayers are the square highlights in order of precedence: a
+// square takes its background from the first layer that covers it, and
+// its plain light or dark color when none does. A new kind of highlight
+// is a new entry here, placed by how much it matters.
+var highlightLayers = []highlightLayer{
+	{"selection", func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool) {
+		return theme.SelectedBg, x == h.selectedX && y == h.selectedY
+	}},
+	{"premove", func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool) {
+		return theme.PremoveBg, h.premove[y][x] > 0
+	}},
+	{"threat", func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool) {
+		return theme.ThreatBg, h.hanging[y][x]
+	}},
+	{"control", func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool) {
+		return theme.ControlBg, h.control[y][x]
+	}},
+	{"legal move", func(h *boardHighlights, theme Theme, x, y int) (termbox.Attribute, bool) {
+		kind := h.legalMoves[squareKey(x, y)]
+		return theme.moveBg(kind), kind != moveNone
+	}},
+	{"heatmap", func(h *boardHighlights, _ Theme, x, y int) (termbox.Attribute, bool) {
+		if !h.heatmap || h.net[y][x] == 0 {
+			return 0, false
+		}
+		return heatmapColors[min(max(h.net[y][x], -3), 3)+3], true
+	}},
+}
+
+// highlights gathers what the highlight layers need for this frame. The
+// caller holds g.lock.
+func (g *Game) highlights() *boardHighlights {
+	h := &boardHighlights{selectedX: g.selectedX, selectedY: g.selectedY, legalMoves: g.legalMoves}
+	if g.showControl && g.selectedX >= 0 && g.board[g.selectedY][g.selectedX] != nil {
+		h.control = controlledSquares(&g.board, g.selectedY, g.selectedX)
+	}
+	if g.showThreats {
+		h.hanging = hangingPieces(&g.board, g.ownColor())
+	}
+	if g.showHeatmap {
+		h.heatmap, h.net = true, netControl(&g.board)
+	}
+	for i, moveStr := range g.premoves {
+		if fromY, fromX, toY, toX, _, ok := parseMove(moveStr); ok {
+			h.premove[fromY][fromX] = i + 1
+			h.premove[toY][toX] = i + 1
+		}
+	}
+	return h
+}
+
+// squareBg resolves the background of square (x, y) through the highlight
+// layers.
+func (h *boardHighlights) squareBg(theme Theme, x, y int) termbox.Attribute {
+	for _, layer := range highlightLayers {
+		if bg, ok := layer.bg(h, theme, x, y); ok {
+			return bg
+		}
+	}
+	if (x+y)%2 == 0 {
+		return theme.DarkSquareBg
+	}
+	return theme.LightSquareBg
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+// testTheme gives every square color its own value, so a test can tell
+// which layer a background came from.
+var testTheme = Theme{
+	LightSquareBg: 1,
+	DarkSquareBg:  2,
+	SelectedBg:    3,
+	LegalMoveBg:   4,
+	CaptureMoveBg: 5,
+	CastleMoveBg:  6,
+	EnPassantBg:   7,
+	ControlBg:     8,
+	ThreatBg:      9,
+	PremoveBg:     10,
+}
+
+func TestHighlightPrecedence(t *testing.T) {
+	// Every layer covers e4 (x 4, y 4). Peeling them off from the top
+	// shows each in turn, down to the plain square.
+	h := &boardHighlights{selectedX: 4, selectedY: 4, legalMoves: map[string]moveKind{squareKey(4, 4): moveCapture}, heatmap: true}
+	h.premove[4][4] = 1
+	h.hanging[4][4] = true
+	h.control[4][4] = true
+	h.net[4][4] = 3
+	steps := []struct {
+		want termbox.Attribute
+		peel func()
+	}{
+		{testTheme.SelectedBg, func() { h.selectedX, h.selectedY = -1, -1 }},
+		{testTheme.PremoveBg, func() { h.premove[4][4] = 0 }},
+		{testTheme.ThreatBg, func() { h.hanging[4][4] = false }},
+		{testTheme.ControlBg, func() { h.control[4][4] = false }},
+		{testTheme.CaptureMoveBg, func() { delete(h.legalMoves, squareKey(4, 4)) }},
+		{heatmapColors[6], func() { h.net[4][4] = 0 }},
+		{testTheme.DarkSquareBg, nil},
+	}
+	for _, step := range steps {
+		if got := h.squareBg(testTheme, 4, 4); got != step.want {
+			t.Fatalf("background %d, want %d", got, step.want)
+		}
+		if step.peel != nil {
+			step.peel()
+		}
+	}
+	if got := h.squareBg(testTheme, 5, 4); got != testTheme.LightSquareBg {
+		t.Errorf("plain light square got %d, want %d", got, testTheme.LightSquareBg)
+	}
+}
+
+func TestHighlightLegalMoveKinds(t *testing.T) {
+	// The king on e1 may castle, step to f1 or take the rook on d2.
+	g := newTestGame(t, "4k3/8/8/8/8/8/3r4/4K2R w K - 0 1")
+	g.selectedX, g.selectedY = 4, 7
+	g.legalMoves = g.movesFrom(7, 4)
+	h := g.highlights()
+	tests := []struct {
+		square string
+		want   termbox.Attribute
+	}{
+		{"e1", testTheme.SelectedBg},
+		{"g1", testTheme.CastleMoveBg},
+		{"d2", testTheme.CaptureMoveBg},
+		{"f1", testTheme.LegalMoveBg},
+	}
+	for _, tt := range tests {
+		x, y := int(tt.square[0]-'a'), int('8'-tt.square[1])
+		if got := h.squareBg(testTheme, x, y); got != tt.want {
+			t.Errorf("%s: background %d, want %d", tt.square, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// With sendDelay set, our moves are held for that long before they are
+// sent, so a misclick can be taken back with Backspace before the opponent
+// ever sees it. Unlike a takeback this needs no consent: as far as the
+// opponent knows, the move was never made. A move that ends the game is
+// sent at once, since there is nothing left to play.
+
+// sendMove sends one of our moves to the opponent, or holds it for
+// sendDelay first.
+func (g *Game) sendMove(conn io.Writer, moveStr string) {
+	g.lock.Lock()
+	if g.sendDelay <= 0 || g.gameOver {
+		g.lock.Unlock()
+		g.transmitMove(conn, moveStr)
+		return
+	}
+	g.heldMove = moveStr
+	g.holdSeq++
+	seq := g.holdSeq
+	g.message = tr(txtHeldSending, moveStr, g.sendDelay)
+	g.lock.Unlock()
+	time.AfterFunc(g.sendDelay, func() { g.releaseMove(conn, seq) })
+}
+
+// releaseMove sends the held move once its delay is up, unless it has been
+// taken back or sent since.
+func (g *Game) releaseMove(conn io.Writer, seq int) {
+	g.lock.Lock()
+	moveStr := g.heldMove
+	if moveStr == "" || seq != g.holdSeq {
+		g.lock.Unlock()
+		return
+	}
+	g.heldMove = ""
+	g.message = tr(txtHeldSent, moveStr)
+	g.lock.Unlock()
+	g.transmitMove(conn, moveStr)
+	if !g.headless {
+		termbox.Interrupt() // Wake the event loop to redraw
+	}
+}
+
+// flushHeldMove sends the held move now, if there is one, so that nothing
+// sent after it overtakes it.
+func (g *Game) flushHeldMove(conn io.Writer) {
+	g.lock.Lock()
+	moveStr := g.heldMove
+	g.heldMove = ""
+	g.lock.Unlock()
+	if moveStr != "" {
+		g.transmitMove(conn, moveStr)
+	}
+}
+
+// undoHeldMove takes back our last move while it is still held, without
+// the opponent ever knowing of it.
+func (g *Game) undoHeldMove() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.heldMove == "" {
+		g.message = tr(txtHeldNone)
+		if g.sendDelay <= 0 {
+			g.message = tr(txtHeldNoDelay)
+		}
+		return
+	}
+	moveStr := g.heldMove
+	g.heldMove = ""
+	g.takeBack()
+	if g.clock != nil {
+		g.clock.Unpress()
+		g.armClockTimer()
+	}
+	g.message = tr(txtHeldTakenBack, moveStr)
+}
+
+// transmitMove writes one of our moves to the opponent and starts waiting
+// for its ack.
+func (g *Game) transmitMove(conn io.Writer, moveStr string) {
+	g.lock.Lock()
+	g.settingUp = false
+	g.lock.Unlock()
+	sendMessage(conn, message{kind: msgMove, arg: moveStr})
+	g.awaitAck()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// ctrlAdjudicate ends a game on the authority of whoever runs it, the host
+// or the server, e.g. "adjudicate black 1-0" when black stayed idle too
+// long. It names the idle side because the receiver cannot tell it from
+// the side to move: a move still on its way changes that. Players never
+// send it.
+const ctrlAdjudicate = "adjudicate"
+
+// armIdleTimer restarts the idle timer for the side to move. It is called
+// when the game starts and after every move, and does nothing unless
+// idleTimeout is set or while a ready check is pending. The caller holds
+// g.lock.
+func (g *Game) armIdleTimer() {
+	if g.idleTimeout <= 0 || g.gameOver || !g.readyToPlay() {
+		return
+	}
+	if g.idleTimer != nil {
+		g.idleTimer.Stop()
+	}
+	g.idleSeq++
+	seq := g.idleSeq
+	g.idleTimer = time.AfterFunc(g.idleTimeout, func() { g.idleExpired(seq) })
+}
+
+// idleExpired adjudicates the game against the side to move, or as a draw
+// with idleDraw, unless a move has been made since the timer was armed.
+func (g *Game) idleExpired(seq int) {
+	g.lock.Lock()
+	if g.gameOver || seq != g.idleSeq {
+		g.lock.Unlock()
+		return
+	}
+	idle := g.currentPlayer
+	result := winResult(opponent(idle))
+	if g.idleDraw {
+		result = resultDraw
+	}
+	g.adjudicate(idle, result)
+	notify := g.onAdjudicate
+	g.lock.Unlock()
+
+	if notify != nil {
+		notify(ctrlAdjudicate + " " + idle + " " + result)
+	}
+	if !g.headless {
+		termbox.Interrupt() // Wake the event loop to show the result
+	}
+}
+
+// adjudicate ends the game with result because idle took too long to
+// move. The caller holds g.lock.
+func (g *Game) adjudicate(idle, result string) {
+	var message string
+	switch {
+	case result == resultDraw:
+		message = tr(txtIdleDrawn, colorName(idle))
+	case idle == g.playerColor:
+		message = tr(txtIdleYouLose)
+	case opponent(idle) == g.playerColor:
+		message = tr(txtIdleYouWin, colorName(idle))
+	default:
+		message = tr(txtIdleResult, colorName(idle), result)
+	}
+	g.endGame(result, "adjudication", message)
+}
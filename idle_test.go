@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIdleAdjudication(t *testing.T) {
+	host := newTestGame(t, "")
+	host.playerColor, host.hosting = "white", true
+	host.idleTimeout = time.Millisecond
+	verbs := make(chan string, 1)
+	host.onAdjudicate = func(verb string) { verbs <- verb }
+	playMoves(t, host, "e2e4")
+	host.lock.Lock()
+	host.armIdleTimer()
+	host.lock.Unlock()
+
+	var verb string
+	select {
+	case verb = <-verbs:
+	case <-time.After(time.Second):
+		t.Fatal("idle timer never fired")
+	}
+	if want := "adjudicate black 1-0"; verb != want {
+		t.Fatalf("host sent %q, want %q", verb, want)
+	}
+	host.lock.Lock()
+	if host.result != resultWhiteWins || host.termination != "adjudication" || host.message != tr(txtIdleYouWin, colorName("black")) {
+		t.Errorf("host: result %q, termination %q, message %q", host.result, host.termination, host.message)
+	}
+	host.lock.Unlock()
+
+	// Black moved just as the timer ran out, so the joiner has white to
+	// move when the verdict against black arrives. It still stands.
+	joiner := newTestGame(t, "")
+	joiner.playerColor = "black"
+	playMoves(t, joiner, "e2e4", "e7e5")
+	joiner.lock.Lock()
+	defer joiner.lock.Unlock()
+	if err := joiner.applyControl("white", verb); err != nil {
+		t.Fatalf("joiner refused %q: %v", verb, err)
+	}
+	if joiner.result != resultWhiteWins || joiner.message != tr(txtIdleYouLose) {
+		t.Errorf("joiner: result %q, message %q", joiner.result, joiner.message)
+	}
+}
+
+func TestAdjudicateRejected(t *testing.T) {
+	for _, verb := range []string{"adjudicate black 0-1", "adjudicate 1-0", "adjudicate green 1-0", "adjudicate white *"} {
+		g := newTestGame(t, "")
+		g.playerColor = "black"
+		g.lock.Lock()
+		if err := g.applyControl("white", verb); !errors.Is(err, errUnknownControl) {
+			t.Errorf("%q: got %v, want %v", verb, err, errUnknownControl)
+		}
+		g.lock.Unlock()
+	}
+	host := newTestGame(t, "")
+	host.playerColor, host.hosting = "white", true
+	host.lock.Lock()
+	defer host.lock.Unlock()
+	if err := host.applyControl("black", "adjudicate white 0-1"); !errors.Is(err, errNotAuthority) {
+		t.Errorf("host accepted a player's adjudication: %v", err)
+	}
+}
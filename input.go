@@ -0,0 +1,220 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// inputMode decides where key presses go. Game shortcuts only fire in
+// modeNormal; while text is being typed or a question is pending, letters
+// and digits belong to that prompt, so typing can never change the theme
+// or trigger another command by accident.
+type inputMode int
+
+const (
+	modeNormal      inputMode = iota // Keys are game shortcuts
+	modeTextEntry                    // Keys edit the text after inputPrompt
+	modeConfirm                      // Waiting for y/n to inputPrompt
+	modeGameOver                     // The game-over panel is open
+	modeThemePicker                  // The theme list is open
+	modeHelp                         // The key help is open; any key closes it
+	modePromotion                    // The promotion menu is open
+)
+
+// maxInputLength caps how much text a prompt accepts.
+const maxInputLength = 32
+
+// handleKey routes a key press according to the input mode and reports
+// whether the player has chosen to quit. Ctrl-L repaints the screen in
+// every mode.
+func (g *Game) handleKey(ev termbox.Event, conn io.Writer, player string) bool {
+	if ev.Key == termbox.KeyCtrlL {
+		g.repaint = true
+		return g.quit
+	}
+	if ev.Key == termbox.KeyCtrlC {
+		g.leave(conn)
+		return true
+	}
+	switch g.inputMode {
+	case modeTextEntry:
+		g.handleTextKey(ev)
+	case modeConfirm:
+		g.handleConfirmKey(ev)
+	case modeGameOver:
+		g.handleGameOverKey(ev)
+	case modeThemePicker:
+		g.handleThemePickerKey(ev)
+	case modeHelp:
+		g.inputMode = modeNormal
+	case modePromotion:
+		g.handlePromotionKey(ev, conn)
+	default:
+		g.handleShortcut(ev, conn, player)
+	}
+	return g.quit
+}
+
+// handleShortcut runs the game command bound to a key in normal mode.
+func (g *Game) handleShortcut(ev termbox.Event, conn io.Writer, player string) {
+	for _, b := range keyBindings() {
+		if b.matches(ev) {
+			b.run(g, conn, player)
+			return
+		}
+	}
+}
+
+// quitGame leaves the game. Leaving early aborts it; after that it
+// resigns, so the player is asked first.
+func (g *Game) quitGame(conn io.Writer, _ string) {
+	if g.gameOver || g.spectating {
+		g.quit = true
+		return
+	}
+	verb, question := ctrlResign, tr(txtResignQuitAsk)
+	if g.quitVerb() == ctrlAbort {
+		verb, question = ctrlAbort, tr(txtAbortAsk)
+	}
+	g.askConfirm(question, func() {
+		g.sendControl(conn, verb)
+		g.quit = true
+	})
+}
+
+// toggleThreats shows or hides our hanging pieces.
+func (g *Game) toggleThreats(io.Writer, string) {
+	g.showThreats = !g.showThreats
+	if g.showThreats {
+		g.message = tr(txtThreatsShown)
+	} else {
+		g.message = tr(txtThreatsHidden)
+	}
+}
+
+// toggleHeatmap shows or hides which side controls each square.
+func (g *Game) toggleHeatmap() {
+	g.showHeatmap = !g.showHeatmap
+	if g.showHeatmap {
+		g.message = tr(txtHeatmapShown)
+	} else {
+		g.message = tr(txtHeatmapHidden)
+	}
+}
+
+// toggleControl shows or hides the squares the selected piece controls.
+func (g *Game) toggleControl(io.Writer, string) {
+	g.showControl = !g.showControl
+	if g.showControl {
+		g.message = tr(txtControlShown)
+	} else {
+		g.message = tr(txtControlHidden)
+	}
+}
+
+// startTextEntry switches to text entry with the given prompt. When the
+// player presses Enter, submit receives the typed text; Esc cancels.
+func (g *Game) startTextEntry(prompt string, submit func(text string)) {
+	g.inputMode = modeTextEntry
+	g.inputPrompt = prompt
+	g.inputText = ""
+	g.inputSubmit = submit
+	g.message = prompt
+}
+
+// handleTextKey edits the text being entered.
+func (g *Game) handleTextKey(ev termbox.Event) {
+	switch {
+	case ev.Key == termbox.KeyEsc:
+		g.inputMode = modeNormal
+		g.message = tr(txtCancelled)
+		return
+	case ev.Key == termbox.KeyEnter:
+		g.inputMode = modeNormal
+		g.inputSubmit(strings.TrimSpace(g.inputText))
+		return
+	case ev.Key == termbox.KeyBackspace || ev.Key == termbox.KeyBackspace2:
+		if n := len(g.inputText); n > 0 {
+			g.inputText = g.inputText[:n-1]
+		}
+	case ev.Key == termbox.KeySpace:
+		g.inputText += " "
+	case ev.Ch >= ' ' && ev.Ch < 0x7f && len(g.inputText) < maxInputLength:
+		g.inputText += string(ev.Ch)
+	}
+	g.message = g.inputPrompt + g.inputText
+}
+
+// askConfirm asks a yes/no question; yes runs only if the player presses y.
+func (g *Game) askConfirm(question string, yes func()) {
+	g.inputMode = modeConfirm
+	g.inputPrompt = tr(txtConfirm, question)
+	g.inputConfirm = yes
+	g.message = g.inputPrompt
+}
+
+// handleConfirmKey answers the pending question. Any key but y means no.
+func (g *Game) handleConfirmKey(ev termbox.Event) {
+	g.inputMode = modeNormal
+	if ev.Ch == 'y' || ev.Ch == 'Y' {
+		g.inputConfirm()
+		return
+	}
+	g.message = tr(txtCancelled)
+}
+
+// jumpToSquare moves the cursor to a square typed by name, e.g. "e4".
+func (g *Game) jumpToSquare(text string) {
+	x, y, ok := parseSquare(strings.ToLower(text))
+	if !ok {
+		g.message = tr(txtNoSuchSquare, text)
+		return
+	}
+	g.cursorX, g.cursorY = x, y
+	g.message = tr(txtCursorOn, squareName(x, y))
+}
+
+// cycleMovable moves the cursor to the next of color's pieces with a legal
+// move, or the previous one when step is -1, in reading order as the board
+// is shown, so flipping the board reverses the order. Empty squares, the
+// opponent's pieces and pieces that cannot move, pinned ones included, are
+// skipped.
+func (g *Game) cycleMovable(color string, step int) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	var squares [][2]int // Movable pieces' squares in screen order
+	current := -1        // Where the cursor falls in screen order
+	for i := 0; i < 64; i++ {
+		x, y := i%8, i/8
+		if g.flipped {
+			x, y = 7-x, 7-y
+		}
+		if x == g.cursorX && y == g.cursorY {
+			current = len(squares)
+		}
+		piece := g.board[y][x]
+		if piece == nil || piece.color != color {
+			continue
+		}
+		for _, kind := range g.movesFrom(y, x) {
+			if kind != moveNone {
+				squares = append(squares, [2]int{x, y})
+				break
+			}
+		}
+	}
+	if len(squares) == 0 {
+		g.message = tr(txtNoMovablePiece)
+		return
+	}
+	// current indexes the first movable piece at or after the cursor.
+	next := current
+	if step < 0 || (current < len(squares) && squares[current] == [2]int{g.cursorX, g.cursorY}) {
+		next = current + step
+	}
+	next = (next + len(squares)) % len(squares)
+	g.cursorX, g.cursorY = squares[next][0], squares[next][1]
+	g.message = tr(txtCursorOnMovable, squareName(g.cursorX, g.cursorY), next+1, len(squares))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// keyBinding is one game shortcut: the keys that trigger it, how they are
+// written in the help overlay, and what they do.
+type keyBinding struct {
+	keys   []termbox.Key // Special keys, e.g. termbox.KeyEsc
+	chars  string        // Printable keys; letters are listed in both cases where both work
+	label  string        // The keys as shown in the help, e.g. "c"
+	action textKey       // What the keys do, as shown in the help
+	run    func(g *Game, conn io.Writer, player string)
+}
+
+// matches reports whether ev is one of b's keys.
+func (b keyBinding) matches(ev termbox.Event) bool {
+	if ev.Ch != 0 {
+		return strings.ContainsRune(b.chars, ev.Ch)
+	}
+	return slices.Contains(b.keys, ev.Key)
+}
+
+// keyBindings lists every shortcut of normal mode. handleShortcut and the
+// help overlay both read it, so the help can never disagree with the keys.
+// It is built by a function because some actions draw boards whose help
+// refers back to it.
+func keyBindings() []keyBinding {
+	return []keyBinding{
+		{keys: []termbox.Key{termbox.KeyEsc}, label: "Esc", action: txtHelpQuit, run: (*Game).quitGame},
+		{keys: []termbox.Key{termbox.KeyCtrlC}, label: "Ctrl-C", action: txtHelpQuitNow, run: func(g *Game, conn io.Writer, _ string) { g.leave(conn) }},
+		{chars: "?", label: "?", action: txtHelpHelp, run: func(g *Game, _ io.Writer, _ string) { g.inputMode = modeHelp }},
+
+		{keys: []termbox.Key{termbox.KeyArrowLeft}, chars: "h", label: "←/h", action: txtHelpLeft, run: func(g *Game, _ io.Writer, _ string) { g.moveCursor(-1, 0) }},
+		{keys: []termbox.Key{termbox.KeyArrowRight}, chars: "l", label: "→/l", action: txtHelpRight, run: func(g *Game, _ io.Writer, _ string) { g.moveCursor(1, 0) }},
+		{keys: []termbox.Key{termbox.KeyArrowUp}, chars: "k", label: "↑/k", action: txtHelpUp, run: func(g *Game, _ io.Writer, _ string) { g.moveCursor(0, -1) }},
+		{keys: []termbox.Key{termbox.KeyArrowDown}, chars: "j", label: "↓/j", action: txtHelpDown, run: func(g *Game, _ io.Writer, _ string) { g.moveCursor(0, 1) }},
+		{keys: []termbox.Key{termbox.KeyEnter, termbox.KeySpace}, label: "Enter/Space", action: txtHelpSelect, run: func(g *Game, conn io.Writer, player string) {
+			if moveStr := g.handleMouseClick(player); moveStr != "" {
+				g.sendMove(conn, moveStr)
+			}
+		}},
+		{keys: []termbox.Key{termbox.KeyTab}, label: "Tab", action: txtHelpNextPiece, run: func(g *Game, _ io.Writer, player string) { g.cycleMovable(player, 1) }},
+		{chars: "bB", label: "b", action: txtHelpPrevPiece, run: func(g *Game, _ io.Writer, player string) { g.cycleMovable(player, -1) }},
+		{chars: "/", label: "/", action: txtHelpJump, run: func(g *Game, _ io.Writer, _ string) { g.startTextEntry(tr(txtJumpPrompt), g.jumpToSquare) }},
+
+		{keys: []termbox.Key{termbox.KeyBackspace, termbox.KeyBackspace2}, label: "Backspace", action: txtHelpUndoHeld, run: func(g *Game, _ io.Writer, _ string) { g.undoHeldMove() }},
+		{chars: "gG", label: "g", action: txtHelpReady, run: func(g *Game, conn io.Writer, _ string) { g.sendControl(conn, ctrlReady) }},
+		{chars: "dD", label: "d", action: txtHelpDraw, run: func(g *Game, conn io.Writer, _ string) { g.respond(conn, ctrlDraw) }},
+		{chars: "uU", label: "u", action: txtHelpTakeback, run: func(g *Game, conn io.Writer, _ string) { g.respond(conn, ctrlTakeback) }},
+		{chars: "nN", label: "n", action: txtHelpDecline, run: func(g *Game, conn io.Writer, _ string) { g.declineOffer(conn) }},
+		{chars: "rR", label: "r", action: txtHelpResign, run: func(g *Game, conn io.Writer, _ string) {
+			if !g.gameOver {
+				g.askConfirm(tr(txtResignAsk), func() { g.sendControl(conn, ctrlResign) })
+			}
+		}},
+		{chars: "iI", label: "i", action: txtHelpChat, run: func(g *Game, conn io.Writer, _ string) { g.chat(conn) }},
+
+		{chars: "aA", label: "a", action: txtHelpAnalyze, run: func(g *Game, _ io.Writer, _ string) { g.analysisBoard() }},
+		{chars: "wW", label: "w", action: txtHelpThreats, run: (*Game).toggleThreats},
+		{chars: "vV", label: "v", action: txtHelpControl, run: (*Game).toggleControl},
+		{chars: "fF", label: "f", action: txtHelpFlip, run: func(g *Game, _ io.Writer, _ string) { g.flipBoard() }},
+		{chars: "cC", label: "c", action: txtHelpTheme, run: func(g *Game, _ io.Writer, _ string) {
+			g.message = tr(txtThemeHint) // Reset message after theme change
+			g.cycleTheme()
+		}},
+		{chars: "tT", label: "t", action: txtHelpThemePicker, run: func(g *Game, _ io.Writer, _ string) { g.openThemePicker() }},
+		{chars: "mM", label: "m", action: txtHelpMute, run: func(g *Game, _ io.Writer, _ string) { g.toggleMute() }},
+		{keys: []termbox.Key{termbox.KeyCtrlL}, label: "Ctrl-L", action: txtHelpRedraw, run: func(g *Game, _ io.Writer, _ string) { g.repaint = true }},
+
+		{chars: "yY", label: "y", action: txtHelpCopy, run: func(g *Game, _ io.Writer, _ string) { g.copyMoveList() }},
+		{chars: "pP", label: "p", action: txtHelpPicture, run: func(g *Game, _ io.Writer, _ string) { g.screenshot() }},
+		{chars: "o", label: "o", action: txtHelpExportPosition, run: func(g *Game, _ io.Writer, _ string) { g.exportAnalysisURL(false) }},
+		{chars: "O", label: "O", action: txtHelpExportGame, run: func(g *Game, _ io.Writer, _ string) { g.exportAnalysisURL(true) }},
+	}
+}
+
+// helpLines are the rows of the help overlay, one per key binding.
+func helpLines() []string {
+	bindings := keyBindings()
+	width := 0
+	for _, b := range bindings {
+		width = max(width, len([]rune(b.label)))
+	}
+	lines := []string{tr(txtHelpTitle), ""}
+	for _, b := range bindings {
+		lines = append(lines, fmt.Sprintf("%-*s  %s", width, b.label, tr(b.action)))
+	}
+	// Left-align the rows by padding them to one width; drawPanel centres
+	// each line on its own.
+	inner := 0
+	for _, line := range lines {
+		inner = max(inner, len([]rune(line)))
+	}
+	for i := 2; i < len(lines); i++ {
+		lines[i] += strings.Repeat(" ", inner-len([]rune(lines[i])))
+	}
+	return append(lines, "", tr(txtHelpClose))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// lichessAnalysis is where exported positions and games are opened for
+// analysis with a full engine.
+const lichessAnalysis = "https://lichess.org/analysis/"
+
+// positionURL links to the current position on the lichess analysis board.
+// FEN only uses characters that are safe in a URL path once its spaces are
+// written as underscores, which is the form lichess expects.
+func (g *Game) positionURL() string {
+	return lichessAnalysis + "standard/" + strings.ReplaceAll(g.FEN(), " ", "_")
+}
+
+// gameURL links to the whole game on the lichess analysis board, as PGN so
+// that a continued game keeps its starting position.
+func (g *Game) gameURL() string {
+	var sb strings.Builder
+	g.writePGN(&sb, time.Now())
+	return lichessAnalysis + "pgn/" + url.PathEscape(sb.String())
+}
+
+// exportAnalysisURL copies a lichess link to the current position, or to
+// the whole game, to the clipboard where the terminal allows it. The link
+// is also kept to be printed once the board is closed, since it is too
+// long for the message bar.
+func (g *Game) exportAnalysisURL(wholeGame bool) {
+	link, copied, later := g.positionURL(), txtPositionLinkCopied, txtPositionLinkLater
+	if wholeGame {
+		link, copied, later = g.gameURL(), txtGameLinkCopied, txtGameLinkLater
+	}
+	g.analysisLink = link
+	if clipboardSupported() {
+		fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(link)))
+		g.message = tr(copied)
+		return
+	}
+	g.message = tr(later)
+}
+
+// printAnalysisLink prints the last exported lichess link, if any, after
+// the terminal has been restored.
+func (g *Game) printAnalysisLink() {
+	if g.analysisLink != "" {
+		fmt.Println("Analysis link:", g.analysisLink)
+	}
+}
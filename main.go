@@ -2,11 +2,15 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nsf/termbox-go"
 )
@@ -23,13 +27,34 @@ type Theme struct {
 	LightSquareBg termbox.Attribute
 	DarkSquareBg  termbox.Attribute
 	SelectedBg    termbox.Attribute
-	LegalMoveBg   termbox.Attribute
+	LegalMoveBg   termbox.Attribute // Quiet legal moves of the selected piece
+	CaptureMoveBg termbox.Attribute // Legal captures
+	CastleMoveBg  termbox.Attribute
+	EnPassantBg   termbox.Attribute
+	ControlBg     termbox.Attribute // Squares the selected piece controls, when shown
+	ThreatBg      termbox.Attribute // Own pieces that are attacked and undefended, when shown
+	PremoveBg     termbox.Attribute // From and to squares of a queued premove
 	CursorFg      termbox.Attribute
+	BackgroundBg  termbox.Attribute // The screen around the board, under the message bar and panels
 	MessageFg     termbox.Attribute
 	WhitePieceFg  termbox.Attribute
 	BlackPieceFg  termbox.Attribute
 }
 
+// moveBg is the highlight for a legal destination of the given kind, so
+// captures, castles and en passant stand out from quiet moves.
+func (t Theme) moveBg(kind moveKind) termbox.Attribute {
+	switch kind {
+	case moveCapture:
+		return t.CaptureMoveBg
+	case moveCastle:
+		return t.CastleMoveBg
+	case moveEnPassant:
+		return t.EnPassantBg
+	}
+	return t.LegalMoveBg
+}
+
 // Predefined color themes, revised for better contrast and variety.
 var themes = []Theme{
 	{
@@ -38,8 +63,15 @@ var themes = []Theme{
 		DarkSquareBg:  termbox.Attribute(130), // Rich, dark brown
 		SelectedBg:    termbox.Attribute(22),  // Deep Green
 		LegalMoveBg:   termbox.Attribute(57),  // Muted Blue
+		CaptureMoveBg: termbox.Attribute(125), // Deep Magenta
+		CastleMoveBg:  termbox.Attribute(30),  // Teal
+		EnPassantBg:   termbox.Attribute(91),  // Purple
+		ControlBg:     termbox.Attribute(166), // Burnt Orange
+		ThreatBg:      termbox.Attribute(196), // Bright Red
+		PremoveBg:     termbox.Attribute(67),  // Steel Blue
 		CursorFg:      termbox.ColorRed,
-		MessageFg:     termbox.ColorDefault,
+		BackgroundBg:  termbox.Attribute(52),  // Dark Mahogany
+		MessageFg:     termbox.Attribute(252), // Light Gray
 		WhitePieceFg:  termbox.Attribute(255), // Bright White
 		BlackPieceFg:  termbox.Attribute(232), // Off-black
 	},
@@ -49,8 +81,15 @@ var themes = []Theme{
 		DarkSquareBg:  termbox.Attribute(24),  // Deep Ocean Blue
 		SelectedBg:    termbox.Attribute(226), // Bright Yellow
 		LegalMoveBg:   termbox.Attribute(201), // Bright Magenta
+		CaptureMoveBg: termbox.Attribute(124), // Dark Red
+		CastleMoveBg:  termbox.Attribute(46),  // Bright Green
+		EnPassantBg:   termbox.Attribute(93),  // Violet
+		ControlBg:     termbox.Attribute(208), // Orange
+		ThreatBg:      termbox.Attribute(160), // Red
+		PremoveBg:     termbox.Attribute(37),  // Turquoise
 		CursorFg:      termbox.ColorYellow,
-		MessageFg:     termbox.ColorDefault,
+		BackgroundBg:  termbox.Attribute(17),  // Midnight Navy
+		MessageFg:     termbox.Attribute(252), // Light Gray
 		WhitePieceFg:  termbox.ColorWhite,
 		BlackPieceFg:  termbox.ColorBlack,
 	},
@@ -60,8 +99,15 @@ var themes = []Theme{
 		DarkSquareBg:  termbox.Attribute(22),  // Dark, forest green
 		SelectedBg:    termbox.Attribute(208), // Bright Orange
 		LegalMoveBg:   termbox.Attribute(135), // Purple
+		CaptureMoveBg: termbox.Attribute(125), // Deep Magenta
+		CastleMoveBg:  termbox.Attribute(33),  // Blue
+		EnPassantBg:   termbox.Attribute(97),  // Violet
+		ControlBg:     termbox.Attribute(220), // Gold
+		ThreatBg:      termbox.Attribute(196), // Bright Red
+		PremoveBg:     termbox.Attribute(67),  // Steel Blue
 		CursorFg:      termbox.ColorRed,
-		MessageFg:     termbox.ColorDefault,
+		BackgroundBg:  termbox.Attribute(234), // Deep Shade
+		MessageFg:     termbox.Attribute(252), // Light Gray
 		WhitePieceFg:  termbox.Attribute(231), // Off-white
 		BlackPieceFg:  termbox.Attribute(232), // Off-black
 	},
@@ -71,8 +117,15 @@ var themes = []Theme{
 		DarkSquareBg:  termbox.Attribute(238), // Dark gray granite
 		SelectedBg:    termbox.Attribute(160), // Red
 		LegalMoveBg:   termbox.Attribute(21),  // Blue
+		CaptureMoveBg: termbox.Attribute(124), // Dark Red
+		CastleMoveBg:  termbox.Attribute(30),  // Teal
+		EnPassantBg:   termbox.Attribute(55),  // Purple
+		ControlBg:     termbox.Attribute(28),  // Green
+		ThreatBg:      termbox.Attribute(202), // Orange Red
+		PremoveBg:     termbox.Attribute(136), // Dark Goldenrod
 		CursorFg:      termbox.ColorYellow,
-		MessageFg:     termbox.ColorDefault,
+		BackgroundBg:  termbox.Attribute(236), // Slate
+		MessageFg:     termbox.Attribute(252), // Light Gray
 		WhitePieceFg:  termbox.ColorBlack,
 		BlackPieceFg:  termbox.ColorWhite,
 	},
@@ -82,7 +135,14 @@ var themes = []Theme{
 		DarkSquareBg:  termbox.ColorDefault,
 		SelectedBg:    termbox.ColorGreen,
 		LegalMoveBg:   termbox.ColorYellow,
+		CaptureMoveBg: termbox.ColorMagenta,
+		CastleMoveBg:  termbox.ColorBlue,
+		EnPassantBg:   termbox.ColorMagenta,
+		ControlBg:     termbox.ColorCyan,
+		ThreatBg:      termbox.ColorMagenta,
+		PremoveBg:     termbox.ColorRed,
 		CursorFg:      termbox.ColorRed,
+		BackgroundBg:  termbox.ColorDefault,
 		MessageFg:     termbox.ColorDefault,
 		WhitePieceFg:  termbox.ColorWhite,
 		BlackPieceFg:  termbox.ColorBlack,
@@ -91,19 +151,118 @@ var themes = []Theme{
 
 // Game represents the entire state of the chess game.
 type Game struct {
-	board             [8][8]*Piece
-	currentPlayer     string
-	gameOver          bool
-	lock              sync.Mutex
-	cursorX           int
-	cursorY           int
-	selectedX         int
-	selectedY         int
-	message           string
-	legalMoves        map[string]bool // Stores legal moves for the selected piece
-	currentThemeIndex int
-	squareWidth       int
-	squareHeight      int
+	board               [8][8]*Piece
+	currentPlayer       string
+	gameOver            bool
+	lock                sync.Mutex
+	cursorX             int
+	cursorY             int
+	selectedX           int
+	selectedY           int
+	message             string
+	legalMoves          map[string]moveKind // Stores legal moves for the selected piece
+	moveHistory         []string            // Moves played so far, in wire format (e.g. "e2e4")
+	sanHistory          []string            // The same moves in SAN (e.g. "Nf3"), with check suffixes
+	events              []gameEvent         // Every move and control message, in order, for review after the game
+	castling            string              // Castles still allowed, as FEN letters (e.g. "KQkq")
+	enPassant           string              // Square a pawn can capture onto en passant (e.g. "e3"), or "-" as in FEN
+	halfmoveClock       int                 // Halfmoves since the last capture or pawn move, for the fifty-move rule
+	fullmoveNumber      int                 // Number of the move in progress, from 1, going up after each black move
+	hash                uint64              // Zobrist hash of the position, kept up to date move by move (see zobrist.go)
+	repetitions         map[uint64]int      // How often each position has occurred, by hash
+	checks              map[string]int      // Checks each color has given, counted for three-check
+	analysisLink        string              // Last lichess link exported with 'o' or 'O', printed on quitting
+	premoves            []string            // Our moves queued while the opponent is to move, in wire format, played one a turn
+	maxPremoves         int                 // How many premoves can be queued at once
+	offer               string              // Pending control offer, ctrlDraw or ctrlTakeback, or empty
+	offerFrom           string              // Color that made the pending offer
+	startFEN            string              // Position the game started from, empty for the standard one
+	settingUp           bool                // The host may still send the position we continue from
+	hosting             bool                // This side runs the game, as host or server, and may adjudicate it
+	opponentQuit        bool                // The opponent said they were leaving before the connection closed
+	disconnected        bool                // The connection to the opponent or server has closed
+	readyCheck          bool                // Moves wait until both players confirm they are ready
+	ready               map[string]bool     // Colors that have confirmed, during a ready check
+	idleTimeout         time.Duration       // Adjudicate against a side that takes longer to move; zero disables
+	idleDraw            bool                // Adjudicate an idle game as a draw rather than a loss
+	idleTimer           *time.Timer
+	idleSeq             int
+	clock               *Clock // The players' clocks, nil in a game without them
+	maxMoves            int    // Full moves after which an unfinished game is adjudicated; zero never
+	maxMovesByMaterial  bool   // Adjudicate at maxMoves by material rather than as a draw
+	clockTimer          timer
+	clockSeq            int
+	timeSource          timeSource        // The clocks' time; nil uses the wall clock
+	onAdjudicate        func(verb string) // Tells the players the game was adjudicated
+	result              string            // PGN result token, "*" while the game is in progress
+	termination         string            // How the game ended, e.g. "checkmate" or "abandoned"
+	playerColor         string            // Color played from this client in a networked game
+	localName           string            // Name of the player at this client, for PGN headers
+	autosaveDir         string            // Where finished games are saved as PGN; empty disables it
+	ackTimeout          time.Duration     // How long to wait for a move ack; zero disables the check
+	sendAcks            bool              // The opponent asked for an ack of every move it sends
+	sendDelay           time.Duration     // How long our moves are held before sending, so they can be taken back unseen
+	heldMove            string            // Our move held back from sending, in wire format, or empty
+	holdSeq             int
+	ackPending          bool
+	ackSeq              int
+	deliveryUnconfirmed bool
+	lastHeard           time.Time // When anything last arrived from the opponent
+	spectatorCount      int       // Spectators watching, as last reported by the server
+	viewSent            string    // The side our board was last reported drawn from, or empty to report it again
+	follow              string    // The player whose view of the board a spectator mirrors, or empty to turn it freely
+	currentThemeIndex   int
+	flipped             bool          // Draw the board from black's side
+	showControl         bool          // Highlight every square the selected piece controls
+	showThreats         bool          // Highlight our pieces that are attacked and undefended
+	showHeatmap         bool          // Shade every square by which side controls it, on the analysis board
+	analysis            bool          // This is a private analysis board; its moves are never sent
+	spectating          bool          // Watching a served game; nothing is sent and no move can be made
+	analysing           bool          // An analysis board is open over this game, so it doesn't draw
+	keyboardOnly        bool          // No mouse events are requested; the status bar says so
+	wrapCursor          bool          // Keyboard cursor wraps around the board edges instead of stopping
+	touchMove           bool          // A selected piece with a legal move cannot be put back
+	headless            bool          // Skip all terminal output, e.g. when driven by tests
+	strict              bool          // Assert the rules engine is consistent around every move
+	freestyle           bool          // Any piece may move to any square not held by its own side; taking a king wins
+	rules               variant       // Decides when the game is over; nil plays standard or freestyle chess
+	glyphs              map[rune]rune // Glyph set used to draw pieces; nil draws the Unicode symbols
+	pieceStyle          pieceStyle    // How pieces are drawn inside their squares; nil draws one glyph
+	sound               soundPlayer   // Plays move cues; nil keeps the game silent
+	muted               bool
+	prefs               *preferences // Saved when settings change; nil for games that don't persist them
+	inputMode           inputMode
+	inputPrompt         string
+	inputText           string
+	inputSubmit         func(text string) // Receives the text when a text entry is submitted
+	inputConfirm        func()            // Runs when a pending question is answered yes
+	pickerOrigin        int               // Theme to restore if the theme picker is cancelled
+	askPromotion        bool              // Ask which piece a pawn promotes to rather than always queening
+	promotion           *promotionChoice  // The promotion waiting for a piece, while the menu is open
+	quit                bool
+	repaint             bool // Redraw every cell on the next frame, not just the changed ones
+	squareWidth         int
+	squareHeight        int
+	moveCache           moveCache
+}
+
+// moveKind classifies a legal destination so it can be drawn distinctly.
+type moveKind int
+
+const (
+	moveNone moveKind = iota // Not a legal destination
+	moveQuiet
+	moveCapture
+	moveEnPassant
+	moveCastle
+)
+
+// Markers drawn in the corner of each legal destination, by move kind.
+var moveMarkers = map[moveKind]rune{
+	moveQuiet:     '·',
+	moveCapture:   '×',
+	moveEnPassant: 'e',
+	moveCastle:    'o',
 }
 
 // Unicode characters for chess pieces
@@ -122,6 +281,58 @@ var pieces = map[string]rune{
 	"black_pawn":   '♟',
 }
 
+// Glyph sets for drawing pieces, selectable with -pieces. Each maps a piece's
+// Unicode symbol to the rune drawn for it; symbols missing from a set are
+// drawn as-is.
+var glyphSets = map[string]map[rune]rune{
+	"unicode": {},
+	"ascii": {
+		'♔': 'K', '♕': 'Q', '♖': 'R', '♗': 'B', '♘': 'N', '♙': 'P',
+		'♚': 'k', '♛': 'q', '♜': 'r', '♝': 'b', '♞': 'n', '♟': 'p',
+	},
+}
+
+// defaultGlyphSet picks the ASCII set when the locale does not advertise
+// UTF-8, since such terminals usually cannot show the chess glyphs.
+func defaultGlyphSet() string {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			v = strings.ToLower(v)
+			if strings.Contains(v, "utf-8") || strings.Contains(v, "utf8") {
+				return "unicode"
+			}
+			return "ascii"
+		}
+	}
+	return "ascii"
+}
+
+// glyph returns the rune to draw for piece in the selected glyph set.
+func (g *Game) glyph(piece *Piece) rune {
+	if r, ok := g.glyphs[piece.symbol]; ok {
+		return r
+	}
+	return piece.symbol
+}
+
+// drawPiece draws piece at a terminal cell in its theme color. Every piece
+// on screen is drawn through here, so the glyph always follows the piece's
+// type and the color its owner, wherever it is shown.
+func (g *Game) drawPiece(x, y int, piece *Piece, theme Theme, bg termbox.Attribute) {
+	fg := theme.WhitePieceFg
+	if piece.color == "black" {
+		fg = theme.BlackPieceFg
+	}
+	termbox.SetCell(x, y, g.glyph(piece), fg, bg)
+}
+
+// newPiece returns a piece of the given color and kind, e.g. ("white",
+// "queen"). Pieces that change or appear mid-game, such as a promoted pawn,
+// are made here so their symbol always matches their kind.
+func newPiece(color, kind string) *Piece {
+	return &Piece{color, pieces[color+"_"+kind]}
+}
+
 // NewGame initializes a new game with the standard chess starting position.
 func NewGame() *Game {
 	g := &Game{
@@ -129,9 +340,15 @@ func NewGame() *Game {
 		gameOver:          false,
 		selectedX:         -1,
 		selectedY:         -1,
-		message:           "Welcome! White's turn. Press 'c' to change theme.",
-		legalMoves:        make(map[string]bool),
+		message:           tr(txtWelcome),
+		legalMoves:        make(map[string]moveKind),
+		result:            resultOngoing,
+		castling:          "KQkq",
+		enPassant:         "-",
+		fullmoveNumber:    1,
 		currentThemeIndex: 0,
+		maxMoves:          defaultMaxMoves,
+		maxPremoves:       1,
 		squareWidth:       8, // Kept squares large
 		squareHeight:      4, // Kept squares large
 	}
@@ -159,208 +376,537 @@ func NewGame() *Game {
 			&Piece{"white", pieces["white_king"]}, &Piece{"white", pieces["white_bishop"]}, &Piece{"white", pieces["white_knight"]}, &Piece{"white", pieces["white_rook"]},
 		},
 	}
+	g.hash = g.computeHash()
+	g.recordPosition()
 	return g
 }
 
 // drawBoard renders the entire TUI to the screen using 256 colors.
 func (g *Game) drawBoard() {
+	if g.headless {
+		return
+	}
 	// Lock the game state to prevent race conditions with the network goroutine
 	g.lock.Lock()
 	defer g.lock.Unlock()
+	if g.analysing {
+		// Wake the analysis board so it can report what changed.
+		termbox.Interrupt()
+		return
+	}
 
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
 	theme := themes[g.currentThemeIndex]
+	termbox.Clear(theme.MessageFg, theme.BackgroundBg)
+	highlights := g.highlights()
+	layout := g.squareLayout()
 
 	// Draw board squares and pieces
 	for y := 0; y < 8; y++ {
 		for x := 0; x < 8; x++ {
-			bg := theme.LightSquareBg
-			if (x+y)%2 == 0 {
-				bg = theme.DarkSquareBg
-			}
-
-			if x == g.selectedX && y == g.selectedY {
-				bg = theme.SelectedBg
-			} else if g.legalMoves[fmt.Sprintf("%d,%d", x, y)] {
-				bg = theme.LegalMoveBg
-			}
+			bg := highlights.squareBg(theme, x, y)
+			kind := g.legalMoves[squareKey(x, y)]
+			sx, sy := g.squareToScreen(x, y)
 
 			// Draw the larger cell for the board square
 			for i := 0; i < g.squareHeight; i++ {
 				for j := 0; j < g.squareWidth; j++ {
-					termbox.SetCell(x*g.squareWidth+j, y*g.squareHeight+i, ' ', termbox.ColorDefault, bg)
+					termbox.SetCell(sx+j, sy+i, ' ', termbox.ColorDefault, bg)
 				}
 			}
 
 			if piece := g.board[y][x]; piece != nil {
-				fg := theme.WhitePieceFg
-				if piece.color == "black" {
-					fg = theme.BlackPieceFg
-				}
-
-				// Center the piece symbol within the large square.
-				pieceX := x*g.squareWidth + (g.squareWidth / 2) - 1
-				pieceY := y*g.squareHeight + (g.squareHeight / 2) - 1
-				termbox.SetCell(pieceX, pieceY, piece.symbol, fg, bg)
+				g.style().drawInSquare(g, sx, sy, piece, theme, bg)
+			}
+			if kind != moveNone && layout.moveMarker {
+				termbox.SetCell(sx+layout.moveX, sy+layout.moveY, moveMarkers[kind], theme.CursorFg, bg)
+			} else if link := highlights.premove[y][x]; link > 0 && len(g.premoves) > 1 && layout.moveMarker {
+				// Number the links of a chain so their order shows.
+				termbox.SetCell(sx+layout.moveX, sy+layout.moveY, rune('0'+link%10), theme.CursorFg, bg)
 			}
 		}
 	}
-	// Draw cursor on the edges of the square
-	cursorYPos := g.cursorY*g.squareHeight + (g.squareHeight / 2) - 1
-	termbox.SetCell(g.cursorX*g.squareWidth, cursorYPos, '>', theme.CursorFg, termbox.ColorDefault)
-	termbox.SetCell(g.cursorX*g.squareWidth+g.squareWidth-1, cursorYPos, '<', theme.CursorFg, termbox.ColorDefault)
+	// Draw cursor on the edges of the square, level with the piece, or
+	// reverse the piece's cell when the square is too narrow for that.
+	cursorX, cursorY := g.squareToScreen(g.cursorX, g.cursorY)
+	if layout.cursorMarkers {
+		termbox.SetCell(cursorX, cursorY+layout.pieceY, '>', theme.CursorFg, termbox.ColorDefault)
+		termbox.SetCell(cursorX+g.squareWidth-1, cursorY+layout.pieceY, '<', theme.CursorFg, termbox.ColorDefault)
+	} else if width, height := termbox.Size(); cursorX+layout.pieceX < width && cursorY+layout.pieceY < height {
+		cell := &termbox.CellBuffer()[(cursorY+layout.pieceY)*width+cursorX+layout.pieceX]
+		cell.Fg |= termbox.AttrReverse
+	}
+	if g.inputMode == modePromotion {
+		g.drawPromotionMenu(theme)
+	}
 
 	// Draw message bar below the board
 	messageY := g.squareHeight*8 + 2
-	themeName := fmt.Sprintf("Theme: %s | ", theme.Name)
+	themeName := tr(txtThemeName, theme.Name) + " | "
+	if g.keyboardOnly {
+		themeName += tr(txtKeyboardMode) + " | "
+	}
 	fullMessage := themeName + g.message
+	if g.deliveryUnconfirmed {
+		fullMessage = themeName + tr(txtDeliveryUnconfirmed) + " | " + g.message
+	}
+	if g.analysis {
+		fullMessage = themeName + tr(txtAnalysisNotSent) + " | " + g.message
+	}
+	if status := g.drawStatus(); status != "" {
+		fullMessage += " | " + status
+	}
+	if checks := g.checkStatus(); checks != "" {
+		fullMessage += " | " + checks
+	}
+	if clock := g.clockStatus(); clock != "" {
+		fullMessage += " | " + clock
+	}
+	if waiting := g.waitingStatus(); waiting != "" {
+		fullMessage += " | " + waiting
+	}
+	if presence := g.presenceStatus(); presence != "" {
+		fullMessage += " | " + presence
+	}
 	for i, r := range fullMessage {
-		termbox.SetCell(i, messageY, r, theme.MessageFg, termbox.ColorDefault)
+		termbox.SetCell(i, messageY, r, theme.MessageFg, theme.BackgroundBg)
+	}
+	g.drawThemeStrip(theme)
+	switch g.inputMode {
+	case modeGameOver:
+		g.drawGameOver(theme)
+	case modeThemePicker:
+		g.drawPanel(theme, g.themePickerLines())
+	case modeHelp:
+		g.drawPanel(theme, helpLines())
+	}
+	if g.repaint {
+		// Sync rewrites the whole terminal rather than the cells termbox
+		// thinks have changed, which repairs a screen garbled by output
+		// from elsewhere.
+		g.repaint = false
+		termbox.Sync()
+		return
 	}
 	termbox.Flush()
 }
 
-// applyMove commits a move to the board state.
-func (g *Game) applyMove(fromY, fromX, toY, toX int) {
+// applyMove commits a move to the board state. A pawn reaching the last
+// rank becomes the promotion piece, or a queen for noPromotion.
+func (g *Game) applyMove(fromY, fromX, toY, toX int, promotion string) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	if g.strict {
+		g.assertLegal(fromY, fromX, toY, toX)
+	}
+	g.offer, g.offerFrom = "", "" // Moving lets any unanswered offer lapse
+	san := g.san(fromY, fromX, toY, toX, promotion)
 	piece := g.board[fromY][fromX]
-	// Check for game over (king capture)
-	if targetPiece := g.board[toY][toX]; targetPiece != nil {
-		if targetPiece.symbol == pieces["white_king"] || targetPiece.symbol == pieces["black_king"] {
-			g.gameOver = true
-			g.message = fmt.Sprintf("Game Over! %s wins.", g.currentPlayer)
-		}
+	captured := g.board[toY][toX]
+	kind, promoted := moveQuiet, false
+	// Take what changes out of the hash now and put its new state back in
+	// once the move is made.
+	hash := g.hash ^ zobristRights(g.castling) ^ g.enPassantKey() ^ zobristSquare(piece, fromY, fromX) ^ zobristBlack
+	if captured != nil {
+		kind = moveCapture
+		hash ^= zobristSquare(captured, toY, toX)
+	}
+	isPawn := piece.symbol == pieces[piece.color+"_pawn"]
+	if isPawn || g.board[toY][toX] != nil {
+		g.halfmoveClock = 0
+	} else {
+		g.halfmoveClock++
+	}
+	if isPawn && fromX != toX && g.board[toY][toX] == nil && !g.freestyle {
+		// En passant: the captured pawn is beside us, not on the target.
+		hash ^= zobristSquare(g.board[fromY][toX], fromY, toX)
+		g.board[fromY][toX] = nil
+		kind = moveEnPassant
 	}
-
 	g.board[toY][toX] = piece
 	g.board[fromY][fromX] = nil
+	if isPawn && (toY == 0 || toY == 7) && !g.freestyle {
+		// toY is a board row, not a screen one, so the flip never changes
+		// which rank promotes.
+		if promotion == noPromotion {
+			promotion = "queen"
+		}
+		g.board[toY][toX] = newPiece(piece.color, promotion)
+		promoted = true
+	} else {
+		promotion = noPromotion
+	}
+	hash ^= zobristSquare(g.board[toY][toX], toY, toX)
+	g.moveHistory = append(g.moveHistory, formatMove(fromY, fromX, toY, toX)+promotionLetter(promotion))
+
+	// Castling is sent as the king's two-square move; bring the rook along.
+	if piece.symbol == pieces[piece.color+"_king"] && !g.freestyle {
+		if toX-fromX == 2 {
+			hash ^= zobristSquare(g.board[toY][7], toY, 7) ^ zobristSquare(g.board[toY][7], toY, 5)
+			g.board[toY][5], g.board[toY][7] = g.board[toY][7], nil
+			kind = moveCastle
+		} else if fromX-toX == 2 {
+			hash ^= zobristSquare(g.board[toY][0], toY, 0) ^ zobristSquare(g.board[toY][0], toY, 3)
+			g.board[toY][3], g.board[toY][0] = g.board[toY][0], nil
+			kind = moveCastle
+		}
+	}
+	g.updateCastlingRights(fromY, fromX, toY, toX)
+	g.enPassant = "-"
+	if isPawn && (toY-fromY == 2 || fromY-toY == 2) && !g.freestyle {
+		g.enPassant = squareName(fromX, (fromY+toY)/2)
+	}
+	g.hash = hash ^ zobristRights(g.castling)
 
 	// Switch player
 	if g.currentPlayer == "white" {
 		g.currentPlayer = "black"
-		g.message = "Black's turn."
+		g.message = tr(txtTurn, colorName("black"))
 	} else {
 		g.currentPlayer = "white"
-		g.message = "White's turn."
+		g.message = tr(txtTurn, colorName("white"))
+		g.fullmoveNumber++
+	}
+	g.hash ^= g.enPassantKey() // Whether it counts depends on who is to move
+	g.recordPosition()
+	g.sanHistory = append(g.sanHistory, san+g.checkSuffix())
+	if g.strict {
+		g.assertConsistent()
+	}
+	g.pressClock(piece.color)
+
+	// Check for game over, as the variant decides. This sees the board
+	// after any promotion, so e8=Q# ends the game, and both sides of a
+	// networked game reach the result themselves: it is never sent over
+	// the wire.
+	check := g.InCheck(g.currentPlayer)
+	if check {
+		if g.checks == nil {
+			g.checks = make(map[string]int)
+		}
+		g.checks[piece.color]++ // Once per move, however many pieces give check
+	}
+	if end := g.variant().ending(g, piece.color, captured); end.termination != "" {
+		g.endGame(end.result, end.termination, end.message)
+	} else if check {
+		g.message += " " + tr(txtCheck)
+	}
+	ev := gameEvent{kind: eventMove, color: piece.color, move: g.lastMove(), san: g.sanHistory[len(g.sanHistory)-1], piece: pieceKind(piece), check: check, balance: g.Evaluate()}
+	ev.mate = g.gameOver && g.termination == "checkmate"
+	if captured != nil {
+		ev.captured = pieceKind(captured)
+	} else if kind == moveEnPassant {
+		ev.captured = "pawn"
+	}
+	g.logEvent(ev)
+	g.armIdleTimer()
+
+	switch {
+	case g.gameOver && g.termination == "checkmate":
+		g.playCue(cueCheckmate)
+	case g.gameOver && g.termination == "stalemate":
+		g.playCue(cueStalemate)
+	case g.gameOver:
+		g.playCue(cueGameOver)
+	case check:
+		g.playCue(cueCheck)
+	default:
+		g.playCue(moveCue(kind, promoted))
+	}
+}
+
+// squareLayout places what is drawn inside a square, as offsets from its
+// top-left cell.
+type squareLayout struct {
+	pieceX, pieceY int  // The middle cell, or the upper-left of the middle ones when a side is even
+	cursorMarkers  bool // The cursor's '>' and '<' fit on the left and right edges beside the piece
+	moveX, moveY   int  // The legal-move marker, in the bottom row next to the left edge
+	moveMarker     bool // The move marker fits without covering the piece
+}
+
+// squareLayout works out squareLayout for the current square size, which
+// may be as small as one cell.
+func (g *Game) squareLayout() squareLayout {
+	w, h := g.squareWidth, g.squareHeight
+	l := squareLayout{
+		pieceX:        (w - 1) / 2,
+		pieceY:        (h - 1) / 2,
+		cursorMarkers: w >= 3,
+		moveX:         min(1, w-1),
+		moveY:         h - 1,
+	}
+	l.moveMarker = l.moveX != l.pieceX || l.moveY != l.pieceY
+	return l
+}
+
+// squareKeys holds the legalMoves key of every square, built once so the
+// draw loop and move generation never format a key.
+var squareKeys = func() (keys [8][8]string) {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			keys[y][x] = strconv.Itoa(x) + "," + strconv.Itoa(y)
+		}
+	}
+	return keys
+}()
+
+// squareKey returns the legalMoves key for board square (x, y). Keys are
+// always in board coordinates; only rendering and mouse input know about
+// flipping.
+func squareKey(x, y int) string {
+	return squareKeys[y][x]
+}
+
+// squareToScreen returns the top-left terminal cell of board square (x, y).
+func (g *Game) squareToScreen(x, y int) (int, int) {
+	if g.flipped {
+		x, y = 7-x, 7-y
+	}
+	return x * g.squareWidth, y * g.squareHeight
+}
+
+// screenToSquare returns the board square under terminal cell (sx, sy),
+// clamped to the board. It is the inverse of squareToScreen.
+func (g *Game) screenToSquare(sx, sy int) (int, int) {
+	x := min(max(sx/g.squareWidth, 0), 7)
+	y := min(max(sy/g.squareHeight, 0), 7)
+	if g.flipped {
+		x, y = 7-x, 7-y
+	}
+	return x, y
+}
+
+// Game results, as written in PGN.
+const (
+	resultWhiteWins = "1-0"
+	resultBlackWins = "0-1"
+	resultDraw      = "1/2-1/2"
+	resultOngoing   = "*"
+)
+
+// winResult returns the result token for a win by color.
+func winResult(color string) string {
+	if color == "white" {
+		return resultWhiteWins
 	}
+	return resultBlackWins
+}
+
+// endGame records how the game ended. Every ending goes through here,
+// whether it comes from a move (checkmate, stalemate) or from outside the
+// board (abandonment, abort), so terminal events never add a move to the
+// history.
+func (g *Game) endGame(result, termination, message string) {
+	g.gameOver = true
+	g.result = result
+	g.termination = termination
+	g.message = message
+	g.premoves = nil
+	g.inputMode = modeGameOver
+	if g.clock != nil {
+		g.clock.Stop()
+	}
+	if result != resultOngoing {
+		g.autosave()
+	}
+}
+
+// moveCursor moves the cursor one square in a screen direction, so left is
+// always visually left even when the board is flipped. At the board's edge
+// the cursor stops, or wraps to the opposite edge when wrapCursor is set.
+func (g *Game) moveCursor(dx, dy int) {
+	if g.flipped {
+		dx, dy = -dx, -dy
+	}
+	g.cursorX = g.stepCursor(g.cursorX, dx)
+	g.cursorY = g.stepCursor(g.cursorY, dy)
+}
+
+// stepCursor moves one cursor coordinate by delta, applying the edge
+// behavior.
+func (g *Game) stepCursor(pos, delta int) int {
+	pos += delta
+	if g.wrapCursor {
+		return (pos + 8) % 8
+	}
+	return min(max(pos, 0), 7)
 }
 
 // handleMouseClick processes user input from mouse clicks.
 func (g *Game) handleMouseClick(playerColor string) string {
 	x, y := g.cursorX, g.cursorY
 
+	if g.gameOver {
+		return ""
+	}
+	if g.spectating {
+		g.message = tr(txtSpectatorMove)
+		return ""
+	}
+	if !g.readyToPlay() {
+		g.message = tr(txtReadyWaiting)
+		return ""
+	}
 	if g.currentPlayer != playerColor {
-		g.message = "Not your turn!"
+		g.premoveClick(playerColor)
 		return ""
 	}
 
 	if g.selectedX != -1 {
-		if g.legalMoves[fmt.Sprintf("%d,%d", x, y)] {
-			moveStr := fmt.Sprintf("%c%d%c%d", 'a'+rune(g.selectedX), 8-g.selectedY, 'a'+rune(x), 8-y)
-			g.applyMove(g.selectedY, g.selectedX, y, x)
+		if g.legalMoves[squareKey(x, y)] != moveNone {
+			if g.askPromotion && g.promotes(g.selectedY, g.selectedX, y) {
+				g.openPromotion(x, y)
+				return ""
+			}
+			moveStr := formatMove(g.selectedY, g.selectedX, y, x)
+			g.applyMove(g.selectedY, g.selectedX, y, x, noPromotion)
 			g.selectedX, g.selectedY = -1, -1
-			g.legalMoves = make(map[string]bool)
+			g.legalMoves = make(map[string]moveKind)
 			return moveStr
+		} else if g.touchMove && len(g.legalMoves) > 0 {
+			// A touched piece that can move must be moved.
+			g.message = tr(txtTouchMove, squareName(g.selectedX, g.selectedY))
+			return ""
+		} else if piece := g.board[y][x]; piece != nil && piece.color == g.currentPlayer && (x != g.selectedX || y != g.selectedY) {
+			// Another of our pieces: switch to it in one click.
+			g.selectedX, g.selectedY = x, y
+			g.calculateLegalMoves(y, x)
+			g.message = g.mobility(y, x)
+			return ""
 		} else {
+			g.message = tr(txtMoveCancelled)
+			if reason := g.whyIllegal(g.selectedY, g.selectedX, y, x); reason != ErrOwnPiece && (x != g.selectedX || y != g.selectedY) {
+				g.message = tr(txtMoveCancelledWhy, reason)
+			}
 			g.selectedX, g.selectedY = -1, -1
-			g.legalMoves = make(map[string]bool)
-			g.message = "Move cancelled."
+			g.legalMoves = make(map[string]moveKind)
 			return ""
 		}
 	} else {
 		piece := g.board[y][x]
 		if piece != nil && piece.color == g.currentPlayer {
 			g.selectedX, g.selectedY = x, y
-			g.message = "Piece selected. Click a destination square."
 			g.calculateLegalMoves(y, x)
+			g.message = g.mobility(y, x)
 		} else {
-			g.message = "Select one of your own pieces."
+			g.message = tr(txtOwnPiece)
 		}
 	}
 	return ""
 }
 
+// ownColor is the side this client plays, or the side to move on a board
+// where one player moves both colors.
+func (g *Game) ownColor() string {
+	if g.playerColor != "" {
+		return g.playerColor
+	}
+	return g.currentPlayer
+}
+
 // play is the main game loop.
-func (g *Game) play(conn net.Conn, player string) {
-	go func() {
-		reader := bufio.NewReader(conn)
-		for {
-			moveStr, err := reader.ReadString('\n')
-			if err != nil {
-				g.message = "Opponent disconnected."
-				g.gameOver = true
-				g.drawBoard()
-				return
-			}
-			moveStr = strings.TrimSpace(moveStr)
-			fromRow, fromCol, toRow, toCol, _ := parseMove(moveStr)
-			g.applyMove(fromRow, fromCol, toRow, toCol)
-			g.drawBoard()
-		}
-	}()
+func (g *Game) play(conn io.ReadWriteCloser, player string) {
+	g.playerColor = player
+	g.askPromotion = true // This loop is the one that handles the menu
+	go g.receiveMessages(conn)
+	go sendHeartbeats(conn)
+	stop := make(chan struct{})
+	defer close(stop)
+	go g.animateWaiting(stop)
+	defer g.sayGoodbye(conn)
 
-	for !g.gameOver {
+	// Keep running after the game ends so the final message stays visible
+	// until the player quits.
+	for {
+		if shuttingDown.Load() {
+			g.leave(conn)
+			return
+		}
 		g.drawBoard()
 		switch ev := termbox.PollEvent(); ev.Type {
 		case termbox.EventKey:
-			if ev.Key == termbox.KeyEsc {
-				g.gameOver = true
+			if g.handleKey(ev, conn, player) {
 				return
 			}
-			if ev.Ch == 'c' || ev.Ch == 'C' {
-				g.currentThemeIndex = (g.currentThemeIndex + 1) % len(themes)
-				g.message = "Press 'c' to change theme." // Reset message after theme change
-			}
 		case termbox.EventMouse:
-			g.cursorX = ev.MouseX / g.squareWidth
-			g.cursorY = ev.MouseY / g.squareHeight
-			if g.cursorX < 0 {
-				g.cursorX = 0
-			}
-			if g.cursorX > 7 {
-				g.cursorX = 7
+			if g.wheelTheme(ev) {
+				break
 			}
-			if g.cursorY < 0 {
-				g.cursorY = 0
-			}
-			if g.cursorY > 7 {
-				g.cursorY = 7
+			if g.inputMode == modePromotion {
+				if ev.Key == termbox.MouseLeft {
+					if moveStr := g.clickPromotion(ev.MouseX, ev.MouseY); moveStr != "" {
+						g.sendMove(conn, moveStr)
+					}
+				}
+				break
 			}
+			g.cursorX, g.cursorY = g.screenToSquare(ev.MouseX, ev.MouseY)
 
 			if ev.Key == termbox.MouseLeft {
 				moveStr := g.handleMouseClick(player)
 				if moveStr != "" {
-					fmt.Fprintf(conn, "%s\n", moveStr)
+					g.sendMove(conn, moveStr)
 				}
 			}
 		case termbox.EventError:
 			panic(ev.Err)
 		}
+		g.reportView(conn)
 	}
 }
 
-// getLocalIP finds the non-loopback local IP address of the host.
-func getLocalIP() string {
+// localIP is an address of this host that an opponent can connect to.
+type localIP struct {
+	ip     string // Empty if no suitable address was found
+	family string // "ipv4" or "ipv6"
+}
+
+// getLocalIP finds a non-loopback local IP address of the host, preferring
+// IPv4 and falling back to a global IPv6 address.
+func getLocalIP() localIP {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
-		return ""
+		return localIP{}
 	}
+	var v6 localIP
 	for _, address := range addrs {
 		if ipnet, ok := address.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
 			if ipnet.IP.To4() != nil {
-				return ipnet.IP.String()
+				return localIP{ip: ipnet.IP.String(), family: "ipv4"}
+			}
+			if v6.ip == "" && ipnet.IP.IsGlobalUnicast() {
+				v6 = localIP{ip: ipnet.IP.String(), family: "ipv6"}
 			}
 		}
 	}
+	return v6
+}
+
+// noPromotion is the promotion parseMove returns for a move without one.
+const noPromotion = ""
+
+// promotionLetters are the letters that may end a move in wire format, as
+// in "e7e8n", and the piece each promotes to.
+var promotionLetters = map[byte]string{'q': "queen", 'r': "rook", 'b': "bishop", 'n': "knight"}
+
+// promotionLetter is the letter that ends a move in wire format promoting
+// to kind. A queen, the piece a four-letter move promotes to, has none, so
+// every move that was legal before underpromotion is still written the
+// same way.
+func promotionLetter(kind string) string {
+	for letter, k := range promotionLetters {
+		if k == kind && kind != "queen" {
+			return string(letter)
+		}
+	}
 	return ""
 }
 
-// parseMove converts algebraic notation to board coordinates.
-func parseMove(move string) (int, int, int, int, bool) {
-	if len(move) != 4 {
-		return 0, 0, 0, 0, false
+// parseMove converts algebraic notation to board coordinates and the piece
+// a fifth letter promotes to, or noPromotion for a four-letter move.
+func parseMove(move string) (int, int, int, int, string, bool) {
+	if len(move) != 4 && len(move) != 5 {
+		return 0, 0, 0, 0, noPromotion, false
 	}
 	fromCol := int(move[0] - 'a')
 	fromRow := 8 - int(move[1]-'0')
@@ -368,97 +914,187 @@ func parseMove(move string) (int, int, int, int, bool) {
 	toRow := 8 - int(move[3]-'0')
 
 	if fromCol < 0 || fromCol > 7 || fromRow < 0 || fromRow > 7 || toCol < 0 || toCol > 7 || toRow < 0 || toRow > 7 {
-		return 0, 0, 0, 0, false
+		return 0, 0, 0, 0, noPromotion, false
+	}
+	promotion := noPromotion
+	if len(move) == 5 {
+		var ok bool
+		if promotion, ok = promotionLetters[move[4]]; !ok {
+			return 0, 0, 0, 0, noPromotion, false
+		}
 	}
-	return fromRow, fromCol, toRow, toCol, true
+	return fromRow, fromCol, toRow, toCol, promotion, true
+}
+
+// formatMove converts board coordinates to algebraic notation, the inverse
+// of parseMove.
+func formatMove(fromRow, fromCol, toRow, toCol int) string {
+	return squareName(fromCol, fromRow) + squareName(toCol, toRow)
+}
+
+// squareName returns the algebraic name of board square (x, y), e.g. "e4".
+func squareName(x, y int) string {
+	return fmt.Sprintf("%c%d", 'a'+rune(x), 8-y)
+}
+
+// parseSquare converts an algebraic square name to board coordinates.
+func parseSquare(name string) (int, int, bool) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return 0, 0, false
+	}
+	return int(name[0] - 'a'), 8 - int(name[1]-'0'), true
 }
 
 func main() {
-	fmt.Println("Welcome to Go Chess!")
-	fmt.Print("Do you want to (h)ost or (j)oin a game? ")
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
+	var o options
+	o.playFlags(flag.CommandLine)
+	o.idleFlags(flag.CommandLine)
+	o.clockFlags(flag.CommandLine)
+	o.joinFlags(flag.CommandLine)
+	replayPath := flag.String("replay", "", "replay a game from a PGN file or a file of moves, one per line (e.g. e2e4)")
+	gameLogPath := flag.String("game-log", "", "when serving, append every game's moves and result to this JSON lines file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n       %s host|join|serve|watch|replay|analyze|train|puzzle|resume|bot [flags] [args]\n\nWithout a command, a menu asks whether to host, join or serve.\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	s, err := o.setup()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if *replayPath != "" {
+		s.replay(*replayPath)
+		return
+	}
+
+	fmt.Println(tr(txtBanner))
+	fmt.Print(tr(txtMenu))
 	reader := bufio.NewReader(os.Stdin)
 	choice, _ := reader.ReadString('\n')
 	choice = strings.TrimSpace(choice)
 
 	var conn net.Conn
-	var err error
 	var player string
 
 	if choice == "h" {
-		ip := getLocalIP()
-		if ip == "" {
-			fmt.Println("Could not determine local IP address.")
-			return
-		}
-		ln, err := net.Listen("tcp", ip+":8080")
+		conn, player, err = hostGame()
 		if err != nil {
-			fmt.Printf("Failed to host game: %v\n", err)
+			fmt.Println(tr(txtHostFailed, err))
 			return
 		}
-		defer ln.Close()
-		fmt.Printf("Hosting on %s:8080. Waiting for an opponent...\n", ip)
-		conn, err = ln.Accept()
-		if err != nil {
-			fmt.Println("Failed to accept connection:", err)
-			return
-		}
-		player = "white"
 	} else if choice == "j" {
-		fmt.Print("Enter host IP address: ")
+		fmt.Print(tr(txtHostIP))
 		ip, _ := reader.ReadString('\n')
-		ip = strings.TrimSpace(ip)
-		conn, err = net.Dial("tcp", ip+":8080")
+		conn, player, err = joinGame(strings.TrimSpace(ip), o.joinRetry)
 		if err != nil {
-			fmt.Println("Failed to connect to host:", err)
+			fmt.Println(tr(txtJoinFailed, err))
 			return
 		}
-		player = "black"
+	} else if choice == "s" {
+		serveGames(":8080", *gameLogPath, o.serverOptions())
+		return
 	} else {
-		fmt.Println("Invalid choice.")
+		fmt.Println(tr(txtInvalidChoice))
 		return
 	}
-
-	err = termbox.Init()
-	if err != nil {
-		panic(err)
-	}
-	defer termbox.Close()
-	termbox.SetOutputMode(termbox.Output256)
-	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
-
-	game := NewGame()
-	game.play(conn, player)
+	s.playNetworked(conn, player, choice == "h")
 }
 
 // --- Rule Checking Logic ---
 
+// Move offsets as {dy, dx}. Move generation and isSquareAttacked share these
+// tables so they can never disagree about how a piece moves.
+var (
+	rookDirections   = [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	bishopDirections = [][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+	queenDirections  = append(append([][2]int{}, rookDirections...), bishopDirections...)
+	knightOffsets    = [][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+	kingOffsets      = [][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+
+	// On-board destination squares {y, x} of a knight or king from every
+	// square, precomputed once.
+	knightTargets = stepTargets(knightOffsets)
+	kingTargets   = stepTargets(kingOffsets)
+)
+
+// stepTargets lists, for every square, the on-board squares one step of
+// each offset away.
+func stepTargets(offsets [][2]int) [8][8][][2]int {
+	var targets [8][8][][2]int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			for _, off := range offsets {
+				if ny, nx := y+off[0], x+off[1]; nx >= 0 && nx < 8 && ny >= 0 && ny < 8 {
+					targets[y][x] = append(targets[y][x], [2]int{ny, nx})
+				}
+			}
+		}
+	}
+	return targets
+}
+
 // calculateLegalMoves populates the legalMoves map for a selected piece.
 func (g *Game) calculateLegalMoves(y, x int) {
-	g.legalMoves = make(map[string]bool)
+	g.legalMoves = g.movesFrom(y, x)
+}
+
+// generateMoves works out the legal destinations of the piece at (y, x)
+// for movesFrom, which caches them.
+func (g *Game) generateMoves(y, x int) map[string]moveKind {
+	moves := make(map[string]moveKind)
 	piece := g.board[y][x]
 	if piece == nil {
-		return
+		return moves
+	}
+	if g.freestyle {
+		g.addFreestyleMoves(moves, y, x, piece.color)
+		return moves
 	}
 
 	switch piece.symbol {
 	case pieces["white_pawn"]:
-		g.addPawnMoves(y, x, "white")
+		g.addPawnMoves(moves, y, x, "white")
 	case pieces["black_pawn"]:
-		g.addPawnMoves(y, x, "black")
+		g.addPawnMoves(moves, y, x, "black")
 	case pieces["white_rook"], pieces["black_rook"]:
-		g.addSlidingMoves(y, x, piece.color, []int{-1, 1, 0, 0}, []int{0, 0, -1, 1})
+		g.addSlidingMoves(moves, y, x, piece.color, rookDirections)
 	case pieces["white_bishop"], pieces["black_bishop"]:
-		g.addSlidingMoves(y, x, piece.color, []int{-1, -1, 1, 1}, []int{-1, 1, -1, 1})
+		g.addSlidingMoves(moves, y, x, piece.color, bishopDirections)
 	case pieces["white_queen"], pieces["black_queen"]:
-		g.addSlidingMoves(y, x, piece.color, []int{-1, 1, 0, 0, -1, -1, 1, 1}, []int{0, 0, -1, 1, -1, 1, -1, 1})
+		g.addSlidingMoves(moves, y, x, piece.color, queenDirections)
 	case pieces["white_knight"], pieces["black_knight"]:
-		g.addKnightMoves(y, x, piece.color)
+		g.addKnightMoves(moves, y, x, piece.color)
 	case pieces["white_king"], pieces["black_king"]:
-		g.addKingMoves(y, x, piece.color)
+		g.addKingMoves(moves, y, x, piece.color)
+	}
+	return moves
+}
+
+// addFreestyleMoves adds every square not held by color. Freestyle play
+// knows no piece movement, check or special moves.
+func (g *Game) addFreestyleMoves(moves map[string]moveKind, y, x int, color string) {
+	for ny := 0; ny < 8; ny++ {
+		for nx := 0; nx < 8; nx++ {
+			switch target := g.board[ny][nx]; {
+			case target == nil:
+				moves[squareKey(nx, ny)] = moveQuiet
+			case target.color != color:
+				moves[squareKey(nx, ny)] = moveCapture
+			}
+		}
 	}
 }
 
-func (g *Game) addPawnMoves(y, x int, color string) {
+func (g *Game) addPawnMoves(moves map[string]moveKind, y, x int, color string) {
 	dir := -1
 	startRow := 6
 	if color == "black" {
@@ -468,11 +1104,11 @@ func (g *Game) addPawnMoves(y, x int, color string) {
 
 	// Forward 1
 	if ny := y + dir; ny >= 0 && ny < 8 && g.board[ny][x] == nil {
-		g.addMove(x, ny, color)
+		g.addMove(moves, y, x, ny, x)
 		// Forward 2 from start
 		if y == startRow {
 			if nny := y + 2*dir; nny >= 0 && nny < 8 && g.board[nny][x] == nil {
-				g.addMove(x, nny, color)
+				g.addMove(moves, y, x, nny, x)
 			}
 		}
 	}
@@ -480,62 +1116,375 @@ func (g *Game) addPawnMoves(y, x int, color string) {
 	for _, dx := range []int{-1, 1} {
 		if nx, ny := x+dx, y+dir; nx >= 0 && nx < 8 && ny >= 0 && ny < 8 {
 			if target := g.board[ny][nx]; target != nil && target.color != color {
-				g.addMove(nx, ny, color)
+				g.addMove(moves, y, x, ny, nx)
+			} else if target == nil && squareName(nx, ny) == g.enPassant {
+				g.addMoveKind(moves, y, x, ny, nx, moveEnPassant)
 			}
 		}
 	}
 }
 
-func (g *Game) addSlidingMoves(y, x int, color string, yDirs, xDirs []int) {
-	for i := range yDirs {
+func (g *Game) addSlidingMoves(moves map[string]moveKind, y, x int, color string, dirs [][2]int) {
+	for _, dir := range dirs {
 		for d := 1; d < 8; d++ {
-			ny, nx := y+d*yDirs[i], x+d*xDirs[i]
+			ny, nx := y+d*dir[0], x+d*dir[1]
 			if nx < 0 || nx >= 8 || ny < 0 || ny >= 8 {
 				break // Off board
 			}
 			if target := g.board[ny][nx]; target != nil {
 				if target.color != color {
-					g.addMove(nx, ny, color) // Capture
+					g.addMove(moves, y, x, ny, nx) // Capture
 				}
 				break // Blocked
 			}
-			g.addMove(nx, ny, color) // Empty square
+			g.addMove(moves, y, x, ny, nx) // Empty square
 		}
 	}
 }
 
-func (g *Game) addKnightMoves(y, x int, color string) {
-	yMoves := []int{-2, -2, -1, -1, 1, 1, 2, 2}
-	xMoves := []int{-1, 1, -2, 2, -2, 2, -1, 1}
-	for i := range yMoves {
-		ny, nx := y+yMoves[i], x+xMoves[i]
-		if nx >= 0 && nx < 8 && ny >= 0 && ny < 8 {
-			if target := g.board[ny][nx]; target == nil || target.color != color {
-				g.addMove(nx, ny, color)
+func (g *Game) addKnightMoves(moves map[string]moveKind, y, x int, color string) {
+	for _, sq := range knightTargets[y][x] {
+		if target := g.board[sq[0]][sq[1]]; target == nil || target.color != color {
+			g.addMove(moves, y, x, sq[0], sq[1])
+		}
+	}
+}
+
+func (g *Game) addKingMoves(moves map[string]moveKind, y, x int, color string) {
+	for _, sq := range kingTargets[y][x] {
+		if target := g.board[sq[0]][sq[1]]; target == nil || target.color != color {
+			g.addMove(moves, y, x, sq[0], sq[1])
+		}
+	}
+	g.addCastlingMoves(moves, y, x, color)
+}
+
+// addCastlingMoves adds the castles color still has the right to make. The
+// squares between king and rook must be empty and the king may not castle
+// out of or through check; castling into check is caught by addMoveKind.
+func (g *Game) addCastlingMoves(moves map[string]moveKind, y, x int, color string) {
+	homeRow, kingSide, queenSide := 7, 'K', 'Q'
+	if color == "black" {
+		homeRow, kingSide, queenSide = 0, 'k', 'q'
+	}
+	if y != homeRow || x != 4 || inCheck(&g.board, color) {
+		return
+	}
+	enemy := opponent(color)
+	rook := pieces[color+"_rook"]
+	hasRook := func(rx int) bool {
+		return g.board[y][rx] != nil && g.board[y][rx].symbol == rook
+	}
+
+	if strings.ContainsRune(g.castling, kingSide) && hasRook(7) &&
+		g.board[y][5] == nil && g.board[y][6] == nil && !isSquareAttacked(&g.board, y, 5, enemy) {
+		g.addMoveKind(moves, y, x, y, 6, moveCastle)
+	}
+	if strings.ContainsRune(g.castling, queenSide) && hasRook(0) &&
+		g.board[y][1] == nil && g.board[y][2] == nil && g.board[y][3] == nil && !isSquareAttacked(&g.board, y, 3, enemy) {
+		g.addMoveKind(moves, y, x, y, 2, moveCastle)
+	}
+}
+
+// Castling rights lost when a piece moves from or to each square: moving
+// the king or a rook gives up its castles, as does losing the rook.
+var castlingSquares = map[string]string{
+	"e1": "KQ", "h1": "K", "a1": "Q",
+	"e8": "kq", "h8": "k", "a8": "q",
+}
+
+// updateCastlingRights removes the rights a move gives up.
+func (g *Game) updateCastlingRights(fromY, fromX, toY, toX int) {
+	for _, sq := range []string{squareName(fromX, fromY), squareName(toX, toY)} {
+		for _, right := range castlingSquares[sq] {
+			g.castling = strings.ReplaceAll(g.castling, string(right), "")
+		}
+	}
+}
+
+// addMove adds an ordinary move or capture to moves, subject to the same
+// check test as addMoveKind.
+func (g *Game) addMove(moves map[string]moveKind, y, x, ny, nx int) {
+	kind := moveQuiet
+	if g.board[ny][nx] != nil {
+		kind = moveCapture
+	}
+	g.addMoveKind(moves, y, x, ny, nx, kind)
+}
+
+// addMoveKind adds a destination to moves unless playing it would leave the
+// mover's own king attacked. The move is tried on a copy of the board, so
+// every way of answering a check (including double check, where only a king
+// move can help) falls out of the same test. A moving king has already left
+// its square when the test runs, so it cannot shield the square behind it:
+// stepping back along a checking rook's file stays illegal.
+func (g *Game) addMoveKind(moves map[string]moveKind, y, x, ny, nx int, kind moveKind) {
+	board := g.board
+	piece := board[y][x]
+	board[ny][nx] = piece
+	board[y][x] = nil
+	if kind == moveEnPassant {
+		// Both pawns leave the rank at once, which can open a rank to our
+		// king, so take the captured pawn off its real square.
+		board[y][nx] = nil
+	}
+	if inCheck(&board, piece.color) {
+		return
+	}
+	moves[squareKey(nx, ny)] = kind
+}
+
+// mobility describes how many legal moves the piece at (y, x) has, e.g.
+// "Knight: 6 moves. Click a destination square.", noting when a pin or check
+// is what leaves it without any.
+func (g *Game) mobility(y, x int) string {
+	name := pieceName(pieceKind(g.board[y][x]))
+	switch n := len(g.movesFrom(y, x)); {
+	case n == 0 && g.isPinned(y, x):
+		return tr(txtMobilityPinned, name)
+	case n == 0:
+		return tr(txtMobilityNone, name)
+	case n == 1:
+		return tr(txtMobilityOne, name)
+	default:
+		return tr(txtMobility, name, n)
+	}
+}
+
+// isPinned reports whether the piece at (y, x) could move if its own king's
+// safety were ignored, i.e. only a pin or a check keeps it from moving. The
+// moves are generated on a copy of the board without that king, which turns
+// off the check filter in addMove. A king is never pinned.
+func (g *Game) isPinned(y, x int) bool {
+	probe := &Game{board: g.board}
+	king := pieces[g.board[y][x].color+"_king"]
+	for ky := 0; ky < 8; ky++ {
+		for kx := 0; kx < 8; kx++ {
+			if piece := probe.board[ky][kx]; piece != nil && piece.symbol == king {
+				probe.board[ky][kx] = nil
 			}
 		}
 	}
+	return len(probe.movesFrom(y, x)) > 0
 }
 
-func (g *Game) addKingMoves(y, x int, color string) {
-	for dy := -1; dy <= 1; dy++ {
-		for dx := -1; dx <= 1; dx++ {
-			if dy == 0 && dx == 0 {
+// pieceKind returns the kind of piece, e.g. "knight".
+func pieceKind(piece *Piece) string {
+	for name, symbol := range pieces {
+		if symbol == piece.symbol {
+			return strings.TrimPrefix(name, piece.color+"_")
+		}
+	}
+	return ""
+}
+
+// hasLegalMoves reports whether color has at least one legal move.
+func (g *Game) hasLegalMoves(color string) bool {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && piece.color == color && len(g.movesFrom(y, x)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SideToMove returns the color whose turn it is.
+func (g *Game) SideToMove() string {
+	return g.currentPlayer
+}
+
+// InCheck reports whether color's king is currently attacked.
+func (g *Game) InCheck(color string) bool {
+	return inCheck(&g.board, color)
+}
+
+// IsCheckmate reports whether color is in check with no legal move.
+func (g *Game) IsCheckmate(color string) bool {
+	return g.InCheck(color) && !g.hasLegalMoves(color)
+}
+
+// IsStalemate reports whether the side to move has no legal move but is not
+// in check.
+func (g *Game) IsStalemate() bool {
+	return !g.InCheck(g.currentPlayer) && !g.hasLegalMoves(g.currentPlayer)
+}
+
+// Result reports whether the game is over and its PGN result token ("1-0",
+// "0-1", "1/2-1/2", or "*" while in progress or after an abort).
+func (g *Game) Result() (bool, string) {
+	return g.gameOver, g.result
+}
+
+// opponent returns the other player's color.
+func opponent(color string) string {
+	if color == "white" {
+		return "black"
+	}
+	return "white"
+}
+
+// inCheck reports whether color's king is attacked on the given board.
+func inCheck(board *[8][8]*Piece, color string) bool {
+	king := pieces[color+"_king"]
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := board[y][x]; piece != nil && piece.symbol == king {
+				return isSquareAttacked(board, y, x, opponent(color))
+			}
+		}
+	}
+	return false
+}
+
+// controlledSquares returns every square the piece on (y, x) attacks or
+// defends: each square it could capture on if an enemy stood there, whether
+// it is empty or holds a piece of either color. Unlike move generation it
+// ignores friendly pieces in the way and whether its own king is exposed.
+func controlledSquares(board *[8][8]*Piece, y, x int) [8][8]bool {
+	var control [8][8]bool
+	piece := board[y][x]
+	var dirs [][2]int
+	switch pieceKind(piece) {
+	case "pawn":
+		dir := -1
+		if piece.color == "black" {
+			dir = 1
+		}
+		for _, dx := range []int{-1, 1} {
+			if ny, nx := y+dir, x+dx; nx >= 0 && nx < 8 && ny >= 0 && ny < 8 {
+				control[ny][nx] = true
+			}
+		}
+	case "knight":
+		for _, sq := range knightTargets[y][x] {
+			control[sq[0]][sq[1]] = true
+		}
+	case "king":
+		for _, sq := range kingTargets[y][x] {
+			control[sq[0]][sq[1]] = true
+		}
+	case "rook":
+		dirs = rookDirections
+	case "bishop":
+		dirs = bishopDirections
+	case "queen":
+		dirs = queenDirections
+	}
+	for _, dir := range dirs {
+		for d := 1; d < 8; d++ {
+			ny, nx := y+d*dir[0], x+d*dir[1]
+			if nx < 0 || nx >= 8 || ny < 0 || ny >= 8 {
+				break
+			}
+			control[ny][nx] = true
+			if board[ny][nx] != nil {
+				break
+			}
+		}
+	}
+	return control
+}
+
+// hangingPieces marks color's pieces, other than the king, that the
+// opponent attacks and no piece of their own defends.
+func hangingPieces(board *[8][8]*Piece, color string) [8][8]bool {
+	var hanging [8][8]bool
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := board[y][x]
+			if piece == nil || piece.color != color || pieceKind(piece) == "king" {
 				continue
 			}
-			ny, nx := y+dy, x+dx
-			if nx >= 0 && nx < 8 && ny >= 0 && ny < 8 {
-				if target := g.board[ny][nx]; target == nil || target.color != color {
-					g.addMove(nx, ny, color)
+			hanging[y][x] = isSquareAttacked(board, y, x, opponent(color)) && !isSquareAttacked(board, y, x, color)
+		}
+	}
+	return hanging
+}
+
+// netControl counts, for every square, the white pieces that attack or
+// defend it less the black ones: positive where white controls it,
+// negative where black does.
+func netControl(board *[8][8]*Piece) [8][8]int {
+	var net [8][8]int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := board[y][x]
+			if piece == nil {
+				continue
+			}
+			sign := 1
+			if piece.color == "black" {
+				sign = -1
+			}
+			control := controlledSquares(board, y, x)
+			for cy := 0; cy < 8; cy++ {
+				for cx := 0; cx < 8; cx++ {
+					if control[cy][cx] {
+						net[cy][cx] += sign
+					}
 				}
 			}
 		}
 	}
+	return net
 }
 
-// addMove adds a square to the legal moves map.
-func (g *Game) addMove(x, y int, color string) {
-	// A full implementation would check if the move puts the king in check.
-	// This is a simplified version for playability.
-	g.legalMoves[fmt.Sprintf("%d,%d", x, y)] = true
+// isSquareAttacked reports whether any piece of color by attacks (y, x).
+func isSquareAttacked(board *[8][8]*Piece, y, x int, by string) bool {
+	is := func(ny, nx int, kinds ...string) bool {
+		if nx < 0 || nx >= 8 || ny < 0 || ny >= 8 || board[ny][nx] == nil {
+			return false
+		}
+		for _, kind := range kinds {
+			if board[ny][nx].symbol == pieces[by+"_"+kind] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Pawns attack diagonally forward, so look one row back from their side.
+	pawnRow := y + 1
+	if by == "black" {
+		pawnRow = y - 1
+	}
+	if is(pawnRow, x-1, "pawn") || is(pawnRow, x+1, "pawn") {
+		return true
+	}
+
+	// Knight and king moves are symmetric, so the squares a knight or king
+	// could reach from here are exactly those it could attack us from.
+	for _, sq := range knightTargets[y][x] {
+		if is(sq[0], sq[1], "knight") {
+			return true
+		}
+	}
+	for _, sq := range kingTargets[y][x] {
+		if is(sq[0], sq[1], "king") {
+			return true
+		}
+	}
+
+	// Walk each line outwards until the first piece blocks it.
+	for i, dir := range queenDirections {
+		slider := "rook"
+		if i >= len(rookDirections) {
+			slider = "bishop"
+		}
+		for d := 1; d < 8; d++ {
+			ny, nx := y+d*dir[0], x+d*dir[1]
+			if nx < 0 || nx >= 8 || ny < 0 || ny >= 8 {
+				break
+			}
+			if board[ny][nx] != nil {
+				if is(ny, nx, slider, "queen") {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
 }
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestGame returns a headless game set up from fen, or from the
+// standard starting position when fen is empty.
+func newTestGame(t testing.TB, fen string) *Game {
+	t.Helper()
+	g := NewGame()
+	g.headless = true
+	if fen != "" {
+		if err := g.loadFEN(fen); err != nil {
+			t.Fatalf("loadFEN(%q): %v", fen, err)
+		}
+	}
+	return g
+}
+
+// playMoves plays moves, in wire format, for whichever side is to move,
+// failing the test at the first one refused.
+func playMoves(t testing.TB, g *Game, moves ...string) {
+	t.Helper()
+	for _, move := range moves {
+		if err := g.ApplyAlgebraic(move, g.currentPlayer); err != nil {
+			t.Fatalf("playing %s: %v", move, err)
+		}
+	}
+}
+
+// legalMoves is every legal move of the side to move.
+func legalMoves(g *Game) []Move {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.position().Legal
+}
+
+// perft counts the move sequences depth plies long from g's position, the
+// standard check of a move generator against published totals.
+func perft(g *Game, depth int) int {
+	moves := legalMoves(g)
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, move := range moves {
+		nodes += perft(g.after(move), depth-1)
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		nodes int
+	}{
+		{"start", "", 1, 20},
+		{"start", "", 2, 400},
+		{"start", "", 3, 8902},
+		// Castling both ways, en passant and pins, from the Chess
+		// Programming Wiki. Underpromotions first appear deeper.
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 1, 48},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 2, 2039},
+		{"endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 3, 2812},
+	}
+	for _, tt := range tests {
+		if got := perft(newTestGame(t, tt.fen), tt.depth); got != tt.nodes {
+			t.Errorf("perft(%s, %d) = %d, want %d", tt.name, tt.depth, got, tt.nodes)
+		}
+	}
+}
+
+func TestIllegalMoves(t *testing.T) {
+	tests := []struct {
+		move  string
+		color string
+		want  error
+	}{
+		{"e2e5", "white", ErrIllegalMove},
+		{"e2", "white", ErrMalformedMove},
+		{"i2i4", "white", ErrMalformedMove},
+		{"e7e5", "black", ErrNotYourTurn},
+		{"e7e5", "white", ErrWrongColor},
+		{"e4e5", "white", ErrNoPieceThere},
+		{"b1d2", "white", ErrIllegalMove},
+		{"f1c4", "white", ErrPathBlocked},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, "")
+		err := g.ApplyAlgebraic(tt.move, tt.color)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s for %s: got %v, want %v", tt.move, tt.color, err, tt.want)
+		}
+		if len(g.moveHistory) != 0 || g.currentPlayer != "white" {
+			t.Errorf("%s for %s changed the game", tt.move, tt.color)
+		}
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	tests := []struct {
+		move                           string
+		fromRow, fromCol, toRow, toCol int
+		promotion                      string
+		ok                             bool
+	}{
+		{"e2e4", 6, 4, 4, 4, noPromotion, true},
+		{"a1h8", 7, 0, 0, 7, noPromotion, true},
+		{"e7e8q", 1, 4, 0, 4, "queen", true},
+		{"a2a1r", 6, 0, 7, 0, "rook", true},
+		{"g7g8b", 1, 6, 0, 6, "bishop", true},
+		{"b2b1n", 6, 1, 7, 1, "knight", true},
+		{"e7e8k", 0, 0, 0, 0, noPromotion, false},
+		{"e7e8p", 0, 0, 0, 0, noPromotion, false},
+		{"e7e8Q", 0, 0, 0, 0, noPromotion, false},
+		{"e7e8", 1, 4, 0, 4, noPromotion, true},
+		{"", 0, 0, 0, 0, noPromotion, false},
+		{"e2e", 0, 0, 0, 0, noPromotion, false},
+		{"e7e8qq", 0, 0, 0, 0, noPromotion, false},
+		{"e2e9", 0, 0, 0, 0, noPromotion, false},
+		{"i7i8q", 0, 0, 0, 0, noPromotion, false},
+	}
+	for _, tt := range tests {
+		fromRow, fromCol, toRow, toCol, promotion, ok := parseMove(tt.move)
+		if fromRow != tt.fromRow || fromCol != tt.fromCol || toRow != tt.toRow || toCol != tt.toCol || promotion != tt.promotion || ok != tt.ok {
+			t.Errorf("parseMove(%q) = %d %d %d %d %q %v, want %d %d %d %d %q %v", tt.move,
+				fromRow, fromCol, toRow, toCol, promotion, ok,
+				tt.fromRow, tt.fromCol, tt.toRow, tt.toCol, tt.promotion, tt.ok)
+		}
+		if ok {
+			if got := formatMove(fromRow, fromCol, toRow, toCol) + promotionLetter(promotion); got != tt.move && got+"q" != tt.move {
+				t.Errorf("parseMove(%q) formats back as %q", tt.move, got)
+			}
+		}
+	}
+}
+
+// netGame is one end of a networked test game.
+type netGame struct {
+	*Game
+	conn net.Conn
+}
+
+// move plays move for this end's player and sends it, as a click does.
+func (n netGame) move(t testing.TB, move string) {
+	t.Helper()
+	if err := n.ApplyAlgebraic(move, n.playerColor); err != nil {
+		t.Fatalf("%s playing %s: %v", n.playerColor, move, err)
+	}
+	n.sendMove(n.conn, move)
+}
+
+// connectedGames returns a host playing white and a joiner playing black,
+// both set up from fen as newTestGame does, talking over an in-memory pipe
+// with no terminal at either end. Both ends stop listening before the test
+// ends, so none of their work spills into the next test.
+func connectedGames(t testing.TB, fen string) (white, black netGame) {
+	t.Helper()
+	whiteConn, blackConn := net.Pipe()
+	var listening sync.WaitGroup
+	t.Cleanup(func() {
+		whiteConn.Close()
+		blackConn.Close()
+		listening.Wait()
+	})
+	white = netGame{newTestGame(t, fen), whiteConn}
+	white.playerColor, white.hosting = "white", true
+	black = netGame{newTestGame(t, fen), blackConn}
+	black.playerColor = "black"
+	listening.Add(2)
+	go func() {
+		defer listening.Done()
+		white.receiveMessages(whiteConn)
+	}()
+	go func() {
+		defer listening.Done()
+		black.receiveMessages(blackConn)
+	}()
+	return white, black
+}
+
+// waitFor fails the test unless cond comes true within a second.
+func waitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// moveCount is how many moves g has played, read under its lock.
+func moveCount(g *Game) int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.moveHistory)
+}
+
+// fakeTime is a timeSource that stands still until the test advances it,
+// firing any timer that comes due on the way.
+type fakeTime struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (ft *fakeTime) Now() time.Time {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	return ft.now
+}
+
+func (ft *fakeTime) AfterFunc(d time.Duration, f func()) timer {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	t := &fakeTimer{at: ft.now.Add(d), f: f}
+	ft.timers = append(ft.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	stopped := t.stopped
+	t.stopped = true
+	return !stopped
+}
+
+// advance moves the time on by d and runs the timers due by then, outside
+// ft's lock so they may read the time again or set new timers.
+func (ft *fakeTime) advance(d time.Duration) {
+	ft.mu.Lock()
+	ft.now = ft.now.Add(d)
+	var due []*fakeTimer
+	pending := ft.timers[:0]
+	for _, t := range ft.timers {
+		switch {
+		case t.stopped:
+		case !t.at.After(ft.now):
+			t.stopped = true
+			due = append(due, t)
+		default:
+			pending = append(pending, t)
+		}
+	}
+	ft.timers = pending
+	ft.mu.Unlock()
+	for _, t := range due {
+		t.f()
+	}
+}
+
+func TestNetworkedGameStaysInSync(t *testing.T) {
+	white, black := connectedGames(t, "")
+	moves := []string{"f2f3", "e7e5", "g2g4", "d8h4"}
+	for i, move := range moves {
+		mover, other := white, black
+		if i%2 == 1 {
+			mover, other = black, white
+		}
+		mover.move(t, move)
+		waitFor(t, move+" to arrive", func() bool { return moveCount(other.Game) == i+1 })
+	}
+	for _, g := range []*Game{white.Game, black.Game} {
+		g.lock.Lock()
+		if !g.gameOver || g.result != resultBlackWins || g.termination != "checkmate" {
+			t.Errorf("%s: gameOver %v, result %q, termination %q, want black mates", g.playerColor, g.gameOver, g.result, g.termination)
+		}
+		g.lock.Unlock()
+	}
+	if white.FEN() != black.FEN() {
+		t.Errorf("positions differ: %s vs %s", white.FEN(), black.FEN())
+	}
+}
+
+func TestNetworkedIllegalMoveIgnored(t *testing.T) {
+	white, black := connectedGames(t, "")
+	// A peer that skips validation sends a move its rules would refuse.
+	sendMessage(white.conn, message{kind: msgMove, arg: "e2e5"})
+	sendMessage(white.conn, message{kind: msgMove, arg: "e2e4"})
+	waitFor(t, "the legal move", func() bool { return moveCount(black.Game) == 1 })
+	if got := black.lastMove(); got != "e2e4" {
+		t.Errorf("black played %q, want e2e4", got)
+	}
+}
+
+func TestNetworkedOversizedMessageIgnored(t *testing.T) {
+	white, black := connectedGames(t, "")
+	fmt.Fprintln(white.conn, strings.Repeat("x", 2*maxLineLength))
+	waitFor(t, "the oversized message to be reported", func() bool {
+		black.lock.Lock()
+		defer black.lock.Unlock()
+		return black.message == tr(txtIgnoredOversized)
+	})
+	white.move(t, "e2e4")
+	waitFor(t, "the move after it", func() bool { return moveCount(black.Game) == 1 })
+}
+
+func TestNetworkedPromotionMate(t *testing.T) {
+	white, black := connectedGames(t, "k7/4P3/1K6/8/8/8/8/8 w - - 0 1")
+	white.move(t, "e7e8")
+	waitFor(t, "the promotion to arrive", func() bool { return moveCount(black.Game) == 1 })
+	for _, g := range []*Game{white.Game, black.Game} {
+		g.lock.Lock()
+		if got := g.sanHistory[0]; got != "e8=Q#" {
+			t.Errorf("%s recorded %q, want e8=Q#", g.playerColor, got)
+		}
+		if !g.gameOver || g.result != resultWhiteWins || g.termination != "checkmate" {
+			t.Errorf("%s: gameOver %v, result %q, termination %q, want white mates", g.playerColor, g.gameOver, g.result, g.termination)
+		}
+		if piece := g.board[0][4]; piece == nil || piece.symbol != pieces["white_queen"] {
+			t.Errorf("%s has %v on e8, want a white queen", g.playerColor, piece)
+		}
+		g.lock.Unlock()
+	}
+	if white.FEN() != black.FEN() {
+		t.Errorf("positions differ: %s vs %s", white.FEN(), black.FEN())
+	}
+}
+
+func TestDoubleCheckAllowsOnlyKingMoves(t *testing.T) {
+	// Nf6 checks and uncovers the rook's check down the e-file. Capturing
+	// the knight (h6f6) or blocking the rook (d3e4, d3e2) answers only one
+	// of them.
+	g := newTestGame(t, "4k3/8/7r/8/4N3/3b4/8/4R1K1 w - - 0 1")
+	playMoves(t, g, "e4f6")
+	for _, move := range []string{"h6f6", "d3e4", "d3e2"} {
+		if err := g.ApplyAlgebraic(move, "black"); !errors.Is(err, ErrLeavesKingInCheck) {
+			t.Errorf("%s: got %v, want %v", move, err, ErrLeavesKingInCheck)
+		}
+	}
+	var got []string
+	for _, move := range legalMoves(g) {
+		got = append(got, string(move))
+	}
+	slices.Sort(got)
+	if want := []string{"e8d8", "e8f7", "e8f8"}; !slices.Equal(got, want) {
+		t.Errorf("legal moves %v, want %v", got, want)
+	}
+}
+
+func TestKingCannotRetreatAlongCheckLine(t *testing.T) {
+	// Each slider checks the king on e4. Stepping away along the line of
+	// the check is refused: the king only shields the square behind it
+	// while it stands in the way.
+	tests := []struct {
+		name, fen string
+		refused   []string
+		allowed   []string
+	}{
+		{"file", "4r2k/8/8/8/4K3/8/8/8 w - - 0 1", []string{"e4e3", "e4e5"}, []string{"e4d3", "e4f4"}},
+		{"rank", "7k/8/8/8/r3K3/8/8/8 w - - 0 1", []string{"e4f4", "e4d4"}, []string{"e4e3", "e4f5"}},
+		{"diagonal", "b6k/8/8/8/4K3/8/8/8 w - - 0 1", []string{"e4f3", "e4d5"}, []string{"e4e3", "e4d4"}},
+	}
+	for _, tt := range tests {
+		for _, move := range tt.refused {
+			g := newTestGame(t, tt.fen)
+			if err := g.ApplyAlgebraic(move, "white"); !errors.Is(err, ErrLeavesKingInCheck) {
+				t.Errorf("%s: %s got %v, want %v", tt.name, move, err, ErrLeavesKingInCheck)
+			}
+		}
+		for _, move := range tt.allowed {
+			g := newTestGame(t, tt.fen)
+			if err := g.ApplyAlgebraic(move, "white"); err != nil {
+				t.Errorf("%s: %s refused: %v", tt.name, move, err)
+			}
+		}
+	}
+}
+
+func TestStatusQueries(t *testing.T) {
+	tests := []struct {
+		name                    string
+		fen                     string
+		moves                   []string
+		side                    string
+		whiteInCheck, blackMate bool
+		stalemate, done         bool
+		result                  string
+	}{
+		{name: "start", side: "white", result: resultOngoing},
+		{name: "check", fen: "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", moves: []string{"a1a8"}, side: "black", result: resultOngoing},
+		{name: "fool's mate", moves: []string{"f2f3", "e7e5", "g2g4", "d8h4"}, side: "white", whiteInCheck: true, done: true, result: resultBlackWins},
+		{name: "back-rank mate", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", moves: []string{"a1a8"}, side: "black", blackMate: true, done: true, result: resultWhiteWins},
+		{name: "stalemate", fen: "7k/8/6QK/8/8/8/8/8 w - - 0 1", moves: []string{"g6f7"}, side: "black", stalemate: true, done: true, result: resultDraw},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, tt.fen)
+		playMoves(t, g, tt.moves...)
+		if got := g.SideToMove(); got != tt.side {
+			t.Errorf("%s: SideToMove() = %s, want %s", tt.name, got, tt.side)
+		}
+		if got := g.InCheck("white"); got != tt.whiteInCheck {
+			t.Errorf("%s: InCheck(white) = %v, want %v", tt.name, got, tt.whiteInCheck)
+		}
+		if got := g.IsCheckmate("black"); got != tt.blackMate {
+			t.Errorf("%s: IsCheckmate(black) = %v, want %v", tt.name, got, tt.blackMate)
+		}
+		if got := g.IsStalemate(); got != tt.stalemate {
+			t.Errorf("%s: IsStalemate() = %v, want %v", tt.name, got, tt.stalemate)
+		}
+		if done, result := g.Result(); done != tt.done || result != tt.result {
+			t.Errorf("%s: Result() = %v, %q, want %v, %q", tt.name, done, result, tt.done, tt.result)
+		}
+	}
+}
+
+// BenchmarkLegalMoves generates every legal move of the side to move in a
+// busy middlegame position, bypassing the move cache.
+func BenchmarkLegalMoves(b *testing.B) {
+	g := newTestGame(b, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	b.ReportAllocs()
+	for b.Loop() {
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				if piece := g.board[y][x]; piece != nil && piece.color == g.currentPlayer {
+					g.generateMoves(y, x)
+				}
+			}
+		}
+	}
+}
+
+func TestEnPassantHorizontalPin(t *testing.T) {
+	// After c5, bxc6 e.p. would take both pawns off the fifth rank and
+	// open it from the rook on h5 to the king on a5.
+	g := newTestGame(t, "8/2p5/8/KP5r/8/8/8/7k b - - 0 1")
+	playMoves(t, g, "c7c5")
+	if err := g.ApplyAlgebraic("b5c6", "white"); !errors.Is(err, ErrLeavesKingInCheck) {
+		t.Errorf("pinned en passant: got %v, want %v", err, ErrLeavesKingInCheck)
+	}
+
+	// With the rook off the rank the same capture is fine, and removes
+	// the pawn from c5, not c6.
+	g = newTestGame(t, "8/2p5/8/KP6/7r/8/8/7k b - - 0 1")
+	playMoves(t, g, "c7c5", "b5c6")
+	if g.board[3][2] != nil {
+		t.Error("en passant left the captured pawn on c5")
+	}
+}
+
+func TestSquareLayout(t *testing.T) {
+	for w := 1; w <= 12; w++ {
+		for h := 1; h <= 7; h++ {
+			g := newTestGame(t, "")
+			g.squareWidth, g.squareHeight = w, h
+			l := g.squareLayout()
+			// The piece sits in the middle, a cell left or up of it when a
+			// side is even.
+			if left, right := l.pieceX, w-1-l.pieceX; left < 0 || right < left || right-left > 1 {
+				t.Errorf("%dx%d: piece column %d is off center", w, h, l.pieceX)
+			}
+			if top, bottom := l.pieceY, h-1-l.pieceY; top < 0 || bottom < top || bottom-top > 1 {
+				t.Errorf("%dx%d: piece row %d is off center", w, h, l.pieceY)
+			}
+			// The cursor's '>' and '<' go in columns 0 and w-1 of the
+			// piece's row, and must leave the piece showing.
+			if l.cursorMarkers != (w >= 3) {
+				t.Errorf("%dx%d: cursorMarkers = %v", w, h, l.cursorMarkers)
+			}
+			if l.cursorMarkers && (l.pieceX == 0 || l.pieceX == w-1) {
+				t.Errorf("%dx%d: cursor markers cover the piece", w, h)
+			}
+			if !l.moveMarker {
+				continue
+			}
+			if l.moveX < 0 || l.moveX >= w || l.moveY < 0 || l.moveY >= h {
+				t.Errorf("%dx%d: move marker (%d, %d) is outside the square", w, h, l.moveX, l.moveY)
+			}
+			if l.moveX == l.pieceX && l.moveY == l.pieceY {
+				t.Errorf("%dx%d: move marker covers the piece", w, h)
+			}
+			if l.cursorMarkers && l.moveY == l.pieceY && (l.moveX == 0 || l.moveX == w-1) {
+				t.Errorf("%dx%d: move marker covers a cursor marker", w, h)
+			}
+		}
+	}
+}
+
+func TestSquareLayoutSizes(t *testing.T) {
+	tests := []struct {
+		w, h int
+		want squareLayout
+	}{
+		{1, 1, squareLayout{pieceX: 0, pieceY: 0, moveX: 0, moveY: 0}},
+		{2, 1, squareLayout{pieceX: 0, pieceY: 0, moveX: 1, moveY: 0, moveMarker: true}},
+		{3, 1, squareLayout{pieceX: 1, pieceY: 0, cursorMarkers: true, moveX: 1, moveY: 0}},
+		{5, 3, squareLayout{pieceX: 2, pieceY: 1, cursorMarkers: true, moveX: 1, moveY: 2, moveMarker: true}},
+		{6, 4, squareLayout{pieceX: 2, pieceY: 1, cursorMarkers: true, moveX: 1, moveY: 3, moveMarker: true}},
+		{7, 4, squareLayout{pieceX: 3, pieceY: 1, cursorMarkers: true, moveX: 1, moveY: 3, moveMarker: true}},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, "")
+		g.squareWidth, g.squareHeight = tt.w, tt.h
+		if got := g.squareLayout(); got != tt.want {
+			t.Errorf("%dx%d: got %+v, want %+v", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestMoveCounters(t *testing.T) {
+	type step struct {
+		move               string
+		halfmove, fullmove int
+	}
+	tests := []struct {
+		name  string
+		fen   string
+		steps []step
+	}{
+		{"quiet moves, pawn moves and captures", "", []step{
+			{"g1f3", 1, 1}, {"g8f6", 2, 2}, {"f3g1", 3, 2}, {"f6g8", 4, 3},
+			{"e2e4", 0, 3}, {"d7d5", 0, 4}, {"e4d5", 0, 4}, {"d8d5", 0, 5}, {"b1c3", 1, 5},
+		}},
+		{"en passant", "4k3/8/8/8/1p6/8/P7/4K3 w - - 5 40", []step{
+			{"a2a4", 0, 40}, {"b4a3", 0, 41}, {"e1d1", 1, 41},
+		}},
+		{"promotion", "4k3/P7/8/8/8/8/7P/4K3 w - - 7 60", []step{
+			{"a7a8n", 0, 60}, {"e8d7", 1, 61},
+		}},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, tt.fen)
+		for _, s := range tt.steps {
+			playMoves(t, g, s.move)
+			if g.halfmoveClock != s.halfmove || g.fullmoveNumber != s.fullmove {
+				t.Errorf("%s: after %s the counters are %d %d, want %d %d", tt.name, s.move, g.halfmoveClock, g.fullmoveNumber, s.halfmove, s.fullmove)
+			}
+			if fields := strings.Fields(g.FEN()); fields[4] != strconv.Itoa(s.halfmove) || fields[5] != strconv.Itoa(s.fullmove) {
+				t.Errorf("%s: after %s the FEN ends %s %s", tt.name, s.move, fields[4], fields[5])
+			}
+		}
+	}
+}
+
+// click clicks square, e.g. "e2", for color and returns the move it plays,
+// if any.
+func click(g *Game, color, square string) string {
+	g.cursorX, g.cursorY, _ = parseSquare(square)
+	return g.handleMouseClick(color)
+}
+
+// selected is the name of the selected square, or "" with none selected.
+func selected(g *Game) string {
+	if g.selectedX == -1 {
+		return ""
+	}
+	return squareName(g.selectedX, g.selectedY)
+}
+
+func TestClickReselectsOwnPiece(t *testing.T) {
+	g := newTestGame(t, "")
+	click(g, "white", "e2")
+	if moveStr := click(g, "white", "g1"); moveStr != "" || selected(g) != "g1" {
+		t.Fatalf("clicking g1 with e2 selected played %q and selected %q, want g1 selected", moveStr, selected(g))
+	}
+	if g.legalMoves[squareKey(5, 5)] == moveNone || g.legalMoves[squareKey(4, 4)] != moveNone {
+		t.Errorf("legal moves %v are not the knight's", g.legalMoves)
+	}
+	if g.message != g.mobility(7, 6) {
+		t.Errorf("message %q, want the knight's mobility", g.message)
+	}
+
+	// Clicking the selected piece again still puts it back.
+	click(g, "white", "g1")
+	if selected(g) != "" || g.message != tr(txtMoveCancelled) {
+		t.Errorf("clicking g1 twice left %q selected, message %q", selected(g), g.message)
+	}
+
+	// So does an empty square the piece cannot reach.
+	click(g, "white", "g1")
+	click(g, "white", "g4")
+	if selected(g) != "" || len(g.legalMoves) != 0 {
+		t.Errorf("clicking g4 left %q selected", selected(g))
+	}
+
+	// And a move still plays after switching.
+	click(g, "white", "e2")
+	click(g, "white", "d2")
+	if moveStr := click(g, "white", "d4"); moveStr != "d2d4" {
+		t.Errorf("d2 then d4 played %q, want d2d4", moveStr)
+	}
+}
+
+func TestTouchMoveKeepsSelection(t *testing.T) {
+	g := newTestGame(t, "")
+	g.touchMove = true
+	click(g, "white", "e2")
+	click(g, "white", "g1")
+	if selected(g) != "e2" || g.message != tr(txtTouchMove, "e2") {
+		t.Errorf("clicking g1 with e2 touched selected %q, message %q", selected(g), g.message)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PieceValues are the piece values, in centipawns, used to count material
+// and to evaluate positions. The king's value only has to outweigh
+// everything else. Players can override any of them with "piece_values" in
+// the preferences file, e.g. for handicap games.
+var PieceValues = map[string]int{
+	"pawn":   100,
+	"knight": 300,
+	"bishop": 300,
+	"rook":   500,
+	"queen":  900,
+	"king":   100000,
+}
+
+// setPieceValues overrides entries of PieceValues, rejecting names that are
+// not pieces.
+func setPieceValues(values map[string]int) error {
+	for kind := range values {
+		if _, ok := PieceValues[kind]; !ok {
+			return fmt.Errorf("unknown piece %q in piece_values", kind)
+		}
+	}
+	for kind, value := range values {
+		PieceValues[kind] = value
+	}
+	return nil
+}
+
+// material totals the value of color's pieces on the board, leaving out the
+// king, which both sides always have.
+func (g *Game) material(color string) int {
+	total := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && piece.color == color {
+				if kind := pieceKind(piece); kind != "king" {
+					total += PieceValues[kind]
+				}
+			}
+		}
+	}
+	return total
+}
+
+// Evaluate scores the position in centipawns from white's side: positive
+// when white is ahead. For now it is the material balance.
+func (g *Game) Evaluate() int {
+	return g.material("white") - g.material("black")
+}
+
+// pawns formats a centipawn count in pawns, e.g. 3900 as "39" and 350 as
+// "3.5".
+func pawns(centipawns int) string {
+	return strconv.FormatFloat(float64(centipawns)/100, 'f', -1, 64)
+}
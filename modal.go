@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nsf/termbox-go"
+)
+
+// handleGameOverKey answers the game-over panel. While it is open no other
+// key reaches the game; Esc closes it and leaves the final position on screen.
+func (g *Game) handleGameOverKey(ev termbox.Event) {
+	switch {
+	case ev.Key == termbox.KeyEsc:
+		g.inputMode = modeNormal
+	case ev.Ch == 's' || ev.Ch == 'S':
+		g.saveGame()
+	case ev.Ch == 'm' || ev.Ch == 'M':
+		g.saveGameSummary()
+	case ev.Ch == 'a' || ev.Ch == 'A':
+		g.analyze()
+	case ev.Ch == 'q' || ev.Ch == 'Q':
+		g.quit = true
+	}
+}
+
+// outcome describes how the game ended, e.g. "White wins — Checkmate".
+func (g *Game) outcome() string {
+	var outcome string
+	switch g.result {
+	case resultWhiteWins:
+		outcome = tr(txtWhiteWins)
+	case resultBlackWins:
+		outcome = tr(txtBlackWins)
+	case resultDraw:
+		outcome = tr(txtDrawn)
+	default:
+		outcome = tr(txtNoResult)
+	}
+	if key, ok := terminationTexts[g.termination]; ok {
+		outcome += " — " + tr(key)
+	} else if g.termination != "" {
+		outcome += " — " + strings.ToUpper(g.termination[:1]) + g.termination[1:]
+	}
+	return outcome
+}
+
+// terminationTexts are the keys of the terminations, as recorded in PGN,
+// shown in the game-over panel.
+var terminationTexts = map[string]textKey{
+	"abandoned":              txtEndAbandoned,
+	"aborted":                txtEndAborted,
+	"adjudication":           txtEndAdjudication,
+	"agreement":              txtEndAgreement,
+	"checkmate":              txtEndCheckmate,
+	"fivefold repetition":    txtEndFivefold,
+	"forfeit":                txtEndForfeit,
+	"insufficient material":  txtEndDeadPosition,
+	"king captured":          txtEndKingCaptured,
+	"king of the hill":       txtEndKingOfHill,
+	"three-check":            txtEndThreeCheck,
+	"move limit":             txtEndMoveLimit,
+	"resignation":            txtEndResignation,
+	"seventy-five-move rule": txtEndSeventyFive,
+	"stalemate":              txtEndStalemate,
+	"time forfeit":           txtEndTimeForfeit,
+}
+
+// saveGame writes the game as PGN to the autosave directory, or to the
+// current directory when autosave is off, and reports where it went.
+func (g *Game) saveGame() {
+	dir := g.autosaveDir
+	if dir == "" {
+		dir = "."
+	}
+	path, err := g.savePGN(dir)
+	if err != nil {
+		g.message = tr(txtSaveFailed, err)
+		return
+	}
+	g.message = tr(txtSaved, path)
+}
+
+// saveGameSummary writes the game summary next to where saveGame puts the
+// PGN and reports where it went.
+func (g *Game) saveGameSummary() {
+	dir := g.autosaveDir
+	if dir == "" {
+		dir = "."
+	}
+	path, err := g.saveSummary(dir)
+	if err != nil {
+		g.message = tr(txtSaveFailed, err)
+		return
+	}
+	g.message = tr(txtSaved, path)
+}
+
+// analyze opens the finished game in the replay viewer. Esc in the viewer
+// comes back to the game-over panel.
+func (g *Game) analyze() {
+	replay := NewGame()
+	frames := []replayFrame{{board: replay.board, message: tr(txtReplayStart)}}
+	for _, moveStr := range g.moveHistory {
+		if replay.ApplyAlgebraic(moveStr, replay.currentPlayer) != nil {
+			break
+		}
+		frames = append(frames, replayFrame{board: replay.board, move: moveStr, message: replay.message})
+	}
+	frames[len(frames)-1].message = g.outcome() + "."
+
+	viewer := NewGame()
+	viewer.glyphs = g.glyphs
+	viewer.pieceStyle = g.pieceStyle
+	viewer.currentThemeIndex = g.currentThemeIndex
+	viewer.flipped = g.flipped
+	viewer.prefs = g.prefs
+	runReplay(viewer, frames)
+	g.currentThemeIndex = viewer.currentThemeIndex
+}
+
+// drawGameOver dims the board and draws the game-over panel over it. The
+// caller holds g.lock.
+func (g *Game) drawGameOver(theme Theme) {
+	width, _ := termbox.Size()
+	cells := termbox.CellBuffer()
+	for i := 0; i < width*g.squareHeight*8 && i < len(cells); i++ {
+		cells[i].Fg |= termbox.AttrDim
+	}
+
+	lines := []string{g.outcome(), "", g.materialLine()}
+	lines = append(lines, g.summarize().lines()...)
+	g.drawPanel(theme, append(lines, "", tr(txtGameOverChoices)))
+}
+
+// materialLine reports the material each side has left.
+func (g *Game) materialLine() string {
+	return tr(txtMaterial, pawns(g.material("white")), pawns(g.material("black")))
+}
+
+// drawPanel draws lines in a bordered box centred over the board.
+func (g *Game) drawPanel(theme Theme, lines []string) {
+	inner := 0
+	for _, line := range lines {
+		inner = max(inner, utf8.RuneCountInString(line))
+	}
+	w, h := inner+4, len(lines)+2
+	left := max((g.squareWidth*8-w)/2, 0)
+	top := max((g.squareHeight*8-h)/2, 0)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r := ' '
+			switch {
+			case y == 0 && x == 0:
+				r = '┌'
+			case y == 0 && x == w-1:
+				r = '┐'
+			case y == h-1 && x == 0:
+				r = '└'
+			case y == h-1 && x == w-1:
+				r = '┘'
+			case y == 0 || y == h-1:
+				r = '─'
+			case x == 0 || x == w-1:
+				r = '│'
+			}
+			termbox.SetCell(left+x, top+y, r, theme.CursorFg, theme.BackgroundBg)
+		}
+	}
+	for i, line := range lines {
+		x := left + 2 + (inner-utf8.RuneCountInString(line))/2
+		for _, r := range line {
+			termbox.SetCell(x, top+1+i, r, theme.MessageFg, theme.BackgroundBg)
+			x++
+		}
+	}
+}
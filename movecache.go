@@ -0,0 +1,44 @@
+package main
+
+import "sync"
+
+// moveCache remembers the legal moves of each piece in one position.
+// Selecting a piece, SAN disambiguation and the checkmate and stalemate
+// tests after every move all ask for the same check-filtered moves, which
+// are the costly part of the rules engine.
+//
+// Entries are keyed by the position itself rather than dropped by hand:
+// every change to the board, castling rights or en passant square, whether
+// by a move, a takeback, a loaded FEN or the replay viewer, gives a new
+// key and clears the cache on the next lookup.
+type moveCache struct {
+	mu    sync.Mutex // Lookups come from both the event loop and the network goroutine
+	key   moveCacheKey
+	moves [8][8]map[string]moveKind // nil until worked out
+}
+
+// moveCacheKey is everything the legal moves of a piece depend on.
+type moveCacheKey struct {
+	board     [8][8]*Piece
+	castling  string
+	enPassant string
+	freestyle bool
+}
+
+// movesFrom returns the legal destinations of the piece at (y, x), keyed the
+// same way as legalMoves. The map is shared with the cache, so callers must
+// not modify it.
+func (g *Game) movesFrom(y, x int) map[string]moveKind {
+	c := &g.moveCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := moveCacheKey{board: g.board, castling: g.castling, enPassant: g.enPassant, freestyle: g.freestyle}
+	if key != c.key {
+		c.key = key
+		c.moves = [8][8]map[string]moveKind{}
+	}
+	if c.moves[y][x] == nil {
+		c.moves[y][x] = g.generateMoves(y, x)
+	}
+	return c.moves[y][x]
+}
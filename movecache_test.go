@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+// BenchmarkMovesFrom asks for the moves of every piece of the side to move
+// three times a turn, as drawing the board, the checkmate and stalemate
+// tests and the status line do, in a busy middlegame position. The cached
+// run starts each turn with an empty cache, as after a move; the uncached
+// run calls the generator for every question.
+func BenchmarkMovesFrom(b *testing.B) {
+	const asksPerTurn = 3
+	g := newTestGame(b, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	var squares [][2]int
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && piece.color == g.currentPlayer {
+				squares = append(squares, [2]int{y, x})
+			}
+		}
+	}
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			g.moveCache.key = moveCacheKey{} // A new position
+			for range asksPerTurn {
+				for _, sq := range squares {
+					g.movesFrom(sq[0], sq[1])
+				}
+			}
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for range asksPerTurn {
+				for _, sq := range squares {
+					g.generateMoves(sq[0], sq[1])
+				}
+			}
+		}
+	})
+}
+
+func TestMoveCacheFollowsPosition(t *testing.T) {
+	g := newTestGame(t, "")
+	if got := len(g.movesFrom(6, 4)); got != 2 {
+		t.Fatalf("e2 pawn has %d moves, want 2", got)
+	}
+	// Black's reply doesn't touch e2 but changes the position, so the
+	// pawn's moves are worked out again: e4 is now blocked.
+	playMoves(t, g, "g1f3", "e7e5", "f3g1", "e5e4")
+	if got := len(g.movesFrom(6, 4)); got != 1 {
+		t.Errorf("after ...e4 the e2 pawn has %d moves, want 1", got)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxMoves is the game length, in full moves, after which an
+// unfinished game is adjudicated. It is far beyond any game people play, so
+// only runaway automated games ever reach it.
+const defaultMaxMoves = 300
+
+// ctrlMaxMoves sets the move limit of a networked game, e.g. "max-moves
+// 300" or "max-moves 200 material". The host or server sends it before the
+// game starts, so both sides end an overlong game on the same move.
+const ctrlMaxMoves = "max-moves"
+
+// standardValues are the piece values an overlong game is adjudicated by.
+// Material is counted at these whatever piece values the preferences set,
+// so both sides of a networked game reach the same result.
+var standardValues = map[string]int{"pawn": 1, "knight": 3, "bishop": 3, "rook": 5, "queen": 9}
+
+// moveLimitReached reports whether the game has run to its move limit.
+// The caller holds g.lock.
+func (g *Game) moveLimitReached() bool {
+	return g.maxMoves > 0 && len(g.moveHistory) >= 2*g.maxMoves
+}
+
+// moveLimitEnding is how a game that reached its move limit ends: drawn,
+// or with maxMovesByMaterial won by the side with more material. The
+// caller holds g.lock.
+func (g *Game) moveLimitEnding() gameEnding {
+	result, message := resultDraw, tr(txtMoveLimitDraw, g.maxMoves)
+	if g.maxMovesByMaterial {
+		white, black := g.standardMaterial("white"), g.standardMaterial("black")
+		switch {
+		case white > black:
+			result = winResult("white")
+		case black > white:
+			result = winResult("black")
+		}
+		message = tr(txtMoveLimitMaterial, g.maxMoves, white, black, result)
+	}
+	return gameEnding{result, "move limit", message}
+}
+
+// standardMaterial totals color's material at the standard piece values.
+func (g *Game) standardMaterial(color string) int {
+	total := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && piece.color == color {
+				total += standardValues[pieceKind(piece)]
+			}
+		}
+	}
+	return total
+}
+
+// moveLimitVerb is the max-moves control message announcing g's limit.
+func (g *Game) moveLimitVerb() string {
+	verb := ctrlMaxMoves + " " + strconv.Itoa(g.maxMoves)
+	if g.maxMovesByMaterial {
+		verb += " material"
+	}
+	return verb
+}
+
+// applyMoveLimit acts on a max-moves control message from the host or
+// server. The caller holds g.lock.
+func (g *Game) applyMoveLimit(verb, arg string) error {
+	switch {
+	case g.hosting:
+		return fmt.Errorf("%q: %w", verb, errNotAuthority)
+	case !g.settingUp:
+		return fmt.Errorf("%q: %w", verb, errGameStarted)
+	}
+	fields := strings.Fields(arg)
+	if len(fields) == 0 || len(fields) > 2 || (len(fields) == 2 && fields[1] != "material") {
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+	g.maxMoves, g.maxMovesByMaterial = n, len(fields) == 2
+	return nil
+}
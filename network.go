@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// maxLineLength bounds a single message on the wire. Every message of the
+// protocol is far shorter; anything longer is garbage.
+const maxLineLength = 256
+
+// errLineTooLong reports a message longer than maxLineLength. It has been
+// skipped, so the next read starts cleanly at the following line.
+var errLineTooLong = errors.New("message too long")
+
+// lineReader reads newline-terminated messages without ever buffering more
+// than maxLineLength bytes, however much a peer sends without a newline.
+// Lines split across TCP segments or sharing one are reassembled as usual.
+type lineReader struct {
+	r *bufio.Reader
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, maxLineLength)}
+}
+
+// readLine returns the next message with surrounding whitespace trimmed. An
+// oversized line is discarded up to its newline and reported with
+// errLineTooLong; any other error means the connection is done.
+func (lr *lineReader) readLine() (string, error) {
+	line, err := lr.r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		for err == bufio.ErrBufferFull {
+			_, err = lr.r.ReadSlice('\n')
+		}
+		if err != nil {
+			return "", err
+		}
+		return "", errLineTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(line)), nil
+}
+
+// hostGame waits on port 8080 for an opponent to join, and plays white.
+func hostGame() (net.Conn, string, error) {
+	local := getLocalIP()
+	if local.ip == "" {
+		fmt.Println("Could not determine a local IP address, so listening on all interfaces.")
+		fmt.Println("Your opponent should join using this machine's address (see 'ip addr' or 'ipconfig').")
+	}
+	addr := net.JoinHostPort(local.ip, "8080")
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	defer ln.Close()
+	fmt.Printf("Hosting on %s.\n", addr)
+	stop := consoleSpinner("Waiting for an opponent")
+	conn, err := ln.Accept()
+	stop()
+	if err != nil {
+		return nil, "", err
+	}
+	fmt.Fprintln(conn, "black")
+	return conn, "white", nil
+}
+
+// Backoff between connection attempts when joining with a retry period.
+const (
+	firstRetryDelay = 500 * time.Millisecond
+	maxRetryDelay   = 5 * time.Second
+)
+
+// joinGame connects to a host or server on port 8080 and returns the color
+// it assigns. If the host is not listening yet it keeps trying for up to
+// retry, so both players can start at the same time.
+func joinGame(host string, retry time.Duration) (net.Conn, string, error) {
+	conn, err := dialWithRetry(net.JoinHostPort(host, "8080"), retry)
+	if err != nil {
+		return nil, "", err
+	}
+	fmt.Println("Connected.")
+	stop := consoleSpinner("Waiting for the game to start")
+	player, err := readColor(conn)
+	stop()
+	if err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return conn, player, nil
+}
+
+// dialWithRetry connects to addr, retrying with a doubling delay until
+// retry has passed. Ctrl-C cancels the wait.
+func dialWithRetry(addr string, retry time.Duration) (net.Conn, error) {
+	if retry <= 0 {
+		return net.Dial("tcp", addr)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ctx, cancelRetry := context.WithTimeout(ctx, retry)
+	defer cancelRetry()
+
+	var dialer net.Dialer
+	delay := firstRetryDelay
+	for attempt := 1; ; attempt++ {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn, nil
+		}
+		if attempt == 1 {
+			fmt.Printf("%s is not answering yet; retrying for up to %v (Ctrl-C cancels).\n", addr, retry)
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+			}
+			return nil, errors.New("cancelled")
+		case <-time.After(delay):
+		}
+		fmt.Printf("Connecting, attempt %d...\n", attempt+1)
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// receiveMessages dispatches the opponent's messages as they arrive on
+// conn, until the connection fails. It only needs an io.ReadWriter, so a
+// game can be driven over net.Pipe or any in-memory pipe as well as a real
+// socket.
+func (g *Game) receiveMessages(conn io.ReadWriter) {
+	reader := newLineReader(conn)
+	g.heard()
+	for {
+		line, err := reader.readLine()
+		if err == errLineTooLong {
+			g.lock.Lock()
+			g.message = tr(txtIgnoredOversized)
+			g.lock.Unlock()
+			g.drawBoard()
+			continue
+		}
+		if err != nil {
+			g.opponentLeft()
+			g.drawBoard()
+			return
+		}
+		g.heard()
+		m, err := decodeMessage(line)
+		if err != nil {
+			g.lock.Lock()
+			g.message = tr(txtIgnoredOpponent, err)
+			g.lock.Unlock()
+		} else {
+			g.dispatch(conn, m)
+			g.reportView(conn)
+		}
+		g.drawBoard()
+	}
+}
+
+// opponentLeft ends the game when the connection closes. An opponent who
+// quit sent a leave message first; without one, the connection dropped.
+// Either way, leaving while the game is abortable is an abort with no
+// result and leaving later forfeits the game. After the game is over the
+// result stands and only the message says who has gone.
+func (g *Game) opponentLeft() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.disconnected = true
+	how := tr(txtConnectionLost)
+	if g.opponentQuit {
+		how = tr(txtOpponentLeft)
+	}
+	switch {
+	case g.spectating:
+		g.message = tr(txtServerClosed)
+	case g.gameOver:
+		g.message = how
+	case g.abortable():
+		g.endGame(resultOngoing, "aborted", tr(txtLeftAborted, how))
+	default:
+		g.endGame(winResult(g.playerColor), "abandoned", tr(txtLeftYouWin, how))
+	}
+}
+
+// sayGoodbye tells the opponent this player is leaving on purpose, so
+// they can tell a quit from a dropped connection.
+func (g *Game) sayGoodbye(conn io.Writer) {
+	if !g.spectating {
+		sendMessage(conn, message{kind: msgControl, arg: ctrlLeave})
+	}
+}
+
+// awaitAck marks the move just sent as unconfirmed. If the opponent's ack
+// does not arrive within ackTimeout, the message bar warns that delivery is
+// unconfirmed. It does nothing when ackTimeout is zero.
+func (g *Game) awaitAck() {
+	if g.ackTimeout <= 0 {
+		return
+	}
+	g.lock.Lock()
+	g.ackPending = true
+	g.ackSeq++
+	seq := g.ackSeq
+	g.lock.Unlock()
+
+	time.AfterFunc(g.ackTimeout, func() {
+		g.lock.Lock()
+		late := g.ackPending && g.ackSeq == seq
+		if late {
+			g.deliveryUnconfirmed = true
+		}
+		g.lock.Unlock()
+		if late && !g.headless {
+			termbox.Interrupt() // Wake the event loop to redraw
+		}
+	})
+}
+
+// confirmDelivery records that the opponent acknowledged our last move.
+func (g *Game) confirmDelivery() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.ackPending = false
+	g.deliveryUnconfirmed = false
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// pgnTag matches a PGN tag pair such as [White "Alice"].
+var pgnTag = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+
+// pgnMoveNumber matches the move number in front of a move, e.g. "12." or
+// "12...".
+var pgnMoveNumber = regexp.MustCompile(`^\d+\.+`)
+
+// writePGN writes the game in PGN: the tag pairs followed by the movetext,
+// wrapped to 80 columns.
+func (g *Game) writePGN(w io.Writer, date time.Time) error {
+	tags := [][2]string{
+		{"Event", "Casual game"},
+		{"Site", "chessGo"},
+		{"Date", date.Format("2006.01.02")},
+		{"Round", "-"},
+		{"White", g.playerName("white")},
+		{"Black", g.playerName("black")},
+		{"Result", g.result},
+	}
+	if g.termination != "" {
+		tags = append(tags, [2]string{"Termination", g.termination})
+	}
+	if g.startFEN != "" {
+		tags = append(tags, [2]string{"SetUp", "1"}, [2]string{"FEN", g.startFEN})
+	}
+	var sb strings.Builder
+	for _, tag := range tags {
+		fmt.Fprintf(&sb, "[%s %q]\n", tag[0], tag[1])
+	}
+	sb.WriteString("\n")
+
+	movetext := g.moveList()
+	if g.termination == "aborted" {
+		// The "*" result alone would read as a game still in progress.
+		movetext = strings.TrimSuffix(movetext, g.result) + "{Game aborted} " + g.result
+	}
+	line := 0
+	for _, token := range strings.Fields(movetext) {
+		if line > 0 && line+1+len(token) > 80 {
+			sb.WriteString("\n")
+			line = 0
+		}
+		if line > 0 {
+			sb.WriteString(" ")
+			line++
+		}
+		sb.WriteString(token)
+		line += len(token)
+	}
+	sb.WriteString("\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// playerName returns the name recorded for color, or "?" if unknown.
+func (g *Game) playerName(color string) string {
+	if color == g.playerColor && g.localName != "" {
+		return g.localName
+	}
+	return "?"
+}
+
+// autosave writes the finished game as a timestamped PGN file in
+// autosaveDir, creating the directory if needed. Failures are reported on
+// the message bar so the end of the game is never interrupted.
+func (g *Game) autosave() {
+	if g.autosaveDir == "" {
+		return
+	}
+	if _, err := g.savePGN(g.autosaveDir); err != nil {
+		g.message += " " + tr(txtAutosaveFailed, err)
+	}
+}
+
+// savePGN writes the game to a new timestamped PGN file in dir and returns
+// its path.
+func (g *Game) savePGN(dir string) (string, error) {
+	now := time.Now()
+	path := filepath.Join(dir, "game-"+now.Format("20060102-150405")+".pgn")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = g.writePGN(f, now)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return path, err
+}
+
+// readPGN reads the first game in a PGN file: its tag pairs, its moves in
+// SAN and its result token, or "" if the movetext has none. Comments,
+// variations and annotation glyphs are skipped.
+func readPGN(r io.Reader) (tags map[string]string, moves []string, result string, err error) {
+	tags = make(map[string]string)
+	var movetext strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if movetext.Len() > 0 {
+				break // The next game's tags
+			}
+			if m := pgnTag.FindStringSubmatch(line); m != nil {
+				tags[m[1]] = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(m[2])
+			}
+			continue
+		}
+		movetext.WriteString(line)
+		movetext.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, "", err
+	}
+
+	// Drop comments and variations, then split what is left.
+	var clean strings.Builder
+	inComment, inLineComment, depth := false, false, 0
+	for _, r := range movetext.String() {
+		switch {
+		case inComment:
+			inComment = r != '}'
+		case inLineComment:
+			inLineComment = r != '\n'
+		case r == '{':
+			inComment = true
+		case r == ';':
+			inLineComment = true
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			clean.WriteRune(r)
+			continue
+		}
+		clean.WriteRune(' ')
+	}
+	for _, token := range strings.Fields(clean.String()) {
+		token = pgnMoveNumber.ReplaceAllString(token, "")
+		switch {
+		case token == "" || strings.HasPrefix(token, "$"):
+		case token == resultWhiteWins || token == resultBlackWins || token == resultDraw || token == resultOngoing:
+			return tags, moves, token, nil
+		default:
+			moves = append(moves, token)
+		}
+	}
+	return tags, moves, "", nil
+}
+
+// loadPGN plays out the first game in a PGN file on a new Game, starting
+// from its FEN tag if it has one, and returns the game with the file's tags
+// and result token. If each is set it sees the start position, with an
+// empty moveStr, and then the position after every move.
+func loadPGN(r io.Reader, each func(g *Game, moveStr string)) (g *Game, tags map[string]string, result string, err error) {
+	tags, moves, result, err := readPGN(r)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	g = NewGame()
+	if fen := tags["FEN"]; fen != "" {
+		if err := g.loadFEN(fen); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	if each != nil {
+		each(g, "")
+	}
+	for i, san := range moves {
+		moveStr, ok := g.parseSAN(san)
+		if !ok {
+			return nil, nil, "", fmt.Errorf("move %d: %q: %w", i+1, san, ErrIllegalMove)
+		}
+		if err := g.ApplyAlgebraic(moveStr, g.currentPlayer); err != nil {
+			return nil, nil, "", fmt.Errorf("move %d: %w", i+1, err)
+		}
+		if each != nil {
+			each(g, moveStr)
+		}
+	}
+	return g, tags, result, nil
+}
+
+// continuePGN loads the game in the PGN file at path so that play can carry
+// on from its final position, e.g. to resume an adjourned game. A game that
+// has already ended cannot be continued.
+func continuePGN(path string) (*Game, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, _, result, err := loadPGN(f, nil)
+	if err != nil {
+		return nil, err
+	}
+	if g.gameOver || (result != "" && result != resultOngoing) {
+		return nil, fmt.Errorf("%s: the game is already over", path)
+	}
+	return g, nil
+}
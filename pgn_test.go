@@ -0,0 +1,47 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePGNAbortedGame(t *testing.T) {
+	g := newTestGame(t, "")
+	playMoves(t, g, "e2e4")
+	g.endGame(resultOngoing, "aborted", "")
+
+	var sb strings.Builder
+	if err := g.writePGN(&sb, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	pgn := sb.String()
+	for _, want := range []string{`[Result "*"]`, `[Termination "aborted"]`, "1. e4 {Game aborted} *\n"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("PGN lacks %q:\n%s", want, pgn)
+		}
+	}
+
+	_, moves, result, err := readPGN(strings.NewReader(pgn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(moves, []string{"e4"}) || result != "*" {
+		t.Errorf("read back moves %v, result %q; want [e4], *", moves, result)
+	}
+}
+
+func TestWritePGNResignation(t *testing.T) {
+	g := newTestGame(t, "")
+	playMoves(t, g, "e2e4", "e7e5")
+	g.endGame(resultBlackWins, "resignation", "")
+
+	var sb strings.Builder
+	if err := g.writePGN(&sb, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "1. e4 e5 0-1\n") {
+		t.Errorf("movetext wrong:\n%s", sb.String())
+	}
+}
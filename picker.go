@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nsf/termbox-go"
+)
+
+// openThemePicker lists every theme by name. Moving through the list
+// previews each theme on the board; Enter keeps it and Esc goes back to the
+// theme that was showing when the picker opened.
+func (g *Game) openThemePicker() {
+	g.inputMode = modeThemePicker
+	g.pickerOrigin = g.currentThemeIndex
+	g.message = tr(txtThemePicker)
+}
+
+// handleThemePickerKey moves through the theme list or closes the picker.
+func (g *Game) handleThemePickerKey(ev termbox.Event) {
+	switch {
+	case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+		g.currentThemeIndex = (g.currentThemeIndex + len(themes) - 1) % len(themes)
+	case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+		g.currentThemeIndex = (g.currentThemeIndex + 1) % len(themes)
+	case ev.Key == termbox.KeyEnter:
+		g.inputMode = modeNormal
+		g.setTheme(g.currentThemeIndex)
+		g.message = tr(txtThemeChosen, themes[g.currentThemeIndex].Name)
+	case ev.Key == termbox.KeyEsc:
+		g.inputMode = modeNormal
+		g.currentThemeIndex = g.pickerOrigin
+		g.message = tr(txtCancelled)
+	}
+}
+
+// themePickerLines are the picker's rows, with the previewed theme marked.
+// Names are padded to one width so they line up in the centred panel.
+func (g *Game) themePickerLines() []string {
+	width := 0
+	for _, theme := range themes {
+		width = max(width, len(theme.Name))
+	}
+	lines := []string{tr(txtThemePickerTitle), ""}
+	for i, theme := range themes {
+		marker := " "
+		if i == g.currentThemeIndex {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("%s %-*s", marker, width, theme.Name))
+	}
+	return lines
+}
+
+// themeStripSquares is how many squares the preview strip shows of each
+// theme, alternating light and dark.
+const themeStripSquares = 4
+
+// themeStripOrigin is the top-left cell of the theme preview strip, in the
+// corner to the right of the board.
+func (g *Game) themeStripOrigin() (int, int) {
+	return g.squareWidth*8 + 2, 0
+}
+
+// drawThemeStrip shows the themes either side of the current one and the
+// current one between them, each as a few squares in its colors with a
+// piece of each side on them, so the wheel can step through them by eye.
+// It is left out when the terminal reports no mouse or has no room for it.
+func (g *Game) drawThemeStrip(current Theme) {
+	if termbox.SetInputMode(termbox.InputCurrent)&termbox.InputMouse == 0 {
+		return
+	}
+	left, top := g.themeStripOrigin()
+	if width, height := termbox.Size(); left+3+2*themeStripSquares > width || top+3 > height {
+		return
+	}
+	for row, step := range []int{len(themes) - 1, 0, 1} {
+		theme := themes[(g.currentThemeIndex+step)%len(themes)]
+		marker := ' '
+		if step == 0 {
+			marker = '>'
+		}
+		termbox.SetCell(left, top+row, marker, current.CursorFg, current.BackgroundBg)
+		for i := 0; i < themeStripSquares; i++ {
+			bg, color := theme.LightSquareBg, "white"
+			if i%2 == 1 {
+				bg, color = theme.DarkSquareBg, "black"
+			}
+			x := left + 2 + 2*i
+			g.drawPiece(x, top+row, newPiece(color, "pawn"), theme, bg)
+			termbox.SetCell(x+1, top+row, ' ', theme.MessageFg, bg)
+		}
+		for i, r := range theme.Name {
+			termbox.SetCell(left+3+2*themeStripSquares+i, top+row, r, current.MessageFg, current.BackgroundBg)
+		}
+	}
+}
+
+// overThemeArea reports whether the cell (sx, sy) is on the preview strip
+// or on the theme's name at the start of the message bar.
+func (g *Game) overThemeArea(sx, sy int) bool {
+	left, top := g.themeStripOrigin()
+	if sx >= left && sy >= top && sy < top+3 {
+		return true
+	}
+	name := tr(txtThemeName, themes[g.currentThemeIndex].Name)
+	return sy == g.squareHeight*8+2 && sx < len([]rune(name))
+}
+
+// wheelTheme steps through the themes when ev turns the mouse wheel over
+// the theme area, down to the next one and up to the one before, as 'c'
+// does. With the theme picker open the wheel moves its preview anywhere on
+// the screen. It reports whether ev was a wheel event, handled or not.
+func (g *Game) wheelTheme(ev termbox.Event) bool {
+	if ev.Key != termbox.MouseWheelUp && ev.Key != termbox.MouseWheelDown {
+		return false
+	}
+	step := 1
+	if ev.Key == termbox.MouseWheelUp {
+		step = len(themes) - 1
+	}
+	index := (g.currentThemeIndex + step) % len(themes)
+	switch {
+	case g.inputMode == modeThemePicker:
+		g.currentThemeIndex = index
+	case g.overThemeArea(ev.MouseX, ev.MouseY):
+		g.setTheme(index)
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// pieceStyle draws a piece inside its square, whose top-left terminal cell
+// is (sx, sy). Styles are selectable with -piece-style.
+type pieceStyle interface {
+	drawInSquare(g *Game, sx, sy int, piece *Piece, theme Theme, bg termbox.Attribute)
+}
+
+// pieceStyles are the styles -piece-style accepts.
+var pieceStyles = map[string]pieceStyle{
+	"glyph": glyphStyle{},
+	"art":   artStyle{},
+}
+
+// pieceStyleNames lists the piece styles for usage and errors.
+func pieceStyleNames() string {
+	names := make([]string, 0, len(pieceStyles))
+	for name := range pieceStyles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// style is the piece style g draws with.
+func (g *Game) style() pieceStyle {
+	if g.pieceStyle == nil {
+		return glyphStyle{}
+	}
+	return g.pieceStyle
+}
+
+// glyphStyle draws each piece as its one glyph in the middle of the square.
+type glyphStyle struct{}
+
+func (glyphStyle) drawInSquare(g *Game, sx, sy int, piece *Piece, theme Theme, bg termbox.Attribute) {
+	layout := g.squareLayout()
+	g.drawPiece(sx+layout.pieceX, sy+layout.pieceY, piece, theme, bg)
+}
+
+// artWidth and artHeight are the size of each picture in pieceArt.
+const (
+	artWidth  = 5
+	artHeight = 3
+)
+
+// pieceArt holds a small ASCII picture of each kind of piece, drawn by
+// artStyle in the piece's color.
+var pieceArt = map[string][artHeight]string{
+	"king":   {"  +  ", " ) ( ", "/___\\"},
+	"queen":  {" vVv ", " ) ( ", "/___\\"},
+	"rook":   {"|_|_|", " | | ", "/___\\"},
+	"bishop": {"  o  ", " (/) ", "/___\\"},
+	"knight": {" /^) ", "(_ / ", "/___\\"},
+	"pawn":   {"  _  ", " ( ) ", " /_\\ "},
+}
+
+// artStyle draws each piece as a picture several cells across, for a bolder
+// board. The picture keeps clear of the cursor markers at the square's
+// sides and the move marker in its bottom row; in a square too small for
+// that it falls back to glyphStyle.
+type artStyle struct{}
+
+func (artStyle) drawInSquare(g *Game, sx, sy int, piece *Piece, theme Theme, bg termbox.Attribute) {
+	art, ok := pieceArt[pieceKind(piece)]
+	if !ok || g.squareWidth < artWidth+2 || g.squareHeight < artHeight+1 {
+		glyphStyle{}.drawInSquare(g, sx, sy, piece, theme, bg)
+		return
+	}
+	fg := theme.WhitePieceFg
+	if piece.color == "black" {
+		fg = theme.BlackPieceFg
+	}
+	left, top := sx+(g.squareWidth-artWidth)/2, sy+(g.squareHeight-1-artHeight)/2
+	for i, row := range art {
+		for j, r := range row {
+			if r != ' ' {
+				termbox.SetCell(left+j, top+i, r, fg|termbox.AttrBold, bg)
+			}
+		}
+	}
+}
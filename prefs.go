@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// preferencesVersion is the version of the preferences file this build
+// writes. Bump it, and add a step to preferencesMigrations, whenever a
+// field changes meaning or is renamed.
+const preferencesVersion = 1
+
+// preferencesMigrations bring a preferences file from one version to the
+// next: preferencesMigrations[v] turns version v into version v+1. They
+// work on the decoded JSON, so they can see fields the current struct no
+// longer has.
+var preferencesMigrations = []func(fields map[string]any){
+	// Version 0 files predate the version field; its fields are unchanged.
+	func(map[string]any) {},
+}
+
+// preferences are the settings remembered between runs.
+type preferences struct {
+	Version  int    `json:"version"`
+	Theme    string `json:"theme"`
+	Muted    bool   `json:"muted"`
+	Sound    string `json:"sound"`
+	SoundCmd string `json:"sound_cmd,omitempty"`
+	Lang     string `json:"lang,omitempty"`
+
+	PieceValues map[string]int `json:"piece_values,omitempty"` // Overrides for PieceValues, by piece name
+
+	readOnly bool // Loaded from a file this build cannot read, which save leaves alone
+}
+
+// errPreferencesReadOnly is returned by save when the preferences file on
+// disk could not be read and so must not be overwritten.
+var errPreferencesReadOnly = errors.New("the preferences file was not understood, so it is left as it is")
+
+// preferencesPath returns where preferences are stored, under the user's
+// config directory.
+func preferencesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chessGo", "preferences.json"), nil
+}
+
+// loadPreferences reads the saved preferences, migrating a file written by
+// an older version. A missing file just yields the defaults. So does a file
+// that is malformed or was written by a newer version, but then the error
+// says so and the file is never overwritten.
+func loadPreferences() (preferences, error) {
+	prefs := preferences{Sound: "bell"}
+	path, err := preferencesPath()
+	if err != nil {
+		return prefs, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return prefs, nil
+	}
+	if err := decodePreferences(data, &prefs); err != nil {
+		prefs = preferences{Sound: "bell", readOnly: true}
+		return prefs, fmt.Errorf("%s: %w; using the defaults", path, err)
+	}
+	return prefs, nil
+}
+
+// decodePreferences decodes a preferences file of any version up to
+// preferencesVersion into prefs.
+func decodePreferences(data []byte, prefs *preferences) error {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	version := 0
+	if v, ok := fields["version"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n != float64(int(n)) {
+			return fmt.Errorf("bad version %v", v)
+		}
+		version = int(n)
+	}
+	if version > preferencesVersion {
+		return fmt.Errorf("written by a newer chessGo (version %d, this one reads up to %d); update chessGo or move the file aside", version, preferencesVersion)
+	}
+	for ; version < preferencesVersion; version++ {
+		preferencesMigrations[version](fields)
+	}
+	fields["version"] = preferencesVersion
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, prefs)
+}
+
+// save writes the preferences at the current version, creating the config directory if needed.
+func (p *preferences) save() error {
+	if p.readOnly {
+		return errPreferencesReadOnly
+	}
+	p.Version = preferencesVersion
+	path, err := preferencesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// applyPreferences makes g use prefs and keep them up to date as the player
+// changes settings.
+func (g *Game) applyPreferences(prefs *preferences) {
+	for i, theme := range themes {
+		if theme.Name == prefs.Theme {
+			g.currentThemeIndex = i
+		}
+	}
+	g.muted = prefs.Muted
+	g.prefs = prefs
+}
+
+// savePreferences stores g's preferences, reporting failures on the message
+// bar rather than interrupting the game.
+func (g *Game) savePreferences() {
+	if err := g.prefs.save(); err != nil {
+		g.message = tr(txtPrefsSaveFailed, err)
+	}
+}
+
+// cycleTheme switches to the next color theme and remembers it.
+func (g *Game) cycleTheme() {
+	g.setTheme((g.currentThemeIndex + 1) % len(themes))
+}
+
+// setTheme switches to themes[index] and remembers it.
+func (g *Game) setTheme(index int) {
+	g.currentThemeIndex = index
+	if g.prefs != nil {
+		g.prefs.Theme = themes[g.currentThemeIndex].Name
+		g.savePreferences()
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// premoveClick handles a click while the opponent is to move. The first
+// click picks one of our pieces and the second queues a premove for it,
+// which is played as soon as it is our turn if it is legal then. Up to
+// maxPremoves can be chained, one played each turn, and later links pick
+// pieces where the earlier ones left them. Whether a premove will be
+// legal can't be known yet, so any destination not held by our own pieces
+// is accepted. Clicking where there is nothing to pick, or at all once the
+// chain is full, cancels the whole chain.
+func (g *Game) premoveClick(color string) {
+	x, y := g.cursorX, g.cursorY
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	board := g.premoveBoard()
+	switch {
+	case len(g.premoves) >= g.maxPremoves:
+		g.premoves = nil
+		g.selectedX, g.selectedY = -1, -1
+		g.message = tr(txtPremovesCancelled)
+	case g.selectedX != -1:
+		target := board[y][x]
+		if target == nil || target.color != color {
+			g.premoves = append(g.premoves, formatMove(g.selectedY, g.selectedX, y, x))
+			g.message = g.premoveStatus()
+		} else {
+			g.message = tr(txtPremoveCancelled)
+		}
+		g.selectedX, g.selectedY = -1, -1
+	default:
+		if piece := board[y][x]; piece != nil && piece.color == color {
+			g.selectedX, g.selectedY = x, y
+			g.message = tr(txtPremovePick)
+		} else if len(g.premoves) > 0 {
+			g.premoves = nil
+			g.message = tr(txtPremovesCancelled)
+		} else {
+			g.message = tr(txtNotTurn)
+		}
+	}
+}
+
+// premoveBoard is the board as the queued premoves would leave it, with
+// only the moving pieces relocated, for picking the next link's piece.
+// The caller holds g.lock.
+func (g *Game) premoveBoard() [8][8]*Piece {
+	board := g.board
+	for _, moveStr := range g.premoves {
+		if fromY, fromX, toY, toX, _, ok := parseMove(moveStr); ok {
+			board[toY][toX], board[fromY][fromX] = board[fromY][fromX], nil
+		}
+	}
+	return board
+}
+
+// premoveStatus describes the queued chain, e.g. "Premoves queued: e2e4,
+// e4e5; click again to cancel." The caller holds g.lock.
+func (g *Game) premoveStatus() string {
+	if len(g.premoves) == 1 {
+		return tr(txtPremoveQueued, g.premoves[0])
+	}
+	return tr(txtPremovesQueued, strings.Join(g.premoves, ", "))
+}
+
+// firePremove plays the first queued premove, if any, now that it is our
+// turn, and sends it on conn. The rest of the chain waits for the turns
+// after. A premove that has become illegal is dropped with the reason,
+// and the rest of the chain with it, since it was planned on the move
+// that can no longer be played. A piece picked for a premove that was
+// never finished stays selected, if it survived, and gets its legal
+// moves.
+func (g *Game) firePremove(conn io.Writer) {
+	g.lock.Lock()
+	var moveStr string
+	if len(g.premoves) > 0 {
+		moveStr = g.premoves[0]
+		g.premoves = g.premoves[1:]
+	}
+	if moveStr == "" && g.selectedX != -1 {
+		if piece := g.board[g.selectedY][g.selectedX]; piece != nil && piece.color == g.playerColor && !g.gameOver {
+			g.calculateLegalMoves(g.selectedY, g.selectedX)
+		} else {
+			g.selectedX, g.selectedY = -1, -1
+		}
+	}
+	g.lock.Unlock()
+	if moveStr == "" {
+		return
+	}
+	if err := g.ApplyAlgebraic(moveStr, g.playerColor); err != nil {
+		g.lock.Lock()
+		g.message = tr(txtPremoveFailed, moveStr, err)
+		if len(g.premoves) > 0 {
+			g.message = tr(txtPremoveChainLost, moveStr, err)
+			g.premoves = nil
+		}
+		g.lock.Unlock()
+		return
+	}
+	g.sendMove(conn, moveStr)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ctrlSpectators tells the players how many spectators are watching, e.g.
+// "spectators 3". The server sends it whenever the number changes; players
+// never send it.
+const ctrlSpectators = "spectators"
+
+// quietAfter is how long the opponent may go unheard before the info bar
+// says so. Heartbeats arrive every heartbeatInterval even while nobody
+// moves, so missing two of them means the link is in trouble.
+const quietAfter = 2*heartbeatInterval + 5*time.Second
+
+// applySpectators acts on a "spectators N" control message. The caller
+// holds g.lock.
+func (g *Game) applySpectators(verb, arg string) error {
+	if g.hosting {
+		return fmt.Errorf("%q: %w", verb, errNotAuthority)
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+	if n > g.spectatorCount {
+		g.viewSent = "" // The newcomer has not heard which way we look
+	}
+	g.spectatorCount = n
+	return nil
+}
+
+// heard notes that something arrived from the opponent.
+func (g *Game) heard() {
+	g.lock.Lock()
+	g.lastHeard = time.Now()
+	g.lock.Unlock()
+}
+
+// linkQuiet reports how long the opponent has gone unheard, if longer than
+// quietAfter. The caller holds g.lock.
+func (g *Game) linkQuiet() (time.Duration, bool) {
+	if g.playerColor == "" || g.spectating || g.gameOver || g.lastHeard.IsZero() {
+		return 0, false
+	}
+	quiet := time.Since(g.lastHeard)
+	return quiet, quiet > quietAfter
+}
+
+// presenceStatus is the info bar's note on the opponent's connection and
+// the audience, e.g. "Opponent connected, 2 watching", or "" outside a
+// networked game. The caller holds g.lock.
+func (g *Game) presenceStatus() string {
+	if g.playerColor == "" || g.spectating || g.analysis || g.gameOver {
+		return ""
+	}
+	parts := []string{tr(txtOpponentConnected)}
+	if quiet, ok := g.linkQuiet(); ok {
+		parts[0] = tr(txtNoContact, quiet.Truncate(time.Second))
+	}
+	if g.spectatorCount > 0 {
+		parts = append(parts, tr(txtSpectatorCount, g.spectatorCount))
+	}
+	return strings.Join(parts, ", ")
+}
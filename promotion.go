@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/nsf/termbox-go"
+)
+
+// A pawn played to the last rank from the main game loop asks which piece
+// it becomes. A menu of the four opens on the promotion square's file,
+// running from that square toward the far side of the board, so it stays
+// on the board whichever way up the board is drawn. The queen comes first,
+// on the promotion square itself, so Enter straight away still queens.
+// The puzzle, trainer and analysis boards always queen.
+
+// promotionChoice is a promotion waiting for its piece to be chosen.
+type promotionChoice struct {
+	fromX, fromY int
+	toX, toY     int
+	index        int // Index into promotionKinds of the highlighted piece
+}
+
+// openPromotion opens the promotion menu for the selected pawn's move to
+// (x, y).
+func (g *Game) openPromotion(x, y int) {
+	g.promotion = &promotionChoice{fromX: g.selectedX, fromY: g.selectedY, toX: x, toY: y}
+	g.inputMode = modePromotion
+	letters := make([]string, len(promotionKinds))
+	for i, kind := range promotionKinds {
+		letters[i] = localSAN(sanLetters[kind])
+	}
+	g.message = tr(txtPromoteChoose, strings.Join(letters, "/"))
+}
+
+// promotionStep is how far down the screen each entry of the promotion
+// menu is from the one before: down from a promotion square at the top of
+// the screen, up from one at the bottom.
+func (g *Game) promotionStep() int {
+	if _, sy := g.squareToScreen(g.promotion.toX, g.promotion.toY); sy > 0 {
+		return -g.squareHeight
+	}
+	return g.squareHeight
+}
+
+// promotionMenu returns the top-left terminal cell of each entry of the
+// open promotion menu, in the order of promotionKinds.
+func (g *Game) promotionMenu() [4][2]int {
+	sx, sy := g.squareToScreen(g.promotion.toX, g.promotion.toY)
+	step := g.promotionStep()
+	var menu [4][2]int
+	for i := range menu {
+		menu[i] = [2]int{sx, sy + i*step}
+	}
+	return menu
+}
+
+// promote plays the pending promotion to kind and returns the move in wire
+// format, for the caller to send.
+func (g *Game) promote(kind string) string {
+	p := g.promotion
+	g.promotion = nil
+	g.inputMode = modeNormal
+	moveStr := formatMove(p.fromY, p.fromX, p.toY, p.toX) + promotionLetter(kind)
+	g.applyMove(p.fromY, p.fromX, p.toY, p.toX, kind)
+	g.selectedX, g.selectedY = -1, -1
+	g.legalMoves = make(map[string]moveKind)
+	return moveStr
+}
+
+// cancelPromotion closes the promotion menu and drops the pawn.
+func (g *Game) cancelPromotion() {
+	g.promotion = nil
+	g.inputMode = modeNormal
+	g.selectedX, g.selectedY = -1, -1
+	g.legalMoves = make(map[string]moveKind)
+	g.message = tr(txtMoveCancelled)
+}
+
+// promotionKey is the piece a key names in the promotion menu: its letter
+// in the language in use, or failing that its English one.
+func promotionKey(ch rune) (string, bool) {
+	ch = unicode.ToUpper(ch)
+	for _, kind := range promotionKinds {
+		if localSAN(sanLetters[kind]) == string(ch) {
+			return kind, true
+		}
+	}
+	for _, kind := range promotionKinds {
+		if sanLetters[kind] == string(ch) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// handlePromotionKey picks a piece from the promotion menu and sends the
+// move, or cancels it. The arrows move the highlight the way the menu runs
+// on the screen.
+func (g *Game) handlePromotionKey(ev termbox.Event, conn io.Writer) {
+	p := g.promotion
+	down := 1
+	if g.promotionStep() < 0 {
+		down = -1
+	}
+	switch {
+	case ev.Key == termbox.KeyEsc:
+		g.cancelPromotion()
+	case ev.Key == termbox.KeyEnter || ev.Key == termbox.KeySpace:
+		g.sendMove(conn, g.promote(promotionKinds[p.index]))
+	case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+		p.index = min(max(p.index+down, 0), len(promotionKinds)-1)
+	case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+		p.index = min(max(p.index-down, 0), len(promotionKinds)-1)
+	default:
+		if kind, ok := promotionKey(ev.Ch); ok {
+			g.sendMove(conn, g.promote(kind))
+		}
+	}
+}
+
+// clickPromotion picks the piece under terminal cell (sx, sy) from the
+// promotion menu and returns the move to send. A click anywhere else
+// cancels the promotion and returns "".
+func (g *Game) clickPromotion(sx, sy int) string {
+	for i, cell := range g.promotionMenu() {
+		if sx >= cell[0] && sx < cell[0]+g.squareWidth && sy >= cell[1] && sy < cell[1]+g.squareHeight {
+			return g.promote(promotionKinds[i])
+		}
+	}
+	g.cancelPromotion()
+	return ""
+}
+
+// drawPromotionMenu draws the open promotion menu over the board, each
+// piece in the promoting side's color, the highlighted one on the
+// selection color.
+func (g *Game) drawPromotionMenu(theme Theme) {
+	p := g.promotion
+	color := g.board[p.fromY][p.fromX].color
+	for i, cell := range g.promotionMenu() {
+		bg := theme.LegalMoveBg
+		if i == p.index {
+			bg = theme.SelectedBg
+		}
+		for row := 0; row < g.squareHeight; row++ {
+			for col := 0; col < g.squareWidth; col++ {
+				termbox.SetCell(cell[0]+col, cell[1]+row, ' ', theme.MessageFg, bg)
+			}
+		}
+		g.style().drawInSquare(g, cell[0], cell[1], newPiece(color, promotionKinds[i]), theme, bg)
+	}
+}
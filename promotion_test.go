@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestUnderpromotion(t *testing.T) {
+	tests := []struct {
+		move    string
+		kind    string
+		history string
+		san     string
+	}{
+		{"a7a8", "queen", "a7a8", "a8=Q"},
+		{"a7a8q", "queen", "a7a8", "a8=Q"},
+		{"a7a8r", "rook", "a7a8r", "a8=R"},
+		{"a7a8b", "bishop", "a7a8b", "a8=B"},
+		{"a7a8n", "knight", "a7a8n", "a8=N"},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, "8/P7/7k/8/8/8/8/4K3 w - - 0 1")
+		playMoves(t, g, tt.move)
+		if piece := g.board[0][0]; piece == nil || *piece != *newPiece("white", tt.kind) {
+			t.Errorf("%s left %v on a8, want a white %s", tt.move, piece, tt.kind)
+		}
+		if got := g.lastMove(); got != tt.history {
+			t.Errorf("%s recorded as %q, want %q", tt.move, got, tt.history)
+		}
+		if got := g.sanHistory[0]; got != tt.san {
+			t.Errorf("%s written %q, want %q", tt.move, got, tt.san)
+		}
+		if g.hash != g.computeHash() {
+			t.Errorf("%s: hash out of step", tt.move)
+		}
+	}
+
+	g := newTestGame(t, "8/P7/7k/8/8/8/8/4K3 w - - 0 1")
+	if err := g.ApplyAlgebraic("e1e2n", "white"); !errors.Is(err, ErrNotPromotion) {
+		t.Errorf("e1e2n: got %v, want %v", err, ErrNotPromotion)
+	}
+	if err := g.ApplyAlgebraic("a7a8k", "white"); !errors.Is(err, ErrMalformedMove) {
+		t.Errorf("a7a8k: got %v, want %v", err, ErrMalformedMove)
+	}
+	var got []string
+	for _, move := range legalMoves(g) {
+		if move[:2] == "a7" {
+			got = append(got, string(move))
+		}
+	}
+	slices.Sort(got)
+	if want := []string{"a7a8", "a7a8b", "a7a8n", "a7a8r"}; !slices.Equal(got, want) {
+		t.Errorf("pawn moves %v, want %v", got, want)
+	}
+	if move, ok := g.parseSAN("a8=N+"); !ok || move != "a7a8n" {
+		t.Errorf("parseSAN(a8=N+) = %q, %v, want a7a8n", move, ok)
+	}
+}
+
+func TestPerftPromotions(t *testing.T) {
+	// Position 4 of the Chess Programming Wiki's perft results, where
+	// both sides promote, underpromotions included, by the second ply.
+	g := newTestGame(t, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1")
+	for depth, nodes := range []int{6, 264, 9467} {
+		if got := perft(g, depth+1); got != nodes {
+			t.Errorf("perft(%d) = %d, want %d", depth+1, got, nodes)
+		}
+	}
+}
+
+func TestNetworkedUnderpromotion(t *testing.T) {
+	white, black := connectedGames(t, "8/P7/7k/8/8/8/8/4K3 w - - 0 1")
+	white.move(t, "a7a8n")
+	waitFor(t, "the promotion to arrive", func() bool { return moveCount(black.Game) == 1 })
+	if white.FEN() != black.FEN() {
+		t.Errorf("positions differ: %s vs %s", white.FEN(), black.FEN())
+	}
+	if got := black.FEN(); got != "N7/8/7k/8/8/8/8/4K3 b - - 0 1" {
+		t.Errorf("black sees %s", got)
+	}
+}
+
+// promotionGame is black to promote the pawn on a2, with the cursor on it
+// and squares 5 by 3 cells.
+func promotionGame(t *testing.T, flipped bool) *Game {
+	g := newTestGame(t, "4k3/8/8/8/8/8/p7/4K3 b - - 0 1")
+	g.playerColor, g.askPromotion, g.flipped = "black", true, flipped
+	g.squareWidth, g.squareHeight = 5, 3
+	g.cursorX, g.cursorY = 0, 6
+	g.handleMouseClick("black")
+	g.cursorX, g.cursorY = 0, 7
+	if moveStr := g.handleMouseClick("black"); moveStr != "" || g.inputMode != modePromotion {
+		t.Fatalf("a2a1 played %q at once, input mode %v", moveStr, g.inputMode)
+	}
+	return g
+}
+
+func TestPromotionMenuPlacement(t *testing.T) {
+	// Flipped, black's first rank is at the top of the screen and the a-file
+	// on the right: the menu runs down from a1.
+	g := promotionGame(t, true)
+	if got, want := g.promotionMenu(), [4][2]int{{35, 0}, {35, 3}, {35, 6}, {35, 9}}; got != want {
+		t.Errorf("flipped menu at %v, want %v", got, want)
+	}
+	g.handlePromotionKey(termbox.Event{Key: termbox.KeyArrowDown}, io.Discard) // Rook
+	g.handlePromotionKey(termbox.Event{Key: termbox.KeyEnter}, io.Discard)
+	if got := g.lastMove(); got != "a2a1r" || g.inputMode != modeNormal {
+		t.Errorf("flipped: played %q, input mode %v, want a2a1r", got, g.inputMode)
+	}
+
+	// Unflipped, a1 is in the bottom-left corner: the menu runs up.
+	g = promotionGame(t, false)
+	if got, want := g.promotionMenu(), [4][2]int{{0, 21}, {0, 18}, {0, 15}, {0, 12}}; got != want {
+		t.Errorf("menu at %v, want %v", got, want)
+	}
+	if got := g.clickPromotion(2, 13); got != "a2a1n" || g.lastMove() != "a2a1n" {
+		t.Errorf("clicking the knight played %q", got)
+	}
+}
+
+func TestPromotionMenuKeys(t *testing.T) {
+	g := promotionGame(t, true)
+	g.handlePromotionKey(termbox.Event{Ch: 'b'}, io.Discard)
+	if got := g.lastMove(); got != "a2a1b" {
+		t.Errorf("'b' played %q, want a2a1b", got)
+	}
+
+	g = promotionGame(t, true)
+	g.handlePromotionKey(termbox.Event{Key: termbox.KeyEsc}, io.Discard)
+	if len(g.moveHistory) != 0 || g.inputMode != modeNormal || g.selectedX != -1 {
+		t.Errorf("Esc left %d moves, input mode %v, selection %d", len(g.moveHistory), g.inputMode, g.selectedX)
+	}
+
+	// A click off the menu cancels too.
+	g = promotionGame(t, false)
+	if got := g.clickPromotion(20, 20); got != "" || len(g.moveHistory) != 0 || g.inputMode != modeNormal {
+		t.Errorf("click off the menu played %q", got)
+	}
+}
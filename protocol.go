@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The wire protocol. A host or server first sends the joiner its color
+// ("white" or "black") and both sides exchange hellos (see handshake.go);
+// those two lines keep their bare form so that any build can read them and
+// report a version mismatch. Every line after that is one message: a kind
+// token, then for most kinds a space and a payload.
+//
+//	M e2e4           a move in wire format
+//	ACK              the last move was received and applied, sent only to
+//	                 a peer whose hello lists the ack capability
+//	C good luck      a chat line
+//	CTRL draw offer  a control message (see control.go)
+//	HB               a heartbeat, sent while idle to show the peer is alive
+//	FEN <fen>        the position a continued game started from
+//	H e2e4           a move already played in a continued game
+//	R white M e2e4   a message relayed to a spectator (see spectate.go)
+//
+// FEN and H are only sent by a host continuing an earlier game, right
+// after the hellos; the joiner accepts them until the first live move.
+// A server sends them to spectators the same way.
+//
+// A line whose kind is unknown is an error to the decoder and is skipped,
+// so only M messages ever reach the move parser.
+
+// msgKind is the type of a protocol message.
+type msgKind int
+
+const (
+	msgMove msgKind = iota
+	msgAck
+	msgChat
+	msgControl
+	msgHeartbeat
+	msgPosition
+	msgHistory
+	msgRelay
+)
+
+// msgTokens are the kind tokens that start each message on the wire.
+var msgTokens = [...]string{
+	msgMove:      "M",
+	msgAck:       "ACK",
+	msgChat:      "C",
+	msgControl:   "CTRL",
+	msgHeartbeat: "HB",
+	msgPosition:  "FEN",
+	msgHistory:   "H",
+	msgRelay:     "R",
+}
+
+// hasPayload reports whether messages of kind k carry text after the token.
+func (k msgKind) hasPayload() bool {
+	return k != msgAck && k != msgHeartbeat
+}
+
+// message is one decoded protocol line.
+type message struct {
+	kind msgKind
+	arg  string // The move, chat text, control verb, FEN or relayed message; empty for other kinds
+}
+
+// Errors for messages that cannot be acted on.
+var (
+	errUnknownMessage = errors.New("unknown message")
+	errGameStarted    = errors.New("the game has already started")
+)
+
+// encode returns m as it is sent, without the newline.
+func (m message) encode() string {
+	if !m.kind.hasPayload() {
+		return msgTokens[m.kind]
+	}
+	return msgTokens[m.kind] + " " + m.arg
+}
+
+// decodeMessage parses one line received from the peer.
+func decodeMessage(line string) (message, error) {
+	token, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	for kind, t := range msgTokens {
+		if token != t {
+			continue
+		}
+		m := message{kind: msgKind(kind), arg: arg}
+		if m.kind.hasPayload() != (arg != "") {
+			return message{}, fmt.Errorf("%q: malformed %s message", line, token)
+		}
+		return m, nil
+	}
+	return message{}, fmt.Errorf("%q: %w", line, errUnknownMessage)
+}
+
+// sendMessage writes m to w as a single write, so messages sent from
+// different goroutines never interleave.
+func sendMessage(w io.Writer, m message) error {
+	_, err := io.WriteString(w, m.encode()+"\n")
+	return err
+}
+
+// dispatch acts on one message from the opponent. conn is where any reply,
+// such as the ack for a move to an opponent who asked for them, is sent.
+func (g *Game) dispatch(conn io.Writer, m message) {
+	switch m.kind {
+	case msgMove:
+		// The move is checked as the opponent's, so one sent for our
+		// side or while it is our turn is rejected like any illegal move
+		// and never flips the turn.
+		g.lock.Lock()
+		settingUp := g.settingUp
+		g.settingUp = false
+		g.lock.Unlock()
+		if err := g.ApplyAlgebraic(m.arg, opponent(g.playerColor)); err != nil {
+			g.lock.Lock()
+			g.settingUp = settingUp // Only a move that is played starts the game
+			if errors.Is(err, ErrNotYourTurn) || errors.Is(err, ErrWrongColor) {
+				g.message = tr(txtIgnoredOutOfTurn, err)
+			} else {
+				g.message = tr(txtIgnoredMove, err)
+			}
+			g.lock.Unlock()
+			return
+		}
+		if g.sendAcks {
+			sendMessage(conn, message{kind: msgAck})
+		}
+		g.firePremove(conn)
+	case msgAck:
+		g.confirmDelivery()
+	case msgChat:
+		g.lock.Lock()
+		g.message = tr(txtChatOpponent, printable(m.arg))
+		g.lock.Unlock()
+	case msgControl:
+		g.lock.Lock()
+		if err := g.applyControl(opponent(g.playerColor), m.arg); err != nil {
+			g.message = tr(txtIgnoredControl, err)
+		}
+		g.lock.Unlock()
+	case msgHeartbeat:
+		// Arriving at all is the point.
+	case msgPosition, msgHistory:
+		if err := g.applySetup(m); err != nil {
+			g.lock.Lock()
+			g.message = tr(txtIgnoredPosition, err)
+			g.lock.Unlock()
+		}
+	case msgRelay:
+		err := errUnknownMessage
+		if g.spectating {
+			err = g.spectate(m.arg)
+		}
+		if err != nil {
+			g.lock.Lock()
+			g.message = tr(txtIgnoredServer, err)
+			g.lock.Unlock()
+		}
+	}
+}
+
+// applySetup applies a FEN or H message from a host continuing an earlier
+// game.
+func (g *Game) applySetup(m message) error {
+	g.lock.Lock()
+	settingUp := g.settingUp
+	g.lock.Unlock()
+	if !settingUp {
+		return errGameStarted
+	}
+	if m.kind == msgHistory {
+		return g.ApplyAlgebraic(m.arg, g.currentPlayer)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	fresh := NewGame()
+	fresh.freestyle = g.freestyle
+	if err := fresh.loadFEN(m.arg); err != nil {
+		return err
+	}
+	g.copyPosition(fresh)
+	return nil
+}
+
+// sendSetup sends the position and moves of a continued game, so the
+// joiner carries on from the same place.
+func (g *Game) sendSetup(conn io.Writer) {
+	if g.startFEN != "" {
+		sendMessage(conn, message{kind: msgPosition, arg: g.startFEN})
+	}
+	for _, moveStr := range g.moveHistory {
+		sendMessage(conn, message{kind: msgHistory, arg: moveStr})
+	}
+}
+
+// printable drops control characters from text received from the peer.
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+}
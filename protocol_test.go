@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestOutOfTurnMovesIgnored(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []string // Sent by white; the last is out of turn
+	}{
+		{"two moves in a row", []string{"e2e4", "d2d4"}},
+		{"black's move", []string{"e7e5"}},
+		{"black's move after white's", []string{"e2e4", "e7e5"}},
+	}
+	for _, tt := range tests {
+		g := newTestGame(t, "")
+		g.playerColor = "black"
+		for _, move := range tt.moves {
+			g.dispatch(io.Discard, message{kind: msgMove, arg: move})
+		}
+		g.lock.Lock()
+		if want := len(tt.moves) - 1; len(g.moveHistory) != want {
+			t.Errorf("%s: %d moves played, want %d", tt.name, len(g.moveHistory), want)
+		}
+		if !strings.Contains(g.message, "out of turn") {
+			t.Errorf("%s: message %q does not say the move was out of turn", tt.name, g.message)
+		}
+		g.lock.Unlock()
+	}
+}
+
+func TestRejectedMoveKeepsSetup(t *testing.T) {
+	g := newTestGame(t, "")
+	// The joiner waits for the host's setup until the first move.
+	g.playerColor, g.settingUp = "black", true
+	g.dispatch(io.Discard, message{kind: msgMove, arg: "e7e5"}) // Black is ours
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if !g.settingUp || len(g.moveHistory) != 0 {
+		t.Errorf("after a rejected move settingUp is %v with %d moves played", g.settingUp, len(g.moveHistory))
+	}
+}
+
+func TestAckOnlyWhenAsked(t *testing.T) {
+	for _, asked := range []bool{false, true} {
+		g := newTestGame(t, "")
+		g.playerColor = "black"
+		g.sendAcks = asked
+		var sent strings.Builder
+		g.dispatch(&sent, message{kind: msgMove, arg: "e2e4"})
+		if acked := sent.String() == "ACK\n"; acked != asked {
+			t.Errorf("asked for acks %v: sent %q", asked, sent.String())
+		}
+	}
+}
+
+func TestHelloAsksForAcks(t *testing.T) {
+	tests := []struct {
+		hello string
+		ours  []string
+		acks  bool
+	}{
+		{helloMessage(gameCapabilities(false, "")), gameCapabilities(false, ""), false},
+		{helloMessage(append(gameCapabilities(false, ""), capabilityAck)), gameCapabilities(false, ""), true},
+		{helloMessage(gameCapabilities(false, "")), append(gameCapabilities(false, ""), capabilityAck), false},
+	}
+	for _, tt := range tests {
+		peer, err := checkHello(tt.hello, tt.ours)
+		if err != nil || peer.acks != tt.acks {
+			t.Errorf("checkHello(%q, %v) = %+v, %v; want acks %v", tt.hello, tt.ours, peer, err, tt.acks)
+		}
+	}
+}
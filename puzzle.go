@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// puzzle is a position to solve: the moves of the solution in wire format,
+// the player's and the opponent's replies in turn, starting with the side
+// to move in the position.
+type puzzle struct {
+	name     string
+	start    *Game    // The position, loaded and checked
+	solution []string // The moves, in wire format
+	san      []string // The same moves in SAN, for feedback
+}
+
+// bundledPuzzles are solved when puzzle is run without a file.
+const bundledPuzzles = `# name: FEN; solution in SAN
+Back rank mate: 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1; Rd8#
+Smothered mate: 6rk/6pp/8/6N1/8/8/6PP/6K1 w - - 0 1; Nf7#
+Queen sacrifice: r6k/6pp/7N/8/8/1Q6/6PP/6K1 w - - 0 1; Qg8+ Rxg8 Nf7#
+Fool's mate: rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2; Qh4#
+`
+
+// loadPuzzles reads puzzles from path, or the bundled set when path is
+// empty.
+func loadPuzzles(path string) ([]puzzle, error) {
+	if path == "" {
+		return readPuzzles(strings.NewReader(bundledPuzzles), "bundled puzzles")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readPuzzles(f, path)
+}
+
+// readPuzzles reads puzzles with one per row: a name, a colon, the FEN, a
+// semicolon and the solution in SAN, e.g.
+//
+//	Back rank mate: 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1; Rd8#
+//
+// Blank rows and rows starting with '#' are ignored. Every solution is
+// played through, so a mistyped one is reported with its row rather than
+// found mid-puzzle.
+func readPuzzles(r io.Reader, source string) ([]puzzle, error) {
+	var puzzles []puzzle
+	scanner := bufio.NewScanner(r)
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, rest, ok := strings.Cut(text, ":")
+		fen, moves, ok2 := strings.Cut(rest, ";")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("line %d: want a name, a colon, the FEN, a semicolon and the solution", row)
+		}
+		p := puzzle{name: strings.TrimSpace(name), start: NewGame()}
+		if err := p.start.loadFEN(strings.TrimSpace(fen)); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", row, p.name, err)
+		}
+		g := NewGame()
+		g.copyPosition(p.start)
+		for _, token := range strings.Fields(moves) {
+			if isMoveNumber(token) {
+				continue
+			}
+			moveStr, ok := g.parseSAN(token)
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q is not a legal move in %s", row, token, p.name)
+			}
+			g.ApplyAlgebraic(moveStr, g.currentPlayer)
+			p.solution = append(p.solution, moveStr)
+			p.san = append(p.san, g.sanHistory[len(g.sanHistory)-1])
+		}
+		if len(p.solution) == 0 {
+			return nil, fmt.Errorf("line %d: %s has no solution", row, p.name)
+		}
+		puzzles = append(puzzles, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(puzzles) == 0 {
+		return nil, fmt.Errorf("%s: no puzzles", source)
+	}
+	return puzzles, nil
+}
+
+// solver is the state of a puzzle session: the puzzles in the order they
+// are set and how the player is doing.
+type solver struct {
+	puzzles []puzzle
+	index   int    // Index into puzzles of the one being solved
+	color   string // The side the player moves, the side to move in the puzzle
+	ply     int    // Moves of the solution played so far
+	wrong   int    // Wrong moves in the current puzzle
+	solved  int    // Puzzles solved without a wrong move
+	done    int    // Puzzles finished
+}
+
+// runPuzzles sets puzzles on g, in a random order if shuffle is set. The
+// player finds the solution's moves for the side to move; the opponent's
+// replies are played for them. A wrong move is taken back with "try
+// again". 's' shows the next move of the solution, 'r' starts the puzzle
+// over, 'n' skips to the next one and Esc quits.
+func runPuzzles(g *Game, puzzles []puzzle, shuffle bool) {
+	s := &solver{puzzles: puzzles}
+	if shuffle {
+		rand.Shuffle(len(s.puzzles), func(i, j int) { s.puzzles[i], s.puzzles[j] = s.puzzles[j], s.puzzles[i] })
+	}
+	g.autosaveDir = "" // Puzzles are not games worth keeping
+	s.start(g)
+
+	for {
+		g.drawBoard()
+		switch ev := termbox.PollEvent(); ev.Type {
+		case termbox.EventInterrupt:
+			if shuttingDown.Load() {
+				return
+			}
+		case termbox.EventKey:
+			switch {
+			case ev.Key == termbox.KeyEsc:
+				return
+			case ev.Key == termbox.KeyEnter || ev.Key == termbox.KeySpace:
+				s.click(g)
+			case ev.Key == termbox.KeyArrowLeft || ev.Ch == 'h':
+				g.moveCursor(-1, 0)
+			case ev.Key == termbox.KeyArrowRight || ev.Ch == 'l':
+				g.moveCursor(1, 0)
+			case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+				g.moveCursor(0, -1)
+			case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+				g.moveCursor(0, 1)
+			case ev.Ch == 's' || ev.Ch == 'S':
+				if s.ply < len(s.current().solution) {
+					g.message = tr(txtSolutionMove, localSAN(s.current().san[s.ply]))
+				}
+			case ev.Ch == 'r' || ev.Ch == 'R':
+				s.start(g)
+			case ev.Ch == 'n' || ev.Ch == 'N':
+				s.next(g)
+			case ev.Ch == 'c' || ev.Ch == 'C':
+				g.cycleTheme()
+			case ev.Ch == 'f' || ev.Ch == 'F':
+				g.flipped = !g.flipped
+			case ev.Key == termbox.KeyCtrlL:
+				g.repaint = true
+			}
+		case termbox.EventMouse:
+			if g.wheelTheme(ev) {
+				break
+			}
+			g.cursorX, g.cursorY = g.screenToSquare(ev.MouseX, ev.MouseY)
+			if ev.Key == termbox.MouseLeft {
+				s.click(g)
+			}
+		case termbox.EventError:
+			panic(ev.Err)
+		}
+	}
+}
+
+// current is the puzzle being solved.
+func (s *solver) current() puzzle {
+	return s.puzzles[s.index]
+}
+
+// start sets up the current puzzle from its position, with the board
+// turned to the side the player moves.
+func (s *solver) start(g *Game) {
+	p := s.current()
+	g.copyPosition(p.start)
+	g.selectedX, g.selectedY = -1, -1
+	g.legalMoves = make(map[string]moveKind)
+	g.inputMode = modeNormal
+	s.color = p.start.currentPlayer
+	g.flipped = s.color == "black"
+	s.ply, s.wrong = 0, 0
+	g.message = tr(txtPuzzleStart, s.index+1, len(s.puzzles), p.name, colorName(s.color))
+}
+
+// click handles a click, or Enter, on the cursor's square: selecting a
+// piece, or playing a move and checking it against the solution.
+func (s *solver) click(g *Game) {
+	p := s.current()
+	if s.ply >= len(p.solution) {
+		g.message = tr(txtPuzzleDone)
+		return
+	}
+	before := &Game{}
+	before.copyPosition(g)
+	moveStr := g.handleMouseClick(s.color)
+	if moveStr == "" {
+		return
+	}
+	g.inputMode = modeNormal // A mate ends the game, but the session goes on
+	// The last move need only match in effect: any mate solves a mate.
+	last := s.ply == len(p.solution)-1
+	mates := g.gameOver && g.termination == "checkmate"
+	if moveStr != p.solution[s.ply] && !(last && mates) {
+		played := g.sanHistory[len(g.sanHistory)-1]
+		g.copyPosition(before)
+		s.wrong++
+		g.message = tr(txtNotTheSolution, localSAN(played))
+		return
+	}
+	s.ply++
+	feedback := tr(txtCorrect) + " "
+	if s.ply < len(p.solution) {
+		g.ApplyAlgebraic(p.solution[s.ply], g.currentPlayer)
+		feedback += tr(txtReplies, colorName(opponent(s.color)), localSAN(p.san[s.ply])) + " "
+		s.ply++
+	}
+	if s.ply < len(p.solution) {
+		g.message = feedback + tr(txtYourMove)
+		return
+	}
+	s.done++
+	if s.wrong == 0 {
+		s.solved++
+	}
+	g.message = feedback + tr(txtPuzzleSolved, p.name, s.solved, s.done)
+}
+
+// next moves on to the next puzzle, going round to the first after the
+// last.
+func (s *solver) next(g *Game) {
+	s.index = (s.index + 1) % len(s.puzzles)
+	s.start(g)
+}
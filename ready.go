@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// With a ready check the game waits for both players to confirm they are
+// present before the first move may be played, and before the idle timer
+// starts. The host or server turns it on by sending "ready check" before
+// the game starts; each player then sends "ready", with 'g', once they are
+// set to play. The joiner's own -ready-check flag does not matter.
+const (
+	ctrlReadyCheck = "ready check"
+	ctrlReady      = "ready"
+)
+
+// Errors returned while a ready check is pending.
+var (
+	ErrNotReady      = errors.New("both players must be ready first")
+	errNoReadyCheck  = errors.New("no ready check is pending")
+	errAlreadyReady  = errors.New("already ready")
+	errNotReadyCheck = errors.New("only the host or server starts a ready check, before the game")
+)
+
+// readyToPlay reports whether moves may be played: there is no ready check,
+// or both players have confirmed. The caller holds g.lock.
+func (g *Game) readyToPlay() bool {
+	return !g.readyCheck || (g.ready["white"] && g.ready["black"])
+}
+
+// startReadyCheck makes the game wait for both players, as the host or
+// server does before the first move. The caller holds g.lock.
+func (g *Game) startReadyCheck() {
+	g.readyCheck = true
+	g.ready = make(map[string]bool)
+	if g.playerColor != "" {
+		g.message = tr(txtReadyToStart)
+	}
+}
+
+// applyReady acts on "ready check" and "ready" control verbs. The caller
+// holds g.lock.
+func (g *Game) applyReady(from, verb string) error {
+	if verb == ctrlReadyCheck {
+		if g.hosting || !g.settingUp {
+			return fmt.Errorf("%q: %w", verb, errNotReadyCheck)
+		}
+		g.startReadyCheck()
+		return nil
+	}
+	// Spectators never see the ready check itself, only the players
+	// confirming it.
+	switch {
+	case g.spectating:
+		if g.ready == nil {
+			g.ready = make(map[string]bool)
+		}
+	case !g.readyCheck || g.readyToPlay():
+		return fmt.Errorf("%q: %w", verb, errNoReadyCheck)
+	case g.ready[from]:
+		return fmt.Errorf("%q: %w", verb, errAlreadyReady)
+	}
+	g.ready[from] = true
+	if g.readyToPlay() {
+		g.message = tr(txtReadyBoth, colorName(g.currentPlayer))
+		g.armIdleTimer()
+		return nil
+	}
+	g.message = g.byWhom(from, tr(txtReadyYou), tr(txtReadyOpponent))
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// eventKind tells apart the entries of a game's event log.
+type eventKind int
+
+const (
+	eventMove    eventKind = iota // A move played on the board
+	eventControl                  // A control message acted on, e.g. "draw offer"
+)
+
+// gameEvent is one entry of a game's event log. The log is only ever
+// appended to, so a move that was taken back stays in it, followed by the
+// "takeback accept" that undid it; playedMoves works out the moves that
+// stand.
+type gameEvent struct {
+	kind      eventKind
+	at        time.Time
+	color     string        // The side that moved or sent the control message, or "server"
+	whiteLeft time.Duration // Time left on white's clock after the event, zero without a clock
+	blackLeft time.Duration // Time left on black's clock after the event, zero without a clock
+
+	// Moves only.
+	move     string // The move in wire format, e.g. "e7e8"
+	san      string // The same move in SAN, with its check suffix
+	piece    string // The kind of piece that moved, e.g. "pawn"
+	captured string // The kind of piece taken, or empty
+	check    bool   // The move gives check, mate included
+	mate     bool
+	balance  int // The material balance after the move, in centipawns from white's side (see Evaluate)
+
+	// Control messages only.
+	control string // The verb, e.g. "draw offer"
+}
+
+// logEvent stamps ev with the time and the clocks and appends it to the
+// event log. The caller holds g.lock.
+func (g *Game) logEvent(ev gameEvent) {
+	ev.at = time.Now()
+	if g.clock != nil {
+		ev.whiteLeft, ev.blackLeft = g.clock.Remaining("white"), g.clock.Remaining("black")
+	}
+	g.events = append(g.events, ev)
+}
+
+// logControl logs a control verb the game has acted on. The spectator
+// count and the players' views of the board say nothing about the game,
+// so they are left out. The caller holds g.lock.
+func (g *Game) logControl(from, verb string) {
+	switch offer, _, _ := strings.Cut(verb, " "); offer {
+	case ctrlSpectators, ctrlView:
+		return
+	}
+	g.logEvent(gameEvent{kind: eventControl, color: from, control: verb})
+}
+
+// playedMoves returns the logged moves that still stand, in the order they
+// were played: every move less those a takeback undid.
+func (g *Game) playedMoves() []gameEvent {
+	var moves []gameEvent
+	for _, ev := range g.events {
+		switch {
+		case ev.kind == eventMove:
+			moves = append(moves, ev)
+		case ev.control == ctrlTakeback+" "+ctrlAccept && len(moves) > 0:
+			moves = moves[:len(moves)-1]
+		}
+	}
+	return moves
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Bounds for the replay speed; '+' and '-' halve or double the interval.
+const (
+	minReplayInterval = 125 * time.Millisecond
+	maxReplayInterval = 8 * time.Second
+)
+
+// replayFrame is one position of a replayed game.
+type replayFrame struct {
+	board   [8][8]*Piece
+	move    string // Move that led to this position, empty for the start
+	san     string // The same move in SAN with its number, e.g. "12... Qg6"
+	message string // Status after the move, e.g. whose turn it is or the result
+}
+
+// playback is the state of the replay viewer: which frame is on screen,
+// whether auto-advance is paused and how long each frame is shown.
+type playback struct {
+	frame    int
+	paused   bool
+	interval time.Duration
+}
+
+// loadReplay reads a game from a file of moves, one per line, and
+// reconstructs every position. A game that did not end on the board may
+// finish with a line such as "result 0-1 abandoned", which is shown on the
+// last frame. Blank lines and lines starting with '#' are ignored. Files
+// ending in .pgn are read as PGN instead.
+func loadReplay(path string) ([]replayFrame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".pgn") {
+		frames, _, err := pgnReplay(f)
+		return frames, err
+	}
+
+	g := NewGame()
+	frames := []replayFrame{{board: g.board, message: tr(txtReplayStart)}}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		moveStr := strings.TrimSpace(scanner.Text())
+		if moveStr == "" || strings.HasPrefix(moveStr, "#") {
+			continue
+		}
+		if fields := strings.Fields(moveStr); fields[0] == "result" {
+			if len(fields) < 2 || g.gameOver {
+				return nil, fmt.Errorf("line %d: unexpected result %q", line, moveStr)
+			}
+			termination := strings.Join(fields[2:], " ")
+			g.endGame(fields[1], termination, tr(txtReplayOver, strings.Join(fields[1:], " ")))
+			frames[len(frames)-1].message = g.message
+			continue
+		}
+		if err := g.ApplyAlgebraic(moveStr, g.currentPlayer); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		frames = append(frames, replayFrame{board: g.board, move: moveStr, san: numberedSAN(g), message: g.message})
+	}
+	return frames, scanner.Err()
+}
+
+// pgnReplay reconstructs every position of the first game in a PGN file
+// and returns them with the file's tags. A game that did not end on the
+// board shows its result and Termination tag on the last frame.
+func pgnReplay(r io.Reader) ([]replayFrame, map[string]string, error) {
+	var frames []replayFrame
+	g, tags, result, err := loadPGN(r, func(g *Game, moveStr string) {
+		if frames == nil {
+			frames = []replayFrame{{board: g.board, message: tr(txtReplayStart)}}
+		}
+		if moveStr != "" {
+			frames = append(frames, replayFrame{board: g.board, move: moveStr, san: numberedSAN(g), message: g.message})
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if result != "" && result != resultOngoing && !g.gameOver {
+		termination := strings.ToLower(tags["Termination"])
+		g.endGame(result, termination, tr(txtReplayOver, strings.TrimSpace(result+" "+termination)))
+		frames[len(frames)-1].message = g.message
+	}
+	return frames, tags, nil
+}
+
+// numberedSAN is the move just played on g in SAN, numbered as in a move
+// list.
+func numberedSAN(g *Game) string {
+	san := g.sanHistory[len(g.sanHistory)-1]
+	if g.currentPlayer == "black" {
+		return fmt.Sprintf("%d. %s", g.fullmoveNumber, san)
+	}
+	return fmt.Sprintf("%d... %s", g.fullmoveNumber-1, san)
+}
+
+// runReplay shows the frames on g until Esc is pressed. Frames advance on a timer
+// while playing; space pauses and resumes, '+'/'-' change the speed and the
+// arrow keys step through the game while paused.
+func runReplay(g *Game, frames []replayFrame) {
+	pb := playback{interval: time.Second}
+
+	ticker := time.NewTicker(pb.interval)
+	defer ticker.Stop()
+	go func() {
+		for range ticker.C {
+			termbox.Interrupt()
+		}
+	}()
+
+	for {
+		frame := frames[pb.frame]
+		g.board = frame.board
+		g.message = pb.status(frames) + frame.message
+		g.drawBoard()
+
+		switch ev := termbox.PollEvent(); ev.Type {
+		case termbox.EventInterrupt:
+			if shuttingDown.Load() {
+				return
+			}
+			if !pb.paused && pb.frame < len(frames)-1 {
+				pb.frame++
+			}
+		case termbox.EventKey:
+			switch {
+			case ev.Key == termbox.KeyEsc:
+				return
+			case ev.Key == termbox.KeySpace:
+				pb.paused = !pb.paused
+			case ev.Ch == '+' || ev.Ch == '=':
+				pb.interval = max(pb.interval/2, minReplayInterval)
+				ticker.Reset(pb.interval)
+			case ev.Ch == '-':
+				pb.interval = min(pb.interval*2, maxReplayInterval)
+				ticker.Reset(pb.interval)
+			case ev.Key == termbox.KeyArrowRight && pb.paused:
+				pb.frame = min(pb.frame+1, len(frames)-1)
+			case ev.Key == termbox.KeyArrowLeft && pb.paused:
+				pb.frame = max(pb.frame-1, 0)
+			case ev.Ch == 'c' || ev.Ch == 'C':
+				g.cycleTheme()
+			case ev.Ch == 'f' || ev.Ch == 'F':
+				g.flipped = !g.flipped
+			case ev.Key == termbox.KeyCtrlL:
+				g.repaint = true
+			}
+		case termbox.EventError:
+			panic(ev.Err)
+		}
+	}
+}
+
+// status describes the playback position and speed for the message bar.
+func (pb *playback) status(frames []replayFrame) string {
+	state := tr(txtReplayPlaying, pb.interval)
+	if pb.paused {
+		state = tr(txtReplayPaused)
+	}
+	status := state + " | " + tr(txtReplayMove, pb.frame, len(frames)-1)
+	if move := frames[pb.frame].move; move != "" {
+		status += " " + move
+	}
+	return status + " | "
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Piece letters used in SAN, by piece kind. Pawns have none.
+var sanLetters = map[string]string{
+	"king":   "K",
+	"queen":  "Q",
+	"rook":   "R",
+	"bishop": "B",
+	"knight": "N",
+}
+
+// san returns the move from (fromY, fromX) to (toY, toX) in Standard
+// Algebraic Notation, without the check suffix. A pawn reaching the last
+// rank promotes to promotion, or a queen for noPromotion. It must be called
+// before the move is applied, since disambiguation depends on the position.
+func (g *Game) san(fromY, fromX, toY, toX int, promotion string) string {
+	piece := g.board[fromY][fromX]
+	kind := pieceKind(piece)
+	capture := g.board[toY][toX] != nil
+	dest := squareName(toX, toY)
+
+	switch {
+	case g.freestyle:
+		// Castling and promotion don't exist, so every move is written
+		// the long way.
+		if capture {
+			return sanLetters[kind] + squareName(fromX, fromY) + "x" + dest
+		}
+		return sanLetters[kind] + squareName(fromX, fromY) + dest
+	case kind == "king" && toX-fromX == 2:
+		return "O-O"
+	case kind == "king" && fromX-toX == 2:
+		return "O-O-O"
+	case kind == "pawn":
+		move := dest
+		if fromX != toX {
+			move = squareName(fromX, fromY)[:1] + "x" + dest
+		}
+		if toY == 0 || toY == 7 {
+			if promotion == noPromotion {
+				promotion = "queen"
+			}
+			move += "=" + sanLetters[promotion]
+		}
+		return move
+	}
+
+	// Name the origin file, rank or both if another piece of the same kind
+	// could also reach the destination.
+	var sameFile, sameRank, ambiguous bool
+	key := squareKey(toX, toY)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			other := g.board[y][x]
+			if (y == fromY && x == fromX) || other == nil || other.symbol != piece.symbol {
+				continue
+			}
+			if g.movesFrom(y, x)[key] != moveNone {
+				ambiguous = true
+				sameFile = sameFile || x == fromX
+				sameRank = sameRank || y == fromY
+			}
+		}
+	}
+	from := squareName(fromX, fromY)
+	disambiguation := ""
+	switch {
+	case !ambiguous:
+	case !sameFile:
+		disambiguation = from[:1]
+	case !sameRank:
+		disambiguation = from[1:]
+	default:
+		disambiguation = from
+	}
+
+	move := sanLetters[kind] + disambiguation
+	if capture {
+		move += "x"
+	}
+	return move + dest
+}
+
+// parseSAN finds the legal move written as san in the current position and
+// returns it in wire format. Check and annotation marks are ignored, and
+// castling may be written with zeros.
+func (g *Game) parseSAN(san string) (string, bool) {
+	san = strings.TrimRight(san, "+#!?")
+	san = strings.ReplaceAll(san, "0", "O")
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece == nil || piece.color != g.currentPlayer {
+				continue
+			}
+			moves := g.movesFrom(y, x)
+			for ty := 0; ty < 8; ty++ {
+				for tx := 0; tx < 8; tx++ {
+					if moves[squareKey(tx, ty)] == moveNone {
+						continue
+					}
+					for _, promotion := range g.promotionsOf(y, x, ty) {
+						if g.san(y, x, ty, tx, promotion) == san {
+							return formatMove(y, x, ty, tx) + promotionLetter(promotion), true
+						}
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// parseLocalSAN is parseSAN for a move typed with the piece letters of the
+// language in use, or failing that with the English ones, which differ in
+// some languages: "R" is the king in Spanish.
+func (g *Game) parseLocalSAN(san string) (string, bool) {
+	if moveStr, ok := g.parseSAN(englishSAN(san)); ok {
+		return moveStr, true
+	}
+	return g.parseSAN(san)
+}
+
+// checkSuffix returns the SAN suffix for the position after a move: "#" for
+// checkmate, "+" for check, or nothing.
+func (g *Game) checkSuffix() string {
+	switch {
+	case g.IsCheckmate(g.currentPlayer):
+		return "#"
+	case g.InCheck(g.currentPlayer):
+		return "+"
+	}
+	return ""
+}
+
+// startPly is the number of half-moves played before the game's first
+// move, counting from white's first move in a standard game: 0 from the
+// standard position, 23 from a start FEN with black to play move 12.
+func (g *Game) startPly() int {
+	number, ply := 1, 0
+	if fields := strings.Fields(g.startFEN); len(fields) > 1 {
+		if len(fields) > 5 {
+			if n, err := strconv.Atoi(fields[5]); err == nil && n > 0 {
+				number = n
+			}
+		}
+		if fields[1] == "b" {
+			ply = 1
+		}
+	}
+	return (number-1)*2 + ply
+}
+
+// moveList returns the game's moves in SAN with move numbers, followed by the
+// result, e.g. "1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0". A game from a
+// start FEN is numbered on from its fullmove number, with "12..." before
+// the first move if black moved first.
+func (g *Game) moveList() string {
+	ply := g.startPly()
+	var sb strings.Builder
+	for i, ev := range g.playedMoves() {
+		switch {
+		case ply%2 == 0:
+			fmt.Fprintf(&sb, "%d. ", ply/2+1)
+		case i == 0:
+			fmt.Fprintf(&sb, "%d... ", ply/2+1)
+		}
+		sb.WriteString(ev.san + " ")
+		ply++
+	}
+	sb.WriteString(g.result)
+	return sb.String()
+}
+
+// clipboardSupported guesses whether the terminal understands OSC 52
+// clipboard writes. Most modern emulators do; the Linux console and dumb
+// terminals don't.
+func clipboardSupported() bool {
+	term := os.Getenv("TERM")
+	if term == "" || term == "dumb" || term == "linux" {
+		return false
+	}
+	for _, name := range []string{"xterm", "kitty", "alacritty", "foot", "wezterm", "tmux", "screen", "rxvt", "ghostty"} {
+		if strings.Contains(term, name) {
+			return true
+		}
+	}
+	return os.Getenv("TERM_PROGRAM") != ""
+}
+
+// copyMoveList puts the move list on the system clipboard with an OSC 52
+// escape sequence, if the terminal is likely to support it.
+func (g *Game) copyMoveList() {
+	if !clipboardSupported() {
+		g.message = tr(txtClipboardUnsupported)
+		return
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(g.moveList()))
+	fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", encoded)
+	g.message = tr(txtMoveListCopied)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Geometry of an SVG screenshot, in SVG user units: the board's squares
+// and the margin around it that holds the coordinates.
+const (
+	svgSquare = 45
+	svgMargin = 20
+)
+
+// Colors used in a screenshot where the theme leaves a color to the
+// terminal, as the Terminal theme does.
+const (
+	svgDefaultLight = "#eeeeee"
+	svgDefaultDark  = "#bbbbbb"
+	svgDefaultText  = "#000000"
+)
+
+// screenshot asks where to save a picture of the board and saves it. The
+// file's extension picks the format: .svg for a vector diagram in the
+// theme's colors, .txt for a text diagram in the piece glyphs.
+func (g *Game) screenshot() {
+	g.startTextEntry(tr(txtScreenshotPrompt), func(text string) {
+		path, err := g.saveScreenshot(text)
+		if err != nil {
+			g.message = tr(txtSaveFailed, err)
+			return
+		}
+		g.message = tr(txtSaved, path)
+	})
+}
+
+// saveScreenshot writes the board to path, or to a new timestamped SVG
+// file where saveGame puts games when path is empty, and returns the path
+// written.
+func (g *Game) saveScreenshot(path string) (string, error) {
+	if path == "" {
+		dir := g.autosaveDir
+		if dir == "" {
+			dir = "."
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, "board-"+time.Now().Format("20060102-150405")+".svg")
+	}
+	var data string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		data = g.boardSVG(themes[g.currentThemeIndex])
+	case ".txt":
+		data = g.boardText()
+	default:
+		return "", fmt.Errorf("%s: unknown format, want .svg or .txt", path)
+	}
+	return path, os.WriteFile(path, []byte(data), 0o644)
+}
+
+// screenSquare returns the board square shown in row and column (row, col)
+// of a picture of the board, following the board's flip.
+func (g *Game) screenSquare(row, col int) (x, y int) {
+	if g.flipped {
+		return 7 - col, 7 - row
+	}
+	return col, row
+}
+
+// boardText draws the board as text, one rank per line with its number
+// and the files lettered underneath, e.g. "8 r n b q k b n r".
+func (g *Game) boardText() string {
+	var sb strings.Builder
+	files := make([]string, 8)
+	for row := 0; row < 8; row++ {
+		_, y := g.screenSquare(row, 0)
+		fmt.Fprintf(&sb, "%d", 8-y)
+		for col := 0; col < 8; col++ {
+			x, y := g.screenSquare(row, col)
+			files[col] = string(rune('a' + x))
+			symbol := '.'
+			if piece := g.board[y][x]; piece != nil {
+				symbol = g.glyph(piece)
+			}
+			fmt.Fprintf(&sb, " %c", symbol)
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("  " + strings.Join(files, " ") + "\n")
+	return sb.String()
+}
+
+// boardSVG draws the board as an SVG diagram in theme's colors, with the
+// highlights shown on screen and coordinates around the edge. Pieces are
+// drawn as the filled Unicode glyphs in their side's color, outlined in
+// the other side's, so both show on any square.
+func (g *Game) boardSVG(theme Theme) string {
+	size := 8*svgSquare + 2*svgMargin
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", size, size, size, size)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="%s"/>`+"\n", size, size, xtermHex(theme.BackgroundBg, "#ffffff"))
+
+	h := g.highlights()
+	white := xtermHex(theme.WhitePieceFg, "#ffffff")
+	black := xtermHex(theme.BlackPieceFg, svgDefaultText)
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			x, y := g.screenSquare(row, col)
+			fallback := svgDefaultLight
+			if (x+y)%2 == 0 {
+				fallback = svgDefaultDark
+			}
+			left, top := svgMargin+col*svgSquare, svgMargin+row*svgSquare
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+				left, top, svgSquare, svgSquare, xtermHex(h.squareBg(theme, x, y), fallback))
+			piece := g.board[y][x]
+			if piece == nil {
+				continue
+			}
+			fill, outline := white, black
+			if piece.color == "black" {
+				fill, outline = black, white
+			}
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central" fill="%s" stroke="%s" stroke-width="1" paint-order="stroke">%s</text>`+"\n",
+				left+svgSquare/2, top+svgSquare/2, svgSquare*4/5, fill, outline, html.EscapeString(string(filledSymbol(piece))))
+		}
+	}
+
+	// Coordinates: files below the board, ranks to its left.
+	coords := xtermHex(theme.MessageFg, svgDefaultText)
+	for i := 0; i < 8; i++ {
+		x, y := g.screenSquare(i, i)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="14" text-anchor="middle" dominant-baseline="central" fill="%s">%c</text>`+"\n",
+			svgMargin+i*svgSquare+svgSquare/2, size-svgMargin/2, coords, 'a'+x)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="14" text-anchor="middle" dominant-baseline="central" fill="%s">%d</text>`+"\n",
+			svgMargin/2, svgMargin+i*svgSquare+svgSquare/2, coords, 8-y)
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// filledSymbol is the solid Unicode glyph for piece's kind, which both
+// sides share in an SVG diagram and tell apart by color.
+func filledSymbol(piece *Piece) rune {
+	if piece.symbol >= '♔' && piece.symbol <= '♙' {
+		return piece.symbol + ('♚' - '♔')
+	}
+	return piece.symbol
+}
+
+// xtermHex converts a termbox color, as used by the themes in 256-color
+// output, to an SVG hex color. ColorDefault, which is up to the terminal,
+// becomes fallback.
+func xtermHex(attr termbox.Attribute, fallback string) string {
+	if attr == termbox.ColorDefault {
+		return fallback
+	}
+	// In 256-color output, termbox draws attribute n as xterm color n-1.
+	n := int(attr&0x1ff) - 1
+	switch {
+	case n < 16:
+		basic := [16]string{
+			"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+			"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+		}
+		return basic[n]
+	case n < 232:
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + 40*v
+		}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		gray := 8 + 10*(n-232)
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// serverOptions are the rules a server applies to every game it runs.
+type serverOptions struct {
+	strict             bool          // Check the rules engine after every move
+	idleTimeout        time.Duration // Adjudicate against a side that takes longer to move; zero disables
+	idleDraw           bool          // Adjudicate idle games as draws rather than losses
+	readyCheck         bool          // Wait for both players to confirm they are ready before the first move
+	clock              timeControl   // The players' time control; a zero base plays without clocks
+	maxMoves           int           // Full moves after which an unfinished game is adjudicated; zero never
+	maxMovesByMaterial bool          // Adjudicate at maxMoves by material rather than as a draw
+	freestyle          bool          // Play freestyle rules (see Game.freestyle)
+	variant            string        // Name of the variant to play (see variants); empty plays standard chess
+	watchAddr          string        // Address spectators connect to; empty accepts none
+}
+
+// serve runs a headless game server. Joiners are paired in arrival order
+// (first two play game 1, the next two game 2, and so on) and every pair
+// plays in its own Game on its own goroutines. Each game's lifecycle is
+// recorded to log, which may be nil. With opts.watchAddr set, spectators
+// can watch any running game (see spectate.go).
+func serve(addr string, log *gameLog, opts serverOptions) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	games := newMatchList()
+	if opts.watchAddr != "" {
+		watchLn, err := net.Listen("tcp", opts.watchAddr)
+		if err != nil {
+			return err
+		}
+		defer watchLn.Close()
+		go serveSpectators(watchLn, games, opts)
+		fmt.Printf("Spectators can watch on %s.\n", opts.watchAddr)
+	}
+	fmt.Printf("Serving games on %s. Waiting for players...\n", addr)
+
+	var waiting net.Conn
+	for match := 1; ; {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		if waiting == nil {
+			waiting = conn
+			continue
+		}
+		fmt.Printf("Game %d: %s (white) vs %s (black)\n", match, waiting.RemoteAddr(), conn.RemoteAddr())
+		go runMatch(match, waiting, conn, log, opts, games)
+		waiting = nil
+		match++
+	}
+}
+
+// runMatch assigns colors to a pair of players and relays messages between
+// them. Each move and control message is validated against the match's own
+// Game before it is forwarded, so a misbehaving client cannot desync its
+// opponent, and is then relayed to the spectators listed in games.
+func runMatch(id int, white, black net.Conn, log *gameLog, opts serverOptions, games *matchList) {
+	defer white.Close()
+	defer black.Close()
+	fmt.Fprintln(white, "white")
+	fmt.Fprintln(black, "black")
+	// The server lists ack so both players send their ACKs, and relays
+	// each one only to a player who asked for it.
+	capabilities := append(gameCapabilities(opts.freestyle, opts.variant), capabilityAck)
+	wantsAcks := make(map[net.Conn]bool)
+	for _, conn := range []net.Conn{white, black} {
+		peer, err := handshake(conn, capabilities)
+		if err == nil {
+			_, err = settleVariant(opts.variant, peer.variant, true)
+		}
+		if err != nil {
+			fmt.Printf("Game %d: %s refused: %v\n", id, conn.RemoteAddr(), err)
+			log.record(logEntry{Game: id, Event: "refused", Reason: fmt.Sprintf("%s: %v", conn.RemoteAddr(), err)})
+			return
+		}
+		wantsAcks[conn] = peer.acks
+	}
+	log.record(logEntry{Game: id, Event: "start", White: white.RemoteAddr().String(), Black: black.RemoteAddr().String()})
+
+	g := NewGame()
+	g.headless = true
+	g.hosting = true
+	g.strict = opts.strict
+	g.freestyle = opts.freestyle
+	g.rules = variants[opts.variant]
+	g.idleTimeout = opts.idleTimeout
+	g.idleDraw = opts.idleDraw
+	mt := &match{id: id, g: g, players: []net.Conn{white, black}, spectators: make(map[chan message]bool)}
+	games.add(mt)
+	defer games.remove(id)
+	g.onAdjudicate = func(verb string) {
+		log.record(logEntry{Game: id, Event: "control", Control: verb})
+		m := message{kind: msgControl, arg: verb}
+		for _, conn := range []net.Conn{white, black} {
+			sendMessage(conn, m)
+		}
+		mt.mu.Lock()
+		mt.broadcast("server", m)
+		mt.mu.Unlock()
+	}
+	g.lock.Lock()
+	g.maxMoves, g.maxMovesByMaterial = opts.maxMoves, opts.maxMovesByMaterial
+	for _, conn := range []net.Conn{white, black} {
+		sendMessage(conn, message{kind: msgControl, arg: g.moveLimitVerb()})
+	}
+	if opts.clock.base > 0 {
+		g.startClock(opts.clock)
+		for _, conn := range []net.Conn{white, black} {
+			sendMessage(conn, message{kind: msgControl, arg: ctrlClock + " " + opts.clock.String()})
+		}
+	}
+	if opts.readyCheck {
+		g.startReadyCheck()
+		for _, conn := range []net.Conn{white, black} {
+			sendMessage(conn, message{kind: msgControl, arg: ctrlReadyCheck})
+		}
+	}
+	g.armIdleTimer()
+	g.lock.Unlock()
+	done := make(chan string, 2) // Receives the color of each player who disconnects
+
+	relay := func(from, to net.Conn, color string) {
+		defer func() { done <- color }()
+		reader := newLineReader(from)
+		for {
+			line, err := reader.readLine()
+			if err == errLineTooLong {
+				fmt.Printf("Game %d: discarded an oversized message from %s\n", id, color)
+				log.record(logEntry{Game: id, Event: "rejected", Color: color, Reason: err.Error()})
+				continue
+			}
+			if err != nil {
+				return
+			}
+			m, err := decodeMessage(line)
+			if err != nil {
+				fmt.Printf("Game %d: discarded a message from %s: %v\n", id, color, err)
+				log.record(logEntry{Game: id, Event: "rejected", Color: color, Reason: err.Error()})
+				continue
+			}
+
+			switch m.kind {
+			case msgMove:
+				mt.mu.Lock()
+				err = g.ApplyAlgebraic(m.arg, color)
+				entry := logEntry{Game: id, Event: "move", Color: color, Move: m.arg}
+				if err == nil {
+					entry.SAN = g.sanHistory[len(g.sanHistory)-1]
+					mt.broadcast(color, m)
+				}
+				mt.mu.Unlock()
+				log.record(rejectedIf(entry, err))
+			case msgControl:
+				mt.mu.Lock()
+				g.lock.Lock()
+				err = g.applyControl(color, m.arg)
+				g.lock.Unlock()
+				if err == nil {
+					mt.broadcast(color, m)
+				}
+				mt.mu.Unlock()
+				log.record(rejectedIf(logEntry{Game: id, Event: "control", Color: color, Control: m.arg}, err))
+			case msgPosition, msgHistory:
+				// Served games always start from the standard position.
+				err = errGameStarted
+				log.record(rejectedIf(logEntry{Game: id, Color: color}, err))
+			case msgRelay:
+				// Only the server relays.
+				err = errUnknownMessage
+				log.record(rejectedIf(logEntry{Game: id, Color: color}, err))
+			case msgAck:
+				if !wantsAcks[to] {
+					continue
+				}
+			}
+			if err != nil {
+				fmt.Printf("Game %d: rejected %q from %s: %v\n", id, line, color, err)
+				continue
+			}
+			sendMessage(to, m)
+		}
+	}
+	go relay(white, black, "white")
+	go relay(black, white, "black")
+
+	// Once either side drops, the deferred closes end the other relay too.
+	// Leaving an unfinished game forfeits it, or aborts it while abortable.
+	left := <-done
+	mt.mu.Lock()
+	mt.finish()
+	switch {
+	case g.gameOver:
+	case g.abortable():
+		g.endGame(resultOngoing, "aborted", "")
+	default:
+		g.endGame(winResult(opponent(left)), "abandoned", "")
+	}
+	log.record(logEntry{Game: id, Event: "end", Result: g.result, Termination: g.termination})
+	mt.mu.Unlock()
+	fmt.Printf("Game %d finished: %s (%s).\n", id, g.result, g.termination)
+}
+
+// rejectedIf turns entry into a "rejected" entry if err is set.
+func rejectedIf(entry logEntry, err error) logEntry {
+	if err != nil {
+		entry.Event = "rejected"
+		entry.Reason = err.Error()
+	}
+	return entry
+}
+
+// readColor reads the color assignment a host or server sends on connect.
+func readColor(conn net.Conn) (string, error) {
+	color, err := readLine(conn)
+	if err != nil {
+		return "", err
+	}
+	if color != "white" && color != "black" {
+		return "", fmt.Errorf("unexpected greeting %q", color)
+	}
+	return color, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/nsf/termbox-go"
+)
+
+// shutdownSignals end the program cleanly while termbox has the terminal:
+// Ctrl-C in a terminal that still sends it as a signal, kill, and the
+// terminal going away.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// shuttingDown is set once a shutdown signal has arrived. Every event loop
+// checks it when PollEvent is interrupted and returns, so the deferred
+// termbox.Close puts the terminal back.
+var shuttingDown atomic.Bool
+
+// watchSignals routes shutdown signals to the event loops: a signal sets
+// shuttingDown and interrupts PollEvent. It is called once termbox is
+// running.
+func watchSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, shutdownSignals...)
+	go func() {
+		for range signals {
+			shuttingDown.Store(true)
+			termbox.Interrupt()
+		}
+	}()
+}
+
+// leave tells the opponent this player is going, as quitting does but
+// without asking: an unfinished game is aborted or resigned. It is how a
+// signal or Ctrl-C ends a networked game.
+func (g *Game) leave(conn io.Writer) {
+	g.lock.Lock()
+	over := g.gameOver
+	g.lock.Unlock()
+	if !over && !g.spectating && !g.analysis {
+		g.sendControl(conn, g.quitVerb())
+	}
+	g.quit = true
+}
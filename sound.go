@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// soundCue names an event that can make a sound.
+type soundCue string
+
+const (
+	cueMove      soundCue = "move"
+	cueCapture   soundCue = "capture"
+	cueCastle    soundCue = "castle"
+	cuePromote   soundCue = "promote"
+	cueCheck     soundCue = "check"
+	cueCheckmate soundCue = "checkmate"
+	cueStalemate soundCue = "stalemate"
+	cueGameOver  soundCue = "gameover" // Any other end of the game
+)
+
+// moveCue is the cue for a move of kind that leaves the game going
+// without check. A promotion outranks whatever else the move did.
+func moveCue(kind moveKind, promoted bool) soundCue {
+	switch {
+	case promoted:
+		return cuePromote
+	case kind == moveCastle:
+		return cueCastle
+	case kind == moveCapture || kind == moveEnPassant:
+		return cueCapture
+	}
+	return cueMove
+}
+
+// soundPlayer plays sound cues. Implementations must not block the caller.
+type soundPlayer interface {
+	play(cue soundCue)
+}
+
+// bellPlayer rings the terminal bell for every cue.
+type bellPlayer struct{}
+
+func (bellPlayer) play(soundCue) {
+	os.Stdout.WriteString("\a")
+}
+
+// commandPlayer runs an external command for every cue, with "{cue}" in its
+// arguments replaced by the cue name, e.g. "paplay /usr/share/chess/{cue}.wav".
+type commandPlayer struct {
+	args []string
+}
+
+func (p commandPlayer) play(cue soundCue) {
+	args := make([]string, len(p.args))
+	for i, arg := range p.args {
+		args[i] = strings.ReplaceAll(arg, "{cue}", string(cue))
+	}
+	// A missing player or sound file must never interrupt the game.
+	go exec.Command(args[0], args[1:]...).Run()
+}
+
+// newSoundPlayer returns the backend for a sound theme: "bell", or "command"
+// to run cmd for every cue.
+func newSoundPlayer(theme, cmd string) (soundPlayer, error) {
+	switch theme {
+	case "bell":
+		return bellPlayer{}, nil
+	case "command":
+		args := strings.Fields(cmd)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("the command sound theme needs -sound-cmd")
+		}
+		return commandPlayer{args: args}, nil
+	}
+	return nil, fmt.Errorf("unknown sound theme %q", theme)
+}
+
+// playCue plays cue unless sound is muted or the game has no player.
+func (g *Game) playCue(cue soundCue) {
+	if g.sound != nil && !g.muted {
+		g.sound.play(cue)
+	}
+}
+
+// toggleMute mutes or unmutes sound and remembers the choice.
+func (g *Game) toggleMute() {
+	g.muted = !g.muted
+	if g.muted {
+		g.message = tr(txtSoundMuted)
+	} else {
+		g.message = tr(txtSoundOn)
+	}
+	if g.prefs != nil {
+		g.prefs.Muted = g.muted
+		g.savePreferences()
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// cueRecorder is a soundPlayer that remembers the cues it was asked to play.
+type cueRecorder struct {
+	cues []soundCue
+}
+
+func (r *cueRecorder) play(cue soundCue) {
+	r.cues = append(r.cues, cue)
+}
+
+// TestNoLegalMoveEndings checks that a side left without a legal move
+// loses only when in check: checkmate is decisive, stalemate a draw, and
+// each has its own message and cue.
+func TestNoLegalMoveEndings(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		move        string
+		result      string
+		termination string
+		message     string
+		cue         soundCue
+	}{
+		{"back-rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", resultWhiteWins, "checkmate", tr(txtCheckmate, colorName("white")), cueCheckmate},
+		{"black mates", "r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", "a8a1", resultBlackWins, "checkmate", tr(txtCheckmate, colorName("black")), cueCheckmate},
+		{"king and pawn stalemate", "7k/7P/5K2/8/8/8/8/8 w - - 0 1", "f6g6", resultDraw, "stalemate", tr(txtStalemate), cueStalemate},
+		{"check with a way out", "6k1/8/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", resultOngoing, "", "", cueCheck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t, tt.fen)
+			sound := &cueRecorder{}
+			g.sound = sound
+			playMoves(t, g, tt.move)
+			if g.result != tt.result || g.termination != tt.termination {
+				t.Errorf("result %q by %q, want %q by %q", g.result, g.termination, tt.result, tt.termination)
+			}
+			if tt.message != "" && g.message != tt.message {
+				t.Errorf("message %q, want %q", g.message, tt.message)
+			}
+			if !slices.Equal(sound.cues, []soundCue{tt.cue}) {
+				t.Errorf("cues %v, want [%s]", sound.cues, tt.cue)
+			}
+		})
+	}
+}
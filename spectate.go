@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Spectators watch a served game on a separate address, so they can never
+// be paired as players. A spectator sends "watch" for the latest game or
+// "watch 3" for game 3, and the server answers "spectator" or the reason
+// it cannot, e.g. "no such game". After the usual hellos the server sends
+// the game so far as FEN and H messages, as a host continuing a game does,
+// then relays every move and control message as it is played, wrapped in
+// an R message naming who sent it:
+//
+//	R white M e2e4
+//	R black CTRL draw offer
+//	R server CTRL adjudicate 1-0
+//
+// Whatever a spectator sends is read and dropped. The players are sent
+// "CTRL spectators N" whenever a spectator comes or goes. While anyone is
+// watching, each player reports which way their board is turned with
+// "CTRL view white" or "CTRL view black", so a spectator coaching one of
+// them can see the board exactly as they do.
+
+// ctrlView reports which side a player's board is drawn from, e.g. "view
+// black". The server relays it like any control message; only a spectator
+// following that player acts on it.
+const ctrlView = "view"
+
+// watchPort is the default port spectators connect to.
+const watchPort = "8081"
+
+// spectatorBacklog is how many messages may queue for a spectator beyond
+// the game so far. A spectator that falls further behind is dropped, so a
+// slow connection never holds up the players.
+const spectatorBacklog = 256
+
+// match is a game running on the server, shared by its relays and the
+// spectators watching it. mu guards g and spectators.
+type match struct {
+	id         int
+	g          *Game
+	players    []net.Conn // Told how many spectators are watching
+	mu         sync.Mutex
+	spectators map[chan message]bool // Each spectator's outgoing queue; nil once the match is over
+}
+
+// broadcast relays msg, sent by from ("white", "black" or "server"), to
+// every spectator. The caller holds mt.mu.
+func (mt *match) broadcast(from string, msg message) {
+	relayed := message{kind: msgRelay, arg: from + " " + msg.encode()}
+	watching := len(mt.spectators)
+	for out := range mt.spectators {
+		select {
+		case out <- relayed:
+		default:
+			mt.drop(out)
+		}
+	}
+	if len(mt.spectators) != watching {
+		mt.announce()
+	}
+}
+
+// announce tells the players how many spectators are watching. The caller
+// holds mt.mu.
+func (mt *match) announce() {
+	if mt.spectators == nil {
+		return
+	}
+	m := message{kind: msgControl, arg: fmt.Sprintf("%s %d", ctrlSpectators, len(mt.spectators))}
+	for _, conn := range mt.players {
+		sendMessage(conn, m)
+	}
+}
+
+// drop stops relaying to a spectator. The caller holds mt.mu.
+func (mt *match) drop(out chan message) {
+	if mt.spectators[out] {
+		delete(mt.spectators, out)
+		close(out)
+	}
+}
+
+// finish drops every spectator once the match is over. The caller holds
+// mt.mu.
+func (mt *match) finish() {
+	for out := range mt.spectators {
+		mt.drop(out)
+	}
+	mt.spectators = nil
+}
+
+// matchList is the server's running games, by id, so spectators can find
+// them.
+type matchList struct {
+	mu      sync.Mutex
+	matches map[int]*match
+	latest  int
+}
+
+func newMatchList() *matchList {
+	return &matchList{matches: make(map[int]*match)}
+}
+
+func (l *matchList) add(mt *match) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.matches[mt.id] = mt
+	l.latest = mt.id
+}
+
+func (l *matchList) remove(id int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.matches, id)
+}
+
+// find returns the running game with the given id, or the latest one for
+// id 0, or nil.
+func (l *matchList) find(id int) *match {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id == 0 {
+		id = l.latest
+	}
+	return l.matches[id]
+}
+
+// watchRequest returns the game id a spectator asks for, 0 for the latest.
+func watchRequest(line string) (int, error) {
+	fields := strings.Fields(line)
+	switch {
+	case len(fields) == 1 && fields[0] == "watch":
+		return 0, nil
+	case len(fields) == 2 && fields[0] == "watch":
+		if id, err := strconv.Atoi(fields[1]); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("bad watch request %q", line)
+}
+
+// serveSpectators accepts spectators on ln until it fails.
+func serveSpectators(ln net.Listener, games *matchList, opts serverOptions) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go watchMatch(conn, games, opts)
+	}
+}
+
+// watchMatch sends a spectator the game it asked for, as described above,
+// until the game or the connection ends.
+func watchMatch(conn net.Conn, games *matchList, opts serverOptions) {
+	conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	line, err := readLine(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return
+	}
+	id, err := watchRequest(line)
+	var mt *match
+	if err == nil {
+		if mt = games.find(id); mt == nil {
+			err = fmt.Errorf("no such game")
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(conn, err)
+		conn.Close()
+		return
+	}
+	fmt.Fprintln(conn, "spectator")
+	peer, err := handshake(conn, gameCapabilities(opts.freestyle, opts.variant))
+	if err == nil {
+		_, err = settleVariant(opts.variant, peer.variant, true)
+	}
+	if err != nil {
+		fmt.Printf("Game %d: spectator %s refused: %v\n", mt.id, conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+
+	// Queue the game so far and join the broadcast in one step, so the
+	// spectator misses no move and sees none twice.
+	mt.mu.Lock()
+	if mt.spectators == nil {
+		mt.mu.Unlock()
+		conn.Close()
+		return
+	}
+	out := make(chan message, len(mt.g.moveHistory)+spectatorBacklog+1)
+	if mt.g.startFEN != "" {
+		out <- message{kind: msgPosition, arg: mt.g.startFEN}
+	}
+	for _, moveStr := range mt.g.moveHistory {
+		out <- message{kind: msgHistory, arg: moveStr}
+	}
+	mt.spectators[out] = true
+	mt.announce()
+	mt.mu.Unlock()
+	fmt.Printf("Game %d: %s is watching\n", mt.id, conn.RemoteAddr())
+
+	go func() {
+		io.Copy(io.Discard, conn) // Spectators have nothing to say
+		mt.mu.Lock()
+		mt.drop(out)
+		mt.announce()
+		mt.mu.Unlock()
+	}()
+	for m := range out {
+		if sendMessage(conn, m) != nil {
+			break
+		}
+	}
+	conn.Close()
+}
+
+// watchAddress is the spectator address to dial for addr, a host with
+// or without a port. A bare host gets watchPort.
+func watchAddress(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), watchPort)
+}
+
+// watchGame connects to the spectator address of a server, addr as
+// watchAddress takes it, and asks to watch game id, or the latest game
+// for 0.
+func watchGame(addr string, id int) (net.Conn, error) {
+	conn, err := net.Dial("tcp", watchAddress(addr))
+	if err != nil {
+		return nil, err
+	}
+	request := "watch"
+	if id > 0 {
+		request += " " + strconv.Itoa(id)
+	}
+	fmt.Fprintln(conn, request)
+	answer, err := readLine(conn)
+	if err == nil && answer != "spectator" {
+		err = fmt.Errorf("server refused: %s", answer)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// readOnlyConn is a spectator's connection: anything the game would send
+// on it, such as heartbeats, is dropped.
+type readOnlyConn struct {
+	net.Conn
+}
+
+func (readOnlyConn) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// spectate applies an R message: a move or control message sent by one of
+// the players, or by the server.
+func (g *Game) spectate(arg string) error {
+	from, inner, _ := strings.Cut(arg, " ")
+	m, err := decodeMessage(inner)
+	if err != nil {
+		return err
+	}
+	g.lock.Lock()
+	g.settingUp = false
+	g.lock.Unlock()
+	switch m.kind {
+	case msgMove:
+		return g.ApplyAlgebraic(m.arg, from)
+	case msgControl:
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		if err := g.applyControl(from, m.arg); err != nil {
+			return err
+		}
+		if verb, _, _ := strings.Cut(m.arg, " "); verb == ctrlView {
+			return nil // Not news to anyone watching
+		}
+		// applyControl words its messages for the players.
+		if g.gameOver {
+			g.message = tr(txtOutcomeQuit, g.outcome())
+		} else {
+			g.message = tr(txtPlayerControl, colorName(from), m.arg)
+		}
+		return nil
+	}
+	return fmt.Errorf("%q: %w", inner, errUnknownMessage)
+}
+
+// applyView acts on a "view white" or "view black" control message from a
+// player: a spectator following them turns the board to match. The caller
+// holds g.lock.
+func (g *Game) applyView(from, verb, arg string) error {
+	if arg != "white" && arg != "black" {
+		return fmt.Errorf("%q: %w", verb, errUnknownControl)
+	}
+	if g.spectating && g.follow == from {
+		g.flipped = arg == "black"
+	}
+	return nil
+}
+
+// reportView tells the server which way the board is turned, if anyone is
+// watching and it has changed since it was last reported.
+func (g *Game) reportView(conn io.Writer) {
+	g.lock.Lock()
+	view := "white"
+	if g.flipped {
+		view = "black"
+	}
+	changed := !g.spectating && !g.gameOver && g.spectatorCount > 0 && view != g.viewSent
+	if changed {
+		g.viewSent = view
+	}
+	g.lock.Unlock()
+	if changed {
+		sendMessage(conn, message{kind: msgControl, arg: ctrlView + " " + view})
+	}
+}
+
+// flipBoard turns the board round. A spectator following a player's view
+// stops following it, or the next report would turn the board straight
+// back.
+func (g *Game) flipBoard() {
+	g.flipped = !g.flipped
+	if g.follow != "" {
+		g.message = tr(txtFollowStopped, colorName(g.follow))
+		g.follow = ""
+	}
+}
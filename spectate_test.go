@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestWatchAddress(t *testing.T) {
+	tests := []struct {
+		addr, want string
+	}{
+		{"example.com", "example.com:8081"},
+		{"example.com:9000", "example.com:9000"},
+		{"10.0.0.2", "10.0.0.2:8081"},
+		{"::1", "[::1]:8081"},
+		{"[::1]", "[::1]:8081"},
+		{"[::1]:9000", "[::1]:9000"},
+	}
+	for _, tt := range tests {
+		if got := watchAddress(tt.addr); got != tt.want {
+			t.Errorf("watchAddress(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
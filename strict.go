@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+// Strict mode re-checks the rules engine around every move, for catching
+// generator and application bugs during development. It is too slow to
+// leave on in normal play.
+
+// assertLegal panics unless (fromY, fromX) -> (toY, toX) is among the legal
+// moves of the side to move. It must be called before the move is applied.
+func (g *Game) assertLegal(fromY, fromX, toY, toX int) {
+	move := formatMove(fromY, fromX, toY, toX)
+	piece := g.board[fromY][fromX]
+	switch {
+	case piece == nil:
+		panic(fmt.Sprintf("strict: move %s from an empty square", move))
+	case piece.color != g.currentPlayer:
+		panic(fmt.Sprintf("strict: move %s by %s on %s's turn", move, piece.color, g.currentPlayer))
+	case g.movesFrom(fromY, fromX)[squareKey(toX, toY)] == moveNone:
+		panic(fmt.Sprintf("strict: move %s is not in the legal move list", move))
+	}
+}
+
+// assertConsistent panics if the position after a move breaks any of the
+// rules ValidatePosition checks, such as one king per side, or if the hash
+// applyMove kept up to date differs from one worked out afresh.
+func (g *Game) assertConsistent() {
+	if hash := g.computeHash(); g.hash != hash {
+		panic(fmt.Sprintf("strict: after %s: hash %016x, want %016x", g.moveHistory[len(g.moveHistory)-1], g.hash, hash))
+	}
+	if g.freestyle {
+		return // Freestyle positions obey none of those rules
+	}
+	if err := g.ValidatePosition(); err != nil {
+		panic(fmt.Sprintf("strict: after %s: %v", g.moveHistory[len(g.moveHistory)-1], err))
+	}
+}
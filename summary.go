@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gameSummary holds the statistics shown when a game ends. The game has no
+// opening book yet, so the opening's name is not among them.
+type gameSummary struct {
+	moves     int                      // Full moves played, counting a lone white or black move as one
+	captures  map[string]int           // Pieces taken, by the color that took them
+	checks    map[string]int           // Checks given, by the color that gave them
+	swing     int                      // Largest change in the material balance made by one move, in centipawns
+	swingMove string                   // The move that made it, in SAN with its number, e.g. "12. Qxd8+"
+	timeLeft  map[string]time.Duration // Time left on each side's clock, nil in a game without clocks
+	timeUsed  map[string]time.Duration // Time each side spent thinking, nil in a game without clocks
+}
+
+// summarize works out the game's statistics from its event log.
+func (g *Game) summarize() gameSummary {
+	moves := g.playedMoves()
+	first := g.startPly()
+	s := gameSummary{
+		captures: map[string]int{"white": 0, "black": 0},
+		checks:   map[string]int{"white": 0, "black": 0},
+	}
+	if len(moves) > 0 {
+		// Move numbers from the first move's to the last's.
+		s.moves = (first+len(moves)-1)/2 - first/2 + 1
+	}
+	if g.clock != nil {
+		s.timeLeft = map[string]time.Duration{"white": g.clock.Remaining("white"), "black": g.clock.Remaining("black")}
+		s.timeUsed = map[string]time.Duration{"white": g.clock.Used("white"), "black": g.clock.Used("black")}
+	}
+	start := NewGame()
+	if g.startFEN != "" {
+		start.loadFEN(g.startFEN)
+	}
+	balance := start.Evaluate()
+	for i, ev := range moves {
+		if ev.captured != "" {
+			s.captures[ev.color]++
+		}
+		if ev.check {
+			s.checks[ev.color]++
+		}
+		if swing := abs(ev.balance - balance); swing > s.swing {
+			s.swing = swing
+			s.swingMove = moveNumber(first+i, ev.color) + localSAN(ev.san)
+		}
+		balance = ev.balance
+	}
+	return s
+}
+
+// moveNumber is the number written before the move ply half-moves after
+// white's first (see startPly), e.g. "3. " for white's third move and
+// "3... " for black's.
+func moveNumber(ply int, mover string) string {
+	if mover == "black" {
+		return fmt.Sprintf("%d... ", ply/2+1)
+	}
+	return fmt.Sprintf("%d. ", ply/2+1)
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// lines formats the summary for the game-over panel and the summary file.
+func (s gameSummary) lines() []string {
+	lines := []string{
+		tr(txtSummaryMoves, s.moves),
+		tr(txtSummaryCaptures, s.captures["white"], s.captures["black"]),
+		tr(txtSummaryChecks, s.checks["white"], s.checks["black"]),
+	}
+	if s.swing > 0 {
+		unit := tr(txtPawns)
+		if s.swing == 100 {
+			unit = tr(txtPawn)
+		}
+		lines = append(lines, tr(txtSummarySwing, s.swingMove, pawns(s.swing), unit))
+	}
+	if s.timeLeft != nil {
+		lines = append(lines, tr(txtSummaryTime,
+			formatClock(s.timeLeft["white"]), formatClock(s.timeUsed["white"]),
+			formatClock(s.timeLeft["black"]), formatClock(s.timeUsed["black"])))
+	}
+	return lines
+}
+
+// saveSummary writes the outcome, final material and summary to a new
+// timestamped text file in dir, named like the PGN files saveGame writes,
+// and returns its path.
+func (g *Game) saveSummary(dir string) (string, error) {
+	path := filepath.Join(dir, "game-"+time.Now().Format("20060102-150405")+"-summary.txt")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	lines := append([]string{g.outcome(), g.materialLine()}, g.summarize().lines()...)
+	return path, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
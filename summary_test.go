@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummaryCountsFromStartFEN(t *testing.T) {
+	// Black plays move 12, then both sides play move 13: two moves.
+	g := newTestGame(t, "4k3/8/8/3p4/4P3/8/8/4K3 b - - 0 12")
+	playMoves(t, g, "d5e4", "e1e2", "e8e7")
+	s := g.summarize()
+	if s.moves != 2 {
+		t.Errorf("moves = %d, want 2", s.moves)
+	}
+	if s.swingMove != "12... dxe4" {
+		t.Errorf("swing move %q, want %q", s.swingMove, "12... dxe4")
+	}
+
+	g = newTestGame(t, "")
+	playMoves(t, g, "e2e4", "e7e5", "g1f3")
+	if s := g.summarize(); s.moves != 2 {
+		t.Errorf("moves = %d after three plies from the start, want 2", s.moves)
+	}
+}
+
+func TestSummaryClockTimes(t *testing.T) {
+	g := newTestGame(t, "")
+	if g.summarize().timeLeft != nil {
+		t.Error("summary of a game without clocks has times")
+	}
+
+	ft := &fakeTime{now: time.Unix(0, 0)}
+	g.timeSource = ft
+	g.startClock(timeControl{base: 5 * time.Minute, bonus: 2 * time.Second, mode: "increment"})
+	playMoves(t, g, "e2e4") // White's clock is not running yet
+	ft.advance(10 * time.Second)
+	playMoves(t, g, "e7e5")
+	ft.advance(3 * time.Second)
+	playMoves(t, g, "g1f3")
+	ft.advance(time.Second) // Black is still thinking
+
+	lines := g.summarize().lines()
+	want := "Time: White 4:59 left (0:03.0 used), Black 4:51 left (0:11 used)"
+	if got := lines[len(lines)-1]; got != want {
+		t.Errorf("last summary line %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,732 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// textKey names a message shown to the player. Every message shown on the
+// board goes through the text table, so a table can rebrand or translate
+// them; only the details of errors quoted in a message stay in English.
+type textKey string
+
+// Keys of the text table, grouped by where the messages come up. The key
+// is what a messages file uses to override the message.
+const (
+	txtBanner   textKey = "banner"
+	txtMenu     textKey = "menu"
+	txtWelcome  textKey = "welcome"
+	txtWhite    textKey = "white"
+	txtBlack    textKey = "black"
+	txtTurn     textKey = "turn"
+	txtNotTurn  textKey = "not_your_turn"
+	txtOwnPiece textKey = "select_own_piece"
+
+	txtSpectatorMove     textKey = "spectators_cannot_move"
+	txtSpectatorAction   textKey = "spectators_cannot_act"
+	txtSpectatorChat     textKey = "spectators_cannot_chat"
+	txtWatching          textKey = "watching"
+	txtWatchingFollow    textKey = "watching_following"
+	txtFollowStopped     textKey = "follow_stopped"
+	txtMoveCancelled     textKey = "move_cancelled"
+	txtMoveCancelledWhy  textKey = "move_cancelled_because"
+	txtTouchMove         textKey = "touch_move"
+	txtPromoteChoose     textKey = "promote_choose"
+	txtCancelled         textKey = "cancelled"
+	txtNotOnAnalysis     textKey = "not_on_analysis_board"
+	txtOpponentGone      textKey = "opponent_gone"
+	txtCannotDo          textKey = "cannot_do"
+	txtChatYou           textKey = "chat_you"
+	txtChatOpponent      textKey = "chat_opponent"
+	txtIgnoredOutOfTurn  textKey = "ignored_out_of_turn"
+	txtIgnoredMove       textKey = "ignored_move"
+	txtIgnoredControl    textKey = "ignored_control"
+	txtIgnoredPosition   textKey = "ignored_position"
+	txtIgnoredServer     textKey = "ignored_server"
+	txtIgnoredOpponent   textKey = "ignored_opponent"
+	txtIgnoredOversized  textKey = "ignored_oversized"
+	txtServerClosed      textKey = "server_closed"
+	txtConnectionLost    textKey = "connection_lost"
+	txtOpponentLeft      textKey = "opponent_left"
+	txtLeftAborted       textKey = "left_aborted"
+	txtLeftYouWin        textKey = "left_you_win"
+	txtReadyWaiting      textKey = "ready_waiting"
+	txtReadyToStart      textKey = "ready_to_start"
+	txtReadyBoth         textKey = "ready_both"
+	txtReadyYou          textKey = "ready_you"
+	txtReadyOpponent     textKey = "ready_opponent"
+	txtPremovesCancelled textKey = "premoves_cancelled"
+	txtPremoveCancelled  textKey = "premove_cancelled"
+	txtPremovePick       textKey = "premove_pick"
+	txtPremoveQueued     textKey = "premove_queued"
+	txtPremovesQueued    textKey = "premoves_queued"
+	txtPremoveFailed     textKey = "premove_failed"
+	txtPremoveChainLost  textKey = "premove_chain_failed"
+	txtHeldSending       textKey = "held_sending"
+	txtHeldSent          textKey = "held_sent"
+	txtHeldNone          textKey = "held_none"
+	txtHeldNoDelay       textKey = "held_no_delay"
+	txtHeldTakenBack     textKey = "held_taken_back"
+
+	txtContinuing           textKey = "continuing"
+	txtKeyboardHint         textKey = "keyboard_hint"
+	txtKeyboardMode         textKey = "keyboard_mode"
+	txtDeliveryUnconfirmed  textKey = "delivery_unconfirmed"
+	txtAnalysisNotSent      textKey = "analysis_not_sent"
+	txtThemeName            textKey = "theme_name"
+	txtThemeHint            textKey = "theme_hint"
+	txtThemePicker          textKey = "theme_picker"
+	txtThemePickerTitle     textKey = "theme_picker_title"
+	txtThemeChosen          textKey = "theme_chosen"
+	txtConfirm              textKey = "confirm"
+	txtResignAsk            textKey = "resign_ask"
+	txtResignQuitAsk        textKey = "resign_quit_ask"
+	txtAbortAsk             textKey = "abort_ask"
+	txtChatPrompt           textKey = "chat_prompt"
+	txtJumpPrompt           textKey = "jump_prompt"
+	txtNoSuchSquare         textKey = "no_such_square"
+	txtCursorOn             textKey = "cursor_on"
+	txtCursorOnMovable      textKey = "cursor_on_movable"
+	txtNoMovablePiece       textKey = "no_movable_piece"
+	txtMobility             textKey = "mobility"
+	txtMobilityOne          textKey = "mobility_one"
+	txtMobilityNone         textKey = "mobility_none"
+	txtMobilityPinned       textKey = "mobility_pinned"
+	txtThreatsShown         textKey = "threats_shown"
+	txtThreatsHidden        textKey = "threats_hidden"
+	txtHeatmapShown         textKey = "heatmap_shown"
+	txtHeatmapHidden        textKey = "heatmap_hidden"
+	txtControlShown         textKey = "control_shown"
+	txtControlHidden        textKey = "control_hidden"
+	txtSoundMuted           textKey = "sound_muted"
+	txtSoundOn              textKey = "sound_on"
+	txtClipboardUnsupported textKey = "clipboard_unsupported"
+	txtMoveListCopied       textKey = "move_list_copied"
+	txtPositionLinkCopied   textKey = "position_link_copied"
+	txtPositionLinkLater    textKey = "position_link_later"
+	txtGameLinkCopied       textKey = "game_link_copied"
+	txtGameLinkLater        textKey = "game_link_later"
+	txtScreenshotPrompt     textKey = "screenshot_prompt"
+	txtPrefsSaveFailed      textKey = "preferences_save_failed"
+	txtAutosaveFailed       textKey = "autosave_failed"
+	txtOpponentConnected    textKey = "opponent_connected"
+	txtNoContact            textKey = "no_contact"
+	txtSpectatorCount       textKey = "spectator_count"
+	txtClockStatus          textKey = "clock_status"
+	txtClockDelay           textKey = "clock_delay"
+	txtHalfmoveClock        textKey = "halfmove_clock"
+	txtPositionRepeated     textKey = "position_repeated"
+	txtOutcomeQuit          textKey = "outcome_quit"
+	txtPlayerControl        textKey = "player_control"
+	txtBotFailed            textKey = "bot_failed"
+	txtWaitingReady         textKey = "waiting_ready"
+	txtWaitingDraw          textKey = "waiting_draw"
+	txtWaitingTakeback      textKey = "waiting_takeback"
+	txtWaitingMove          textKey = "waiting_move"
+	txtHandicapKnight       textKey = "handicap_knight"
+	txtHandicapRook         textKey = "handicap_rook"
+	txtHandicapQueen        textKey = "handicap_queen"
+	txtHandicapPawnAndMove  textKey = "handicap_pawn_and_move"
+	txtHandicapCustom       textKey = "handicap_custom"
+
+	// Piece names, for messages about a piece.
+	txtPieceKing   textKey = "piece_king"
+	txtPieceQueen  textKey = "piece_queen"
+	txtPieceRook   textKey = "piece_rook"
+	txtPieceBishop textKey = "piece_bishop"
+	txtPieceKnight textKey = "piece_knight"
+	txtPiecePawn   textKey = "piece_pawn"
+
+	txtAnalysisWelcome   textKey = "analysis_welcome"
+	txtLiveGame          textKey = "live_game"
+	txtNothingToTakeBack textKey = "nothing_to_take_back"
+	txtTookBack          textKey = "took_back"
+	txtNoMirror          textKey = "no_move_to_mirror"
+	txtCannotMirror      textKey = "cannot_mirror"
+	txtPlayPrompt        textKey = "play_prompt"
+	txtCannotPlay        textKey = "cannot_play"
+
+	txtReplayStart   textKey = "replay_start"
+	txtReplayOver    textKey = "replay_game_over"
+	txtReplayPlaying textKey = "replay_playing"
+	txtReplayPaused  textKey = "replay_paused"
+	txtReplayMove    textKey = "replay_move"
+
+	txtLineStart      textKey = "line_start"
+	txtBookMove       textKey = "book_move"
+	txtBookReply      textKey = "book_reply"
+	txtNotBookMove    textKey = "not_book_move"
+	txtLineDone       textKey = "line_done"
+	txtLineComplete   textKey = "line_complete"
+	txtNoMistakes     textKey = "no_mistakes"
+	txtOneMistake     textKey = "one_mistake"
+	txtMistakes       textKey = "mistakes"
+	txtPuzzleStart    textKey = "puzzle_start"
+	txtSolutionMove   textKey = "solution_move"
+	txtNotTheSolution textKey = "not_the_solution"
+	txtCorrect        textKey = "correct"
+	txtReplies        textKey = "replies"
+	txtYourMove       textKey = "your_move"
+	txtPuzzleSolved   textKey = "puzzle_solved"
+	txtPuzzleDone     textKey = "puzzle_done"
+
+	txtResignedYou       textKey = "resigned_you"
+	txtResignedOpponent  textKey = "resigned_opponent"
+	txtAbortedYou        textKey = "aborted_you"
+	txtAbortedOpponent   textKey = "aborted_opponent"
+	txtDrawOfferYou      textKey = "draw_offer_you"
+	txtDrawOfferOpponent textKey = "draw_offer_opponent"
+	txtTakebackYou       textKey = "takeback_you"
+	txtTakebackOpponent  textKey = "takeback_opponent"
+	txtDeclinedYou       textKey = "declined_you"
+	txtDeclinedOpponent  textKey = "declined_opponent"
+	txtNoOffer           textKey = "no_offer"
+	txtDrawAgreed        textKey = "draw_agreed"
+	txtTakenBack         textKey = "taken_back"
+
+	txtCheck             textKey = "check"
+	txtCheckmate         textKey = "checkmate"
+	txtStalemate         textKey = "stalemate"
+	txtKingTaken         textKey = "king_taken"
+	txtKingOfTheHill     textKey = "king_of_the_hill"
+	txtThirdCheck        textKey = "third_check"
+	txtDeadPosition      textKey = "dead_position"
+	txtSeventyFiveMoves  textKey = "seventy_five_moves"
+	txtFivefold          textKey = "fivefold_repetition"
+	txtMoveLimitDraw     textKey = "move_limit_draw"
+	txtMoveLimitMaterial textKey = "move_limit_material"
+	txtTimeRanOut        textKey = "time_ran_out"
+	txtTimeLoneKing      textKey = "time_lone_king"
+	txtTimeYouLose       textKey = "time_you_lose"
+	txtTimeOpponentLost  textKey = "time_opponent_lost"
+	txtIdleDrawn         textKey = "idle_drawn"
+	txtIdleYouLose       textKey = "idle_you_lose"
+	txtIdleYouWin        textKey = "idle_you_win"
+	txtIdleResult        textKey = "idle_result"
+
+	txtHostIP        textKey = "host_ip"
+	txtHostFailed    textKey = "host_failed"
+	txtJoinFailed    textKey = "join_failed"
+	txtInvalidChoice textKey = "invalid_choice"
+
+	txtWhiteWins       textKey = "white_wins"
+	txtBlackWins       textKey = "black_wins"
+	txtDrawn           textKey = "drawn"
+	txtNoResult        textKey = "no_result"
+	txtEndAbandoned    textKey = "end_abandoned"
+	txtEndAborted      textKey = "end_aborted"
+	txtEndAdjudication textKey = "end_adjudication"
+	txtEndAgreement    textKey = "end_agreement"
+	txtEndCheckmate    textKey = "end_checkmate"
+	txtEndFivefold     textKey = "end_fivefold_repetition"
+	txtEndForfeit      textKey = "end_forfeit"
+	txtEndDeadPosition textKey = "end_insufficient_material"
+	txtEndKingCaptured textKey = "end_king_captured"
+	txtEndKingOfHill   textKey = "end_king_of_the_hill"
+	txtEndThreeCheck   textKey = "end_three_check"
+	txtEndMoveLimit    textKey = "end_move_limit"
+	txtEndResignation  textKey = "end_resignation"
+	txtEndSeventyFive  textKey = "end_seventy_five_move_rule"
+	txtEndStalemate    textKey = "end_stalemate"
+	txtEndTimeForfeit  textKey = "end_time_forfeit"
+	txtGameOverChoices textKey = "game_over_choices"
+	txtMaterial        textKey = "material"
+	txtSummaryMoves    textKey = "summary_moves"
+	txtSummaryCaptures textKey = "summary_captures"
+	txtSummaryChecks   textKey = "summary_checks"
+	txtSummarySwing    textKey = "summary_swing"
+	txtSummaryTime     textKey = "summary_time"
+	txtPawn            textKey = "pawn"
+	txtPawns           textKey = "pawns"
+	txtSaveFailed      textKey = "save_failed"
+	txtSaved           textKey = "saved"
+
+	// Piece letters for showing and typing SAN. PGN files and the
+	// clipboard always get the English letters.
+	txtLetterKing   textKey = "letter_king"
+	txtLetterQueen  textKey = "letter_queen"
+	txtLetterRook   textKey = "letter_rook"
+	txtLetterBishop textKey = "letter_bishop"
+	txtLetterKnight textKey = "letter_knight"
+
+	txtHelpTitle          textKey = "help_title"
+	txtHelpClose          textKey = "help_close"
+	txtHelpQuit           textKey = "help_quit"
+	txtHelpQuitNow        textKey = "help_quit_now"
+	txtHelpHelp           textKey = "help_help"
+	txtHelpLeft           textKey = "help_left"
+	txtHelpRight          textKey = "help_right"
+	txtHelpUp             textKey = "help_up"
+	txtHelpDown           textKey = "help_down"
+	txtHelpSelect         textKey = "help_select"
+	txtHelpNextPiece      textKey = "help_next_piece"
+	txtHelpPrevPiece      textKey = "help_previous_piece"
+	txtHelpJump           textKey = "help_jump"
+	txtHelpUndoHeld       textKey = "help_undo_held"
+	txtHelpReady          textKey = "help_ready"
+	txtHelpDraw           textKey = "help_draw"
+	txtHelpTakeback       textKey = "help_takeback"
+	txtHelpDecline        textKey = "help_decline"
+	txtHelpResign         textKey = "help_resign"
+	txtHelpChat           textKey = "help_chat"
+	txtHelpAnalyze        textKey = "help_analyze"
+	txtHelpThreats        textKey = "help_threats"
+	txtHelpControl        textKey = "help_control"
+	txtHelpFlip           textKey = "help_flip"
+	txtHelpTheme          textKey = "help_theme"
+	txtHelpThemePicker    textKey = "help_theme_picker"
+	txtHelpMute           textKey = "help_mute"
+	txtHelpRedraw         textKey = "help_redraw"
+	txtHelpCopy           textKey = "help_copy"
+	txtHelpPicture        textKey = "help_picture"
+	txtHelpExportPosition textKey = "help_export_position"
+	txtHelpExportGame     textKey = "help_export_game"
+)
+
+// defaultTexts is the built-in English table. Messages with arguments
+// take them in the order of their fmt verbs, and an override must keep
+// the same number of verbs.
+var defaultTexts = map[textKey]string{
+	txtBanner:   "Welcome to Go Chess!",
+	txtMenu:     "Do you want to (h)ost, (j)oin or (s)erve games? ",
+	txtWelcome:  "Welcome! White's turn. Press 'c' to change theme.",
+	txtWhite:    "White",
+	txtBlack:    "Black",
+	txtTurn:     "%s's turn.",
+	txtNotTurn:  "Not your turn!",
+	txtOwnPiece: "Select one of your own pieces.",
+
+	txtSpectatorMove:     "Spectators cannot move.",
+	txtSpectatorAction:   "Spectators cannot do that.",
+	txtSpectatorChat:     "Spectators cannot chat.",
+	txtWatching:          "Watching. Esc leaves.",
+	txtWatchingFollow:    "Watching with the board as %s sees it; 'f' turns it freely. Esc leaves.",
+	txtFollowStopped:     "No longer following %s's view of the board.",
+	txtMoveCancelled:     "Move cancelled.",
+	txtMoveCancelledWhy:  "Move cancelled: %v.",
+	txtTouchMove:         "Touch-move: you must move the piece on %s.",
+	txtPromoteChoose:     "Promote to which piece? Press %s, or pick with the arrows and Enter. Esc cancels.",
+	txtCancelled:         "Cancelled.",
+	txtNotOnAnalysis:     "Not on the analysis board.",
+	txtOpponentGone:      "The opponent is no longer connected.",
+	txtCannotDo:          "Cannot do that: %v",
+	txtChatYou:           "You: %s",
+	txtChatOpponent:      "Opponent: %s",
+	txtIgnoredOutOfTurn:  "Ignored a move the opponent sent out of turn: %v",
+	txtIgnoredMove:       "Ignored a bad move from the opponent: %v",
+	txtIgnoredControl:    "Ignored a control message from the opponent: %v",
+	txtIgnoredPosition:   "Ignored the position sent by the host: %v",
+	txtIgnoredServer:     "Ignored a message from the server: %v",
+	txtIgnoredOpponent:   "Ignored a message from the opponent: %v",
+	txtIgnoredOversized:  "Ignored an oversized message from the opponent.",
+	txtServerClosed:      "The server closed the connection.",
+	txtConnectionLost:    "Lost the connection to the opponent.",
+	txtOpponentLeft:      "The opponent has left.",
+	txtLeftAborted:       "%s Game aborted.",
+	txtLeftYouWin:        "%s You win.",
+	txtReadyWaiting:      "Press 'g' when you are ready; the game starts once both players are.",
+	txtReadyToStart:      "Press 'g' when you are ready to start.",
+	txtReadyBoth:         "Both players are ready. %s to move.",
+	txtReadyYou:          "You are ready.",
+	txtReadyOpponent:     "Opponent is ready: press 'g' when you are.",
+	txtPremovesCancelled: "Premoves cancelled.",
+	txtPremoveCancelled:  "Premove cancelled.",
+	txtPremovePick:       "Premove: pick where it should go.",
+	txtPremoveQueued:     "Premove %s queued; click again to cancel.",
+	txtPremovesQueued:    "Premoves queued: %s; click an empty square to cancel.",
+	txtPremoveFailed:     "Premove %s cancelled: %v.",
+	txtPremoveChainLost:  "Premove %s cancelled: %v. The rest of the chain is dropped.",
+	txtHeldSending:       "Sending %s in %s; Backspace takes it back.",
+	txtHeldSent:          "Sent %s.",
+	txtHeldNone:          "No move waiting to be sent.",
+	txtHeldNoDelay:       "Moves are sent at once; start with -send-delay to take them back before sending.",
+	txtHeldTakenBack:     "Took back %s; it was never sent.",
+
+	txtContinuing:           "Continuing after %d moves. %s to move.",
+	txtKeyboardHint:         "Mouse unavailable, using keyboard: arrows or hjkl move, Enter or space selects, ? lists every key.",
+	txtKeyboardMode:         "Keyboard mode",
+	txtDeliveryUnconfirmed:  "Delivery unconfirmed!",
+	txtAnalysisNotSent:      "ANALYSIS, moves are not sent",
+	txtThemeName:            "Theme: %s",
+	txtThemeHint:            "Press 'c' to change theme.",
+	txtThemePicker:          "Choose a theme: arrows or j/k to preview, Enter to keep, Esc to cancel.",
+	txtThemePickerTitle:     "Themes",
+	txtThemeChosen:          "Theme: %s.",
+	txtConfirm:              "%s (y/n)",
+	txtResignAsk:            "Resign the game?",
+	txtResignQuitAsk:        "Resign and quit?",
+	txtAbortAsk:             "Abort the game?",
+	txtChatPrompt:           "Say: ",
+	txtJumpPrompt:           "Go to square: ",
+	txtNoSuchSquare:         "No such square: %s",
+	txtCursorOn:             "Cursor on %s.",
+	txtCursorOnMovable:      "Cursor on %s (%d of %d pieces that can move).",
+	txtNoMovablePiece:       "No piece can move.",
+	txtMobility:             "%s: %d moves. Click a destination square.",
+	txtMobilityOne:          "%s: 1 move. Click a destination square.",
+	txtMobilityNone:         "%s: 0 moves.",
+	txtMobilityPinned:       "%s: 0 moves (pinned).",
+	txtThreatsShown:         "Showing your hanging pieces.",
+	txtThreatsHidden:        "Hanging pieces hidden.",
+	txtHeatmapShown:         "Shading squares by control: green for white, red for black.",
+	txtHeatmapHidden:        "Control shading hidden.",
+	txtControlShown:         "Showing the squares the selected piece controls.",
+	txtControlHidden:        "Control squares hidden.",
+	txtSoundMuted:           "Sound muted.",
+	txtSoundOn:              "Sound on.",
+	txtClipboardUnsupported: "Clipboard not supported by this terminal.",
+	txtMoveListCopied:       "Move list copied to the clipboard.",
+	txtPositionLinkCopied:   "Lichess link to the position copied; it is also printed when you quit.",
+	txtPositionLinkLater:    "Lichess link to the position will be printed when you quit.",
+	txtGameLinkCopied:       "Lichess link to the game copied; it is also printed when you quit.",
+	txtGameLinkLater:        "Lichess link to the game will be printed when you quit.",
+	txtScreenshotPrompt:     "Save board to (.svg or .txt, Enter for a new SVG): ",
+	txtPrefsSaveFailed:      "Could not save preferences: %v",
+	txtAutosaveFailed:       "(Autosave failed: %v)",
+	txtOpponentConnected:    "Opponent connected",
+	txtNoContact:            "No contact with the opponent for %s",
+	txtSpectatorCount:       "%d watching",
+	txtClockStatus:          "White %s, Black %s",
+	txtClockDelay:           "(delay %s)",
+	txtHalfmoveClock:        "Halfmove clock: %d/%d",
+	txtPositionRepeated:     "Position repeated %d×",
+	txtOutcomeQuit:          "%s. Press Esc to quit.",
+	txtPlayerControl:        "%s: %s.",
+	txtBotFailed:            "The %s bot failed: %v",
+	txtWaitingReady:         "Waiting for the opponent to ready up",
+	txtWaitingDraw:          "Waiting for an answer to your draw offer",
+	txtWaitingTakeback:      "Waiting for an answer to your takeback request",
+	txtWaitingMove:          "Waiting for the opponent's move",
+	txtHandicapKnight:       "Knight odds: white plays without the b1 knight.",
+	txtHandicapRook:         "Rook odds: white plays without the a1 rook.",
+	txtHandicapQueen:        "Queen odds: white plays without the queen.",
+	txtHandicapPawnAndMove:  "Pawn and move: black plays without the f7 pawn, and white moves first.",
+	txtHandicapCustom:       "Handicap game from a custom position.",
+
+	txtPieceKing:   "King",
+	txtPieceQueen:  "Queen",
+	txtPieceRook:   "Rook",
+	txtPieceBishop: "Bishop",
+	txtPieceKnight: "Knight",
+	txtPiecePawn:   "Pawn",
+
+	txtAnalysisWelcome:   "Analysis board. Either side may move; 'u' takes back, 'x' mirrors the last move, 'e' edits it, 'H' shades control, Esc returns to the game.",
+	txtLiveGame:          "Live game: %s",
+	txtNothingToTakeBack: "Nothing to take back.",
+	txtTookBack:          "Took back a move.",
+	txtNoMirror:          "No move to mirror.",
+	txtCannotMirror:      "Cannot mirror: %v",
+	txtPlayPrompt:        "Play: ",
+	txtCannotPlay:        "Cannot play: %v",
+
+	txtReplayStart:   "Start position.",
+	txtReplayOver:    "Game over: %s.",
+	txtReplayPlaying: "Playing (%v)",
+	txtReplayPaused:  "Paused",
+	txtReplayMove:    "Move %d/%d",
+
+	txtLineStart:      "Line %d of %d: %s. Play %s's moves.",
+	txtBookMove:       "The book move is %s.",
+	txtBookReply:      "Book: %s. Your move.",
+	txtNotBookMove:    "%s is not the book move; the book plays %s. Try it.",
+	txtLineDone:       "%s complete with %s (%d of %d lines clean). 'n' drills the next line, Esc quits.",
+	txtLineComplete:   "Line complete. 'n' drills the next line, Esc quits.",
+	txtNoMistakes:     "no mistakes",
+	txtOneMistake:     "1 mistake",
+	txtMistakes:       "%d mistakes",
+	txtPuzzleStart:    "Puzzle %d of %d: %s. %s to play.",
+	txtSolutionMove:   "The solution plays %s.",
+	txtNotTheSolution: "%s is not it. Try again.",
+	txtCorrect:        "Correct!",
+	txtReplies:        "%s replies %s.",
+	txtYourMove:       "Your move.",
+	txtPuzzleSolved:   "%s solved (%d of %d clean). 'n' sets the next puzzle, Esc quits.",
+	txtPuzzleDone:     "Solved. 'n' sets the next puzzle, Esc quits.",
+
+	txtResignedYou:       "You resigned.",
+	txtResignedOpponent:  "Opponent resigned. You win.",
+	txtAbortedYou:        "You aborted the game.",
+	txtAbortedOpponent:   "Opponent aborted the game.",
+	txtDrawOfferYou:      "You offered a draw.",
+	txtDrawOfferOpponent: "Opponent offers a draw: 'd' accepts, 'n' declines.",
+	txtTakebackYou:       "You asked to take back your last move.",
+	txtTakebackOpponent:  "Opponent asks to take back their last move: 'u' accepts, 'n' declines.",
+	txtDeclinedYou:       "Offer declined.",
+	txtDeclinedOpponent:  "Opponent declined your offer.",
+	txtNoOffer:           "No offer to decline.",
+	txtDrawAgreed:        "Draw agreed.",
+	txtTakenBack:         "The last move was taken back.",
+
+	txtCheck:             "Check!",
+	txtCheckmate:         "Checkmate! %s wins. Press Esc to quit.",
+	txtStalemate:         "Stalemate! The game is a draw. Press Esc to quit.",
+	txtKingTaken:         "%s's king is taken! %s wins. Press Esc to quit.",
+	txtKingOfTheHill:     "%s's king reached the centre and wins! Press Esc to quit.",
+	txtThirdCheck:        "Third check! %s wins. Press Esc to quit.",
+	txtDeadPosition:      "Neither side can checkmate. The game is a draw. Press Esc to quit.",
+	txtSeventyFiveMoves:  "Seventy-five moves without a capture or pawn move. The game is a draw. Press Esc to quit.",
+	txtFivefold:          "The position occurred five times. The game is a draw. Press Esc to quit.",
+	txtMoveLimitDraw:     "The game reached the %d-move limit. Drawn. Press Esc to quit.",
+	txtMoveLimitMaterial: "The game reached the %d-move limit with material %d to %d. Result %s. Press Esc to quit.",
+	txtTimeRanOut:        "%s's time ran out.",
+	txtTimeLoneKing:      "%s's time ran out, but a lone king cannot win. The game is a draw.",
+	txtTimeYouLose:       "Your time ran out. You lose.",
+	txtTimeOpponentLost:  "The opponent's time ran out. You win.",
+	txtIdleDrawn:         "%s was idle too long. Game drawn.",
+	txtIdleYouLose:       "You were idle too long. You lose.",
+	txtIdleYouWin:        "%s was idle too long. You win.",
+	txtIdleResult:        "%s was idle too long. Result %s.",
+
+	txtHostIP:        "Enter host IP address: ",
+	txtHostFailed:    "Failed to host game: %v",
+	txtJoinFailed:    "Failed to join game: %v",
+	txtInvalidChoice: "Invalid choice.",
+
+	txtWhiteWins:       "White wins",
+	txtBlackWins:       "Black wins",
+	txtDrawn:           "Draw",
+	txtNoResult:        "No result",
+	txtEndAbandoned:    "Abandoned",
+	txtEndAborted:      "Aborted",
+	txtEndAdjudication: "Adjudication",
+	txtEndAgreement:    "Agreement",
+	txtEndCheckmate:    "Checkmate",
+	txtEndFivefold:     "Fivefold repetition",
+	txtEndForfeit:      "Forfeit",
+	txtEndDeadPosition: "Insufficient material",
+	txtEndKingCaptured: "King captured",
+	txtEndKingOfHill:   "King of the hill",
+	txtEndThreeCheck:   "Three checks",
+	txtEndMoveLimit:    "Move limit",
+	txtEndResignation:  "Resignation",
+	txtEndSeventyFive:  "Seventy-five-move rule",
+	txtEndStalemate:    "Stalemate",
+	txtEndTimeForfeit:  "Time forfeit",
+	txtGameOverChoices: "(s) Save PGN  (m) Save summary  (a) Analyze  (q) Quit  (Esc) Close",
+	txtMaterial:        "Material: White %s, Black %s",
+	txtSummaryMoves:    "Moves: %d",
+	txtSummaryCaptures: "Captures: White %d, Black %d",
+	txtSummaryChecks:   "Checks: White %d, Black %d",
+	txtSummarySwing:    "Biggest swing: %s (%s %s)",
+	txtSummaryTime:     "Time: White %s left (%s used), Black %s left (%s used)",
+	txtPawn:            "pawn",
+	txtPawns:           "pawns",
+	txtSaveFailed:      "Save failed: %v",
+	txtSaved:           "Saved %s.",
+
+	txtLetterKing:   "K",
+	txtLetterQueen:  "Q",
+	txtLetterRook:   "R",
+	txtLetterBishop: "B",
+	txtLetterKnight: "N",
+
+	txtHelpTitle:          "Keys",
+	txtHelpClose:          "Any key closes this help.",
+	txtHelpQuit:           "Quit; abort or resign first if the game is on",
+	txtHelpQuitNow:        "Quit at once, aborting or resigning without asking",
+	txtHelpHelp:           "Show this help",
+	txtHelpLeft:           "Move the cursor left",
+	txtHelpRight:          "Move the cursor right",
+	txtHelpUp:             "Move the cursor up",
+	txtHelpDown:           "Move the cursor down",
+	txtHelpSelect:         "Select or move, like a click on the cursor's square",
+	txtHelpNextPiece:      "Move the cursor to your next piece that can move",
+	txtHelpPrevPiece:      "Move the cursor to your previous piece that can move",
+	txtHelpJump:           "Jump to a square by name",
+	txtHelpUndoHeld:       "Take back your move before it is sent, with -send-delay",
+	txtHelpReady:          "Signal you are ready to start, when the game has a ready check",
+	txtHelpDraw:           "Offer a draw, or accept the opponent's offer",
+	txtHelpTakeback:       "Ask to take back your move, or accept the request",
+	txtHelpDecline:        "Decline the opponent's offer",
+	txtHelpResign:         "Resign",
+	txtHelpChat:           "Chat with the opponent",
+	txtHelpAnalyze:        "Open an analysis board on this position",
+	txtHelpThreats:        "Show or hide your hanging pieces",
+	txtHelpControl:        "Show or hide the squares the selected piece controls",
+	txtHelpFlip:           "Flip the board",
+	txtHelpTheme:          "Next theme",
+	txtHelpThemePicker:    "Choose a theme from a list",
+	txtHelpMute:           "Mute or unmute sounds",
+	txtHelpRedraw:         "Redraw the whole screen",
+	txtHelpCopy:           "Copy the move list to the clipboard",
+	txtHelpPicture:        "Save a picture of the board as SVG or text",
+	txtHelpExportPosition: "Export the position as a lichess link",
+	txtHelpExportGame:     "Export the game as a lichess link",
+}
+
+// languages are the built-in tables -lang chooses from, by language code.
+// A table need not be complete: a message it lacks is shown in English.
+var languages = map[string]map[textKey]string{
+	"en": defaultTexts,
+	"de": germanTexts,
+	"es": spanishTexts,
+}
+
+// languageNames lists the built-in languages for usage and errors.
+func languageNames() string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// texts is the table in use: the defaults, then the language's table,
+// then any overrides on top.
+var texts = maps.Clone(defaultTexts)
+
+// setLanguage switches the table in use to the built-in language lang,
+// dropping any overrides loaded before.
+func setLanguage(lang string) error {
+	table, ok := languages[lang]
+	if !ok {
+		return fmt.Errorf("unknown language %q, want one of %s", lang, languageNames())
+	}
+	texts = maps.Clone(defaultTexts)
+	maps.Copy(texts, table)
+	return nil
+}
+
+// tr returns the message for key, formatted with args as by fmt.Sprintf
+// when there are any.
+func tr(key textKey, args ...any) string {
+	text, ok := texts[key]
+	if !ok {
+		text = string(key) // A key missing from the defaults is a bug; show something
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// colorName is the name of color, "white" or "black", as shown to the
+// player.
+func colorName(color string) string {
+	if color == "black" {
+		return tr(txtBlack)
+	}
+	return tr(txtWhite)
+}
+
+// pieceNameKeys are the keys of the piece names, by kind.
+var pieceNameKeys = map[string]textKey{
+	"king":   txtPieceKing,
+	"queen":  txtPieceQueen,
+	"rook":   txtPieceRook,
+	"bishop": txtPieceBishop,
+	"knight": txtPieceKnight,
+	"pawn":   txtPiecePawn,
+}
+
+// pieceName is the name of a kind of piece, e.g. "knight", as shown to the
+// player.
+func pieceName(kind string) string {
+	return tr(pieceNameKeys[kind])
+}
+
+// textsPath returns where message overrides are read from, beside the
+// preferences file.
+func textsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "chessGo", "messages.json"), nil
+}
+
+// loadTexts applies the overrides in the messages file, a JSON object of
+// messages by key, e.g. {"banner": "Welcome to Club Chess!"}. A missing
+// file changes nothing. Unknown keys, and messages whose fmt verbs do not
+// match the default's, are errors, and then none of the file is used.
+func loadTexts() error {
+	path, err := textsPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var overrides map[textKey]string
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := checkTexts(overrides); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	maps.Copy(texts, overrides)
+	return nil
+}
+
+// checkTexts reports the first override with an unknown key or a
+// different number of fmt verbs from the default message.
+func checkTexts(overrides map[textKey]string) error {
+	for key, text := range overrides {
+		def, ok := defaultTexts[key]
+		if !ok {
+			return fmt.Errorf("unknown message %q", key)
+		}
+		if countVerbs(text) != countVerbs(def) {
+			return fmt.Errorf("message %q must take the same arguments as %q", key, def)
+		}
+	}
+	return nil
+}
+
+// sanLetterKeys are the keys of the piece letters, by the English letter
+// SAN uses.
+var sanLetterKeys = map[rune]textKey{
+	'K': txtLetterKing,
+	'Q': txtLetterQueen,
+	'R': txtLetterRook,
+	'B': txtLetterBishop,
+	'N': txtLetterKnight,
+}
+
+// localSAN shows a move in SAN with the piece letters of the language in
+// use, e.g. "Sxe5" for "Nxe5" in German.
+func localSAN(san string) string {
+	var sb strings.Builder
+	for _, r := range san {
+		if key, ok := sanLetterKeys[r]; ok {
+			sb.WriteString(tr(key))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// englishSAN turns a move typed with the piece letters of the language in
+// use back into SAN. Letters the language does not use are left alone.
+func englishSAN(san string) string {
+	var sb strings.Builder
+	for _, r := range san {
+		english := r
+		for letter, key := range sanLetterKeys {
+			if tr(key) == string(r) {
+				english = letter
+				break
+			}
+		}
+		sb.WriteRune(english)
+	}
+	return sb.String()
+}
+
+// countVerbs counts the fmt verbs in text, not counting "%%".
+func countVerbs(text string) int {
+	return strings.Count(strings.ReplaceAll(text, "%%", ""), "%")
+}
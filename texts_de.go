@@ -0,0 +1,270 @@
+package main
+
+// germanTexts is the German table, chosen with -lang de.
+var germanTexts = map[textKey]string{
+	txtBanner:   "Willkommen bei Go Chess!",
+	txtMenu:     "Möchtest du eine Partie hosten (h), einer beitreten (j) oder Partien bereitstellen (s)? ",
+	txtWelcome:  "Willkommen! Weiß ist am Zug. Mit 'c' wechselst du das Farbschema.",
+	txtWhite:    "Weiß",
+	txtBlack:    "Schwarz",
+	txtTurn:     "%s ist am Zug.",
+	txtNotTurn:  "Du bist nicht am Zug!",
+	txtOwnPiece: "Wähle eine deiner eigenen Figuren.",
+
+	txtSpectatorMove:     "Zuschauer können nicht ziehen.",
+	txtSpectatorAction:   "Zuschauer können das nicht.",
+	txtSpectatorChat:     "Zuschauer können nicht chatten.",
+	txtWatching:          "Du schaust zu. Esc beendet.",
+	txtWatchingFollow:    "Du schaust zu, das Brett so gedreht, wie %s es sieht; 'f' dreht es frei. Esc beendet.",
+	txtFollowStopped:     "Die Brettansicht folgt %s nicht mehr.",
+	txtMoveCancelled:     "Zug abgebrochen.",
+	txtMoveCancelledWhy:  "Zug abgebrochen: %v.",
+	txtTouchMove:         "Berührt, geführt: du musst die Figur auf %s ziehen.",
+	txtPromoteChoose:     "In welche Figur umwandeln? Drücke %s oder wähle mit den Pfeiltasten und Enter. Esc bricht ab.",
+	txtCancelled:         "Abgebrochen.",
+	txtNotOnAnalysis:     "Nicht auf dem Analysebrett.",
+	txtOpponentGone:      "Der Gegner ist nicht mehr verbunden.",
+	txtCannotDo:          "Das geht nicht: %v",
+	txtChatYou:           "Du: %s",
+	txtChatOpponent:      "Gegner: %s",
+	txtIgnoredOutOfTurn:  "Einen Zug ignoriert, den der Gegner außer der Reihe geschickt hat: %v",
+	txtIgnoredMove:       "Einen ungültigen Zug des Gegners ignoriert: %v",
+	txtIgnoredControl:    "Eine Steuernachricht des Gegners ignoriert: %v",
+	txtIgnoredPosition:   "Die vom Host geschickte Stellung ignoriert: %v",
+	txtIgnoredServer:     "Eine Nachricht des Servers ignoriert: %v",
+	txtIgnoredOpponent:   "Eine Nachricht des Gegners ignoriert: %v",
+	txtIgnoredOversized:  "Eine zu lange Nachricht des Gegners ignoriert.",
+	txtServerClosed:      "Der Server hat die Verbindung geschlossen.",
+	txtConnectionLost:    "Die Verbindung zum Gegner ist abgerissen.",
+	txtOpponentLeft:      "Der Gegner ist gegangen.",
+	txtLeftAborted:       "%s Partie abgebrochen.",
+	txtLeftYouWin:        "%s Du gewinnst.",
+	txtReadyWaiting:      "Drücke 'g', wenn du bereit bist; die Partie beginnt, sobald beide bereit sind.",
+	txtReadyToStart:      "Drücke 'g', wenn du bereit bist.",
+	txtReadyBoth:         "Beide Spieler sind bereit. %s ist am Zug.",
+	txtReadyYou:          "Du bist bereit.",
+	txtReadyOpponent:     "Der Gegner ist bereit: drücke 'g', wenn du es bist.",
+	txtPremovesCancelled: "Vorauszüge abgebrochen.",
+	txtPremoveCancelled:  "Vorauszug abgebrochen.",
+	txtPremovePick:       "Vorauszug: wähle das Zielfeld.",
+	txtPremoveQueued:     "Vorauszug %s vorgemerkt; nochmal klicken bricht ihn ab.",
+	txtPremovesQueued:    "Vorauszüge vorgemerkt: %s; ein Klick auf ein leeres Feld bricht sie ab.",
+	txtPremoveFailed:     "Vorauszug %s abgebrochen: %v.",
+	txtPremoveChainLost:  "Vorauszug %s abgebrochen: %v. Die übrigen Vorauszüge entfallen.",
+	txtHeldSending:       "%s wird in %s gesendet; Rücktaste nimmt ihn zurück.",
+	txtHeldSent:          "%s gesendet.",
+	txtHeldNone:          "Kein Zug wartet auf das Senden.",
+	txtHeldNoDelay:       "Züge werden sofort gesendet; starte mit -send-delay, um sie vor dem Senden zurückzunehmen.",
+	txtHeldTakenBack:     "%s zurückgenommen; er wurde nie gesendet.",
+
+	txtContinuing:           "Fortsetzung nach %d Zügen. %s ist am Zug.",
+	txtKeyboardHint:         "Keine Maus, Steuerung per Tastatur: Pfeiltasten oder hjkl bewegen, Enter oder Leertaste wählt, ? zeigt alle Tasten.",
+	txtKeyboardMode:         "Tastaturmodus",
+	txtDeliveryUnconfirmed:  "Zustellung unbestätigt!",
+	txtAnalysisNotSent:      "ANALYSE, Züge werden nicht gesendet",
+	txtThemeName:            "Farbschema: %s",
+	txtThemeHint:            "Mit 'c' wechselst du das Farbschema.",
+	txtThemePicker:          "Wähle ein Farbschema: Pfeiltasten oder j/k zeigen es an, Enter behält es, Esc bricht ab.",
+	txtThemePickerTitle:     "Farbschemata",
+	txtThemeChosen:          "Farbschema: %s.",
+	txtConfirm:              "%s (y/n)",
+	txtResignAsk:            "Die Partie aufgeben?",
+	txtResignQuitAsk:        "Aufgeben und beenden?",
+	txtAbortAsk:             "Die Partie abbrechen?",
+	txtChatPrompt:           "Sag: ",
+	txtJumpPrompt:           "Gehe zu Feld: ",
+	txtNoSuchSquare:         "Kein solches Feld: %s",
+	txtCursorOn:             "Cursor auf %s.",
+	txtCursorOnMovable:      "Cursor auf %s (%d von %d Figuren, die ziehen können).",
+	txtNoMovablePiece:       "Keine Figur kann ziehen.",
+	txtMobility:             "%s: %d Züge. Klicke auf ein Zielfeld.",
+	txtMobilityOne:          "%s: 1 Zug. Klicke auf ein Zielfeld.",
+	txtMobilityNone:         "%s: 0 Züge.",
+	txtMobilityPinned:       "%s: 0 Züge (gefesselt).",
+	txtThreatsShown:         "Deine ungedeckten Figuren werden angezeigt.",
+	txtThreatsHidden:        "Ungedeckte Figuren ausgeblendet.",
+	txtHeatmapShown:         "Felder nach Kontrolle schattiert: grün für Weiß, rot für Schwarz.",
+	txtHeatmapHidden:        "Kontrollschattierung ausgeblendet.",
+	txtControlShown:         "Die Felder, die die gewählte Figur kontrolliert, werden angezeigt.",
+	txtControlHidden:        "Kontrollierte Felder ausgeblendet.",
+	txtSoundMuted:           "Ton aus.",
+	txtSoundOn:              "Ton an.",
+	txtClipboardUnsupported: "Dieses Terminal unterstützt die Zwischenablage nicht.",
+	txtMoveListCopied:       "Zugliste in die Zwischenablage kopiert.",
+	txtPositionLinkCopied:   "Lichess-Link zur Stellung kopiert; er wird beim Beenden auch ausgegeben.",
+	txtPositionLinkLater:    "Der Lichess-Link zur Stellung wird beim Beenden ausgegeben.",
+	txtGameLinkCopied:       "Lichess-Link zur Partie kopiert; er wird beim Beenden auch ausgegeben.",
+	txtGameLinkLater:        "Der Lichess-Link zur Partie wird beim Beenden ausgegeben.",
+	txtScreenshotPrompt:     "Brett speichern unter (.svg oder .txt, Enter für ein neues SVG): ",
+	txtPrefsSaveFailed:      "Einstellungen konnten nicht gespeichert werden: %v",
+	txtAutosaveFailed:       "(Automatisches Speichern fehlgeschlagen: %v)",
+	txtOpponentConnected:    "Gegner verbunden",
+	txtNoContact:            "Seit %s kein Kontakt zum Gegner",
+	txtSpectatorCount:       "%d schauen zu",
+	txtClockStatus:          "Weiß %s, Schwarz %s",
+	txtClockDelay:           "(Verzögerung %s)",
+	txtHalfmoveClock:        "Halbzugzähler: %d/%d",
+	txtPositionRepeated:     "Stellung %d× wiederholt",
+	txtOutcomeQuit:          "%s. Esc beendet.",
+	txtPlayerControl:        "%s: %s.",
+	txtBotFailed:            "Der Bot von %s ist ausgefallen: %v",
+	txtWaitingReady:         "Warte, bis der Gegner bereit ist",
+	txtWaitingDraw:          "Warte auf eine Antwort auf dein Remisangebot",
+	txtWaitingTakeback:      "Warte auf eine Antwort auf deine Rücknahmebitte",
+	txtWaitingMove:          "Warte auf den Zug des Gegners",
+	txtHandicapKnight:       "Springervorgabe: Weiß spielt ohne den Springer auf b1.",
+	txtHandicapRook:         "Turmvorgabe: Weiß spielt ohne den Turm auf a1.",
+	txtHandicapQueen:        "Damenvorgabe: Weiß spielt ohne die Dame.",
+	txtHandicapPawnAndMove:  "Bauer und Zug: Schwarz spielt ohne den Bauern auf f7, und Weiß zieht zuerst.",
+	txtHandicapCustom:       "Vorgabepartie aus einer eigenen Stellung.",
+
+	txtPieceKing:   "König",
+	txtPieceQueen:  "Dame",
+	txtPieceRook:   "Turm",
+	txtPieceBishop: "Läufer",
+	txtPieceKnight: "Springer",
+	txtPiecePawn:   "Bauer",
+
+	txtAnalysisWelcome:   "Analysebrett. Beide Seiten dürfen ziehen; 'u' nimmt zurück, 'x' spiegelt den letzten Zug, 'e' bearbeitet ihn, 'H' schattiert die Kontrolle, Esc kehrt zur Partie zurück.",
+	txtLiveGame:          "Laufende Partie: %s",
+	txtNothingToTakeBack: "Nichts zurückzunehmen.",
+	txtTookBack:          "Einen Zug zurückgenommen.",
+	txtNoMirror:          "Kein Zug zum Spiegeln.",
+	txtCannotMirror:      "Spiegeln geht nicht: %v",
+	txtPlayPrompt:        "Ziehe: ",
+	txtCannotPlay:        "Ziehen geht nicht: %v",
+
+	txtReplayStart:   "Ausgangsstellung.",
+	txtReplayOver:    "Partie beendet: %s.",
+	txtReplayPlaying: "Wiedergabe (%v)",
+	txtReplayPaused:  "Angehalten",
+	txtReplayMove:    "Zug %d/%d",
+
+	txtLineStart:      "Variante %d von %d: %s. Spiele die Züge von %s.",
+	txtBookMove:       "Der Buchzug ist %s.",
+	txtBookReply:      "Buch: %s. Du bist am Zug.",
+	txtNotBookMove:    "%s ist nicht der Buchzug; das Buch spielt %s. Versuch es.",
+	txtLineDone:       "%s beendet mit %s (%d von %d Varianten fehlerfrei). 'n' übt die nächste Variante, Esc beendet.",
+	txtLineComplete:   "Variante beendet. 'n' übt die nächste Variante, Esc beendet.",
+	txtNoMistakes:     "keinem Fehler",
+	txtOneMistake:     "1 Fehler",
+	txtMistakes:       "%d Fehlern",
+	txtPuzzleStart:    "Aufgabe %d von %d: %s. %s ist am Zug.",
+	txtSolutionMove:   "Die Lösung spielt %s.",
+	txtNotTheSolution: "%s ist es nicht. Versuch es nochmal.",
+	txtCorrect:        "Richtig!",
+	txtReplies:        "%s antwortet %s.",
+	txtYourMove:       "Du bist am Zug.",
+	txtPuzzleSolved:   "%s gelöst (%d von %d fehlerfrei). 'n' stellt die nächste Aufgabe, Esc beendet.",
+	txtPuzzleDone:     "Gelöst. 'n' stellt die nächste Aufgabe, Esc beendet.",
+
+	txtResignedYou:       "Du hast aufgegeben.",
+	txtResignedOpponent:  "Der Gegner hat aufgegeben. Du gewinnst.",
+	txtAbortedYou:        "Du hast die Partie abgebrochen.",
+	txtAbortedOpponent:   "Der Gegner hat die Partie abgebrochen.",
+	txtDrawOfferYou:      "Du hast Remis angeboten.",
+	txtDrawOfferOpponent: "Der Gegner bietet Remis an: 'd' nimmt an, 'n' lehnt ab.",
+	txtTakebackYou:       "Du hast gebeten, deinen letzten Zug zurückzunehmen.",
+	txtTakebackOpponent:  "Der Gegner möchte seinen letzten Zug zurücknehmen: 'u' erlaubt es, 'n' lehnt ab.",
+	txtDeclinedYou:       "Angebot abgelehnt.",
+	txtDeclinedOpponent:  "Der Gegner hat dein Angebot abgelehnt.",
+	txtNoOffer:           "Es gibt kein Angebot zum Ablehnen.",
+	txtDrawAgreed:        "Remis vereinbart.",
+	txtTakenBack:         "Der letzte Zug wurde zurückgenommen.",
+
+	txtCheck:             "Schach!",
+	txtCheckmate:         "Schachmatt! %s gewinnt. Esc beendet.",
+	txtStalemate:         "Patt! Die Partie endet remis. Esc beendet.",
+	txtKingTaken:         "Der König von %s ist geschlagen! %s gewinnt. Esc beendet.",
+	txtKingOfTheHill:     "Der König von %s hat das Zentrum erreicht und gewinnt! Esc beendet.",
+	txtThirdCheck:        "Drittes Schach! %s gewinnt. Esc beendet.",
+	txtDeadPosition:      "Keine Seite kann mattsetzen. Die Partie endet remis. Esc beendet.",
+	txtSeventyFiveMoves:  "Fünfundsiebzig Züge ohne Schlagen oder Bauernzug. Die Partie endet remis. Esc beendet.",
+	txtFivefold:          "Die Stellung kam fünfmal vor. Die Partie endet remis. Esc beendet.",
+	txtMoveLimitDraw:     "Die Partie hat das Limit von %d Zügen erreicht. Remis. Esc beendet.",
+	txtMoveLimitMaterial: "Die Partie hat das Limit von %d Zügen mit Material %d zu %d erreicht. Ergebnis %s. Esc beendet.",
+	txtTimeRanOut:        "Die Zeit von %s ist abgelaufen.",
+	txtTimeLoneKing:      "Die Zeit von %s ist abgelaufen, aber ein einzelner König kann nicht gewinnen. Die Partie endet remis.",
+	txtTimeYouLose:       "Deine Zeit ist abgelaufen. Du verlierst.",
+	txtTimeOpponentLost:  "Die Zeit des Gegners ist abgelaufen. Du gewinnst.",
+	txtIdleDrawn:         "%s war zu lange untätig. Die Partie endet remis.",
+	txtIdleYouLose:       "Du warst zu lange untätig. Du verlierst.",
+	txtIdleYouWin:        "%s war zu lange untätig. Du gewinnst.",
+	txtIdleResult:        "%s war zu lange untätig. Ergebnis %s.",
+
+	txtHostIP:        "IP-Adresse des Hosts: ",
+	txtHostFailed:    "Partie konnte nicht gehostet werden: %v",
+	txtJoinFailed:    "Beitritt zur Partie fehlgeschlagen: %v",
+	txtInvalidChoice: "Ungültige Auswahl.",
+
+	txtWhiteWins:       "Weiß gewinnt",
+	txtBlackWins:       "Schwarz gewinnt",
+	txtDrawn:           "Remis",
+	txtNoResult:        "Kein Ergebnis",
+	txtEndAbandoned:    "Verlassen",
+	txtEndAborted:      "Abgebrochen",
+	txtEndAdjudication: "Entscheidung",
+	txtEndAgreement:    "Vereinbarung",
+	txtEndCheckmate:    "Schachmatt",
+	txtEndFivefold:     "Fünffache Wiederholung",
+	txtEndForfeit:      "Kampflos",
+	txtEndDeadPosition: "Ungenügendes Material",
+	txtEndKingCaptured: "König geschlagen",
+	txtEndKingOfHill:   "König im Zentrum",
+	txtEndThreeCheck:   "Drei Schachgebote",
+	txtEndMoveLimit:    "Zuglimit",
+	txtEndResignation:  "Aufgabe",
+	txtEndSeventyFive:  "75-Züge-Regel",
+	txtEndStalemate:    "Patt",
+	txtEndTimeForfeit:  "Zeitüberschreitung",
+	txtGameOverChoices: "(s) PGN speichern  (m) Zusammenfassung speichern  (a) Analysieren  (q) Beenden  (Esc) Schließen",
+	txtMaterial:        "Material: Weiß %s, Schwarz %s",
+	txtSummaryMoves:    "Züge: %d",
+	txtSummaryCaptures: "Geschlagen: Weiß %d, Schwarz %d",
+	txtSummaryChecks:   "Schachgebote: Weiß %d, Schwarz %d",
+	txtSummarySwing:    "Größter Umschwung: %s (%s %s)",
+	txtSummaryTime:     "Zeit: Weiß %s übrig (%s verbraucht), Schwarz %s übrig (%s verbraucht)",
+	txtPawn:            "Bauer",
+	txtPawns:           "Bauern",
+	txtSaveFailed:      "Speichern fehlgeschlagen: %v",
+	txtSaved:           "%s gespeichert.",
+
+	txtLetterKing:   "K",
+	txtLetterQueen:  "D",
+	txtLetterRook:   "T",
+	txtLetterBishop: "L",
+	txtLetterKnight: "S",
+
+	txtHelpTitle:          "Tasten",
+	txtHelpClose:          "Eine beliebige Taste schließt die Hilfe.",
+	txtHelpQuit:           "Beenden; vorher abbrechen oder aufgeben, wenn die Partie läuft",
+	txtHelpQuitNow:        "Sofort beenden, ohne Nachfrage abbrechen oder aufgeben",
+	txtHelpHelp:           "Diese Hilfe zeigen",
+	txtHelpLeft:           "Cursor nach links",
+	txtHelpRight:          "Cursor nach rechts",
+	txtHelpUp:             "Cursor nach oben",
+	txtHelpDown:           "Cursor nach unten",
+	txtHelpSelect:         "Wählen oder ziehen, wie ein Klick auf das Cursorfeld",
+	txtHelpNextPiece:      "Cursor zur nächsten Figur, die ziehen kann",
+	txtHelpPrevPiece:      "Cursor zur vorigen Figur, die ziehen kann",
+	txtHelpJump:           "Zu einem Feld nach Namen springen",
+	txtHelpUndoHeld:       "Deinen Zug vor dem Senden zurücknehmen, mit -send-delay",
+	txtHelpReady:          "Bereitschaft melden, wenn die Partie darauf wartet",
+	txtHelpDraw:           "Remis anbieten oder das Angebot des Gegners annehmen",
+	txtHelpTakeback:       "Zugrücknahme erbitten oder die Bitte annehmen",
+	txtHelpDecline:        "Das Angebot des Gegners ablehnen",
+	txtHelpResign:         "Aufgeben",
+	txtHelpChat:           "Mit dem Gegner chatten",
+	txtHelpAnalyze:        "Ein Analysebrett mit dieser Stellung öffnen",
+	txtHelpThreats:        "Deine ungedeckten Figuren zeigen oder verbergen",
+	txtHelpControl:        "Die von der gewählten Figur kontrollierten Felder zeigen oder verbergen",
+	txtHelpFlip:           "Brett drehen",
+	txtHelpTheme:          "Nächstes Farbschema",
+	txtHelpThemePicker:    "Ein Farbschema aus einer Liste wählen",
+	txtHelpMute:           "Töne aus- oder einschalten",
+	txtHelpRedraw:         "Den ganzen Bildschirm neu zeichnen",
+	txtHelpCopy:           "Die Zugliste in die Zwischenablage kopieren",
+	txtHelpPicture:        "Ein Bild des Bretts als SVG oder Text speichern",
+	txtHelpExportPosition: "Die Stellung als lichess-Link exportieren",
+	txtHelpExportGame:     "Die Partie als lichess-Link exportieren",
+}
@@ -0,0 +1,270 @@
+package main
+
+// spanishTexts is the Spanish table, chosen with -lang es.
+var spanishTexts = map[textKey]string{
+	txtBanner:   "¡Bienvenido a Go Chess!",
+	txtMenu:     "¿Quieres crear una partida (h), unirte a una (j) o servir partidas (s)? ",
+	txtWelcome:  "¡Bienvenido! Juegan las blancas. Pulsa 'c' para cambiar el tema.",
+	txtWhite:    "Blancas",
+	txtBlack:    "Negras",
+	txtTurn:     "Turno de %s.",
+	txtNotTurn:  "¡No es tu turno!",
+	txtOwnPiece: "Elige una de tus piezas.",
+
+	txtSpectatorMove:     "Los espectadores no pueden mover.",
+	txtSpectatorAction:   "Los espectadores no pueden hacer eso.",
+	txtSpectatorChat:     "Los espectadores no pueden chatear.",
+	txtWatching:          "Mirando. Esc para salir.",
+	txtWatchingFollow:    "Mirando con el tablero como lo ven las %s; 'f' lo gira libremente. Esc para salir.",
+	txtFollowStopped:     "El tablero ya no sigue la vista de las %s.",
+	txtMoveCancelled:     "Jugada cancelada.",
+	txtMoveCancelledWhy:  "Jugada cancelada: %v.",
+	txtTouchMove:         "Pieza tocada, pieza movida: debes mover la pieza de %s.",
+	txtPromoteChoose:     "¿A qué pieza coronar? Pulsa %s, o elige con las flechas y Enter. Esc cancela.",
+	txtCancelled:         "Cancelado.",
+	txtNotOnAnalysis:     "No en el tablero de análisis.",
+	txtOpponentGone:      "El rival ya no está conectado.",
+	txtCannotDo:          "No se puede: %v",
+	txtChatYou:           "Tú: %s",
+	txtChatOpponent:      "Rival: %s",
+	txtIgnoredOutOfTurn:  "Se ignoró una jugada que el rival envió fuera de turno: %v",
+	txtIgnoredMove:       "Se ignoró una jugada no válida del rival: %v",
+	txtIgnoredControl:    "Se ignoró un mensaje de control del rival: %v",
+	txtIgnoredPosition:   "Se ignoró la posición enviada por el anfitrión: %v",
+	txtIgnoredServer:     "Se ignoró un mensaje del servidor: %v",
+	txtIgnoredOpponent:   "Se ignoró un mensaje del rival: %v",
+	txtIgnoredOversized:  "Se ignoró un mensaje demasiado largo del rival.",
+	txtServerClosed:      "El servidor cerró la conexión.",
+	txtConnectionLost:    "Se perdió la conexión con el rival.",
+	txtOpponentLeft:      "El rival se ha ido.",
+	txtLeftAborted:       "%s Partida anulada.",
+	txtLeftYouWin:        "%s Ganas.",
+	txtReadyWaiting:      "Pulsa 'g' cuando estés listo; la partida empieza cuando lo estén los dos.",
+	txtReadyToStart:      "Pulsa 'g' cuando estés listo para empezar.",
+	txtReadyBoth:         "Los dos jugadores están listos. Juegan las %s.",
+	txtReadyYou:          "Estás listo.",
+	txtReadyOpponent:     "El rival está listo: pulsa 'g' cuando lo estés tú.",
+	txtPremovesCancelled: "Premovimientos cancelados.",
+	txtPremoveCancelled:  "Premovimiento cancelado.",
+	txtPremovePick:       "Premovimiento: elige la casilla de destino.",
+	txtPremoveQueued:     "Premovimiento %s en cola; haz clic otra vez para cancelarlo.",
+	txtPremovesQueued:    "Premovimientos en cola: %s; haz clic en una casilla vacía para cancelarlos.",
+	txtPremoveFailed:     "Premovimiento %s cancelado: %v.",
+	txtPremoveChainLost:  "Premovimiento %s cancelado: %v. Se descarta el resto de la cadena.",
+	txtHeldSending:       "Enviando %s en %s; Retroceso la deshace.",
+	txtHeldSent:          "%s enviada.",
+	txtHeldNone:          "No hay ninguna jugada esperando a enviarse.",
+	txtHeldNoDelay:       "Las jugadas se envían al momento; usa -send-delay para poder deshacerlas antes de enviarlas.",
+	txtHeldTakenBack:     "%s deshecha; nunca se envió.",
+
+	txtContinuing:           "Se continúa tras %d jugadas. Juegan las %s.",
+	txtKeyboardHint:         "Sin ratón, se usa el teclado: las flechas o hjkl mueven, Enter o espacio selecciona, ? muestra todas las teclas.",
+	txtKeyboardMode:         "Modo teclado",
+	txtDeliveryUnconfirmed:  "¡Entrega sin confirmar!",
+	txtAnalysisNotSent:      "ANÁLISIS, las jugadas no se envían",
+	txtThemeName:            "Tema: %s",
+	txtThemeHint:            "Pulsa 'c' para cambiar el tema.",
+	txtThemePicker:          "Elige un tema: las flechas o j/k lo muestran, Enter lo conserva, Esc cancela.",
+	txtThemePickerTitle:     "Temas",
+	txtThemeChosen:          "Tema: %s.",
+	txtConfirm:              "%s (y/n)",
+	txtResignAsk:            "¿Abandonar la partida?",
+	txtResignQuitAsk:        "¿Abandonar y salir?",
+	txtAbortAsk:             "¿Anular la partida?",
+	txtChatPrompt:           "Di: ",
+	txtJumpPrompt:           "Ir a la casilla: ",
+	txtNoSuchSquare:         "No existe la casilla %s",
+	txtCursorOn:             "Cursor en %s.",
+	txtCursorOnMovable:      "Cursor en %s (%d de %d piezas que pueden mover).",
+	txtNoMovablePiece:       "Ninguna pieza puede mover.",
+	txtMobility:             "%s: %d jugadas. Haz clic en una casilla de destino.",
+	txtMobilityOne:          "%s: 1 jugada. Haz clic en una casilla de destino.",
+	txtMobilityNone:         "%s: 0 jugadas.",
+	txtMobilityPinned:       "%s: 0 jugadas (clavada).",
+	txtThreatsShown:         "Se muestran tus piezas indefensas.",
+	txtThreatsHidden:        "Piezas indefensas ocultas.",
+	txtHeatmapShown:         "Casillas sombreadas según su control: verde para las blancas, rojo para las negras.",
+	txtHeatmapHidden:        "Sombreado de control oculto.",
+	txtControlShown:         "Se muestran las casillas que controla la pieza seleccionada.",
+	txtControlHidden:        "Casillas controladas ocultas.",
+	txtSoundMuted:           "Sonido silenciado.",
+	txtSoundOn:              "Sonido activado.",
+	txtClipboardUnsupported: "Este terminal no admite el portapapeles.",
+	txtMoveListCopied:       "Lista de jugadas copiada al portapapeles.",
+	txtPositionLinkCopied:   "Enlace de Lichess a la posición copiado; también se muestra al salir.",
+	txtPositionLinkLater:    "El enlace de Lichess a la posición se mostrará al salir.",
+	txtGameLinkCopied:       "Enlace de Lichess a la partida copiado; también se muestra al salir.",
+	txtGameLinkLater:        "El enlace de Lichess a la partida se mostrará al salir.",
+	txtScreenshotPrompt:     "Guardar el tablero en (.svg o .txt, Enter para un SVG nuevo): ",
+	txtPrefsSaveFailed:      "No se pudieron guardar las preferencias: %v",
+	txtAutosaveFailed:       "(Falló el guardado automático: %v)",
+	txtOpponentConnected:    "Rival conectado",
+	txtNoContact:            "Sin contacto con el rival desde hace %s",
+	txtSpectatorCount:       "%d mirando",
+	txtClockStatus:          "Blancas %s, negras %s",
+	txtClockDelay:           "(retardo %s)",
+	txtHalfmoveClock:        "Contador de medias jugadas: %d/%d",
+	txtPositionRepeated:     "Posición repetida %d×",
+	txtOutcomeQuit:          "%s. Pulsa Esc para salir.",
+	txtPlayerControl:        "%s: %s.",
+	txtBotFailed:            "Falló el bot de las %s: %v",
+	txtWaitingReady:         "Esperando a que el rival esté listo",
+	txtWaitingDraw:          "Esperando respuesta a tu oferta de tablas",
+	txtWaitingTakeback:      "Esperando respuesta a tu petición de deshacer",
+	txtWaitingMove:          "Esperando la jugada del rival",
+	txtHandicapKnight:       "Ventaja de caballo: las blancas juegan sin el caballo de b1.",
+	txtHandicapRook:         "Ventaja de torre: las blancas juegan sin la torre de a1.",
+	txtHandicapQueen:        "Ventaja de dama: las blancas juegan sin la dama.",
+	txtHandicapPawnAndMove:  "Peón y salida: las negras juegan sin el peón de f7 y las blancas salen primero.",
+	txtHandicapCustom:       "Partida con ventaja desde una posición propia.",
+
+	txtPieceKing:   "Rey",
+	txtPieceQueen:  "Dama",
+	txtPieceRook:   "Torre",
+	txtPieceBishop: "Alfil",
+	txtPieceKnight: "Caballo",
+	txtPiecePawn:   "Peón",
+
+	txtAnalysisWelcome:   "Tablero de análisis. Cualquier bando puede mover; 'u' deshace, 'x' refleja la última jugada, 'e' la edita, 'H' sombrea el control, Esc vuelve a la partida.",
+	txtLiveGame:          "Partida en curso: %s",
+	txtNothingToTakeBack: "No hay nada que deshacer.",
+	txtTookBack:          "Jugada deshecha.",
+	txtNoMirror:          "No hay jugada que reflejar.",
+	txtCannotMirror:      "No se puede reflejar: %v",
+	txtPlayPrompt:        "Juega: ",
+	txtCannotPlay:        "No se puede jugar: %v",
+
+	txtReplayStart:   "Posición inicial.",
+	txtReplayOver:    "Fin de la partida: %s.",
+	txtReplayPlaying: "Reproduciendo (%v)",
+	txtReplayPaused:  "En pausa",
+	txtReplayMove:    "Jugada %d/%d",
+
+	txtLineStart:      "Línea %d de %d: %s. Juega las jugadas de las %s.",
+	txtBookMove:       "La jugada del libro es %s.",
+	txtBookReply:      "Libro: %s. Te toca.",
+	txtNotBookMove:    "%s no es la jugada del libro; el libro juega %s. Pruébala.",
+	txtLineDone:       "%s completada con %s (%d de %d líneas sin errores). 'n' practica la siguiente línea, Esc sale.",
+	txtLineComplete:   "Línea completada. 'n' practica la siguiente línea, Esc sale.",
+	txtNoMistakes:     "ningún error",
+	txtOneMistake:     "1 error",
+	txtMistakes:       "%d errores",
+	txtPuzzleStart:    "Problema %d de %d: %s. Juegan las %s.",
+	txtSolutionMove:   "La solución juega %s.",
+	txtNotTheSolution: "%s no es. Inténtalo otra vez.",
+	txtCorrect:        "¡Correcto!",
+	txtReplies:        "Las %s responden %s.",
+	txtYourMove:       "Te toca.",
+	txtPuzzleSolved:   "%s resuelto (%d de %d sin errores). 'n' plantea el siguiente problema, Esc sale.",
+	txtPuzzleDone:     "Resuelto. 'n' plantea el siguiente problema, Esc sale.",
+
+	txtResignedYou:       "Has abandonado.",
+	txtResignedOpponent:  "El rival abandona. Ganas.",
+	txtAbortedYou:        "Has anulado la partida.",
+	txtAbortedOpponent:   "El rival ha anulado la partida.",
+	txtDrawOfferYou:      "Has ofrecido tablas.",
+	txtDrawOfferOpponent: "El rival ofrece tablas: 'd' acepta, 'n' rechaza.",
+	txtTakebackYou:       "Has pedido deshacer tu última jugada.",
+	txtTakebackOpponent:  "El rival pide deshacer su última jugada: 'u' acepta, 'n' rechaza.",
+	txtDeclinedYou:       "Oferta rechazada.",
+	txtDeclinedOpponent:  "El rival ha rechazado tu oferta.",
+	txtNoOffer:           "No hay ninguna oferta que rechazar.",
+	txtDrawAgreed:        "Tablas acordadas.",
+	txtTakenBack:         "Se deshizo la última jugada.",
+
+	txtCheck:             "¡Jaque!",
+	txtCheckmate:         "¡Jaque mate! Ganan las %s. Pulsa Esc para salir.",
+	txtStalemate:         "¡Ahogado! La partida termina en tablas. Pulsa Esc para salir.",
+	txtKingTaken:         "¡Han capturado el rey de las %s! Ganan las %s. Pulsa Esc para salir.",
+	txtKingOfTheHill:     "¡El rey de las %s llegó al centro y gana! Pulsa Esc para salir.",
+	txtThirdCheck:        "¡Tercer jaque! Ganan las %s. Pulsa Esc para salir.",
+	txtDeadPosition:      "Ningún bando puede dar mate. La partida termina en tablas. Pulsa Esc para salir.",
+	txtSeventyFiveMoves:  "Setenta y cinco jugadas sin capturas ni movimientos de peón. La partida termina en tablas. Pulsa Esc para salir.",
+	txtFivefold:          "La posición se ha repetido cinco veces. La partida termina en tablas. Pulsa Esc para salir.",
+	txtMoveLimitDraw:     "La partida llegó al límite de %d jugadas. Tablas. Pulsa Esc para salir.",
+	txtMoveLimitMaterial: "La partida llegó al límite de %d jugadas con material %d a %d. Resultado %s. Pulsa Esc para salir.",
+	txtTimeRanOut:        "Se acabó el tiempo de las %s.",
+	txtTimeLoneKing:      "Se acabó el tiempo de las %s, pero un rey solo no puede ganar. La partida termina en tablas.",
+	txtTimeYouLose:       "Se acabó tu tiempo. Pierdes.",
+	txtTimeOpponentLost:  "Se acabó el tiempo del rival. Ganas.",
+	txtIdleDrawn:         "Las %s estuvieron inactivas demasiado tiempo. Tablas.",
+	txtIdleYouLose:       "Estuviste inactivo demasiado tiempo. Pierdes.",
+	txtIdleYouWin:        "Las %s estuvieron inactivas demasiado tiempo. Ganas.",
+	txtIdleResult:        "Las %s estuvieron inactivas demasiado tiempo. Resultado %s.",
+
+	txtHostIP:        "Dirección IP del anfitrión: ",
+	txtHostFailed:    "No se pudo crear la partida: %v",
+	txtJoinFailed:    "No se pudo unir a la partida: %v",
+	txtInvalidChoice: "Opción no válida.",
+
+	txtWhiteWins:       "Ganan las blancas",
+	txtBlackWins:       "Ganan las negras",
+	txtDrawn:           "Tablas",
+	txtNoResult:        "Sin resultado",
+	txtEndAbandoned:    "Abandono de la conexión",
+	txtEndAborted:      "Anulada",
+	txtEndAdjudication: "Adjudicación",
+	txtEndAgreement:    "Acuerdo",
+	txtEndCheckmate:    "Jaque mate",
+	txtEndFivefold:     "Quíntuple repetición",
+	txtEndForfeit:      "Incomparecencia",
+	txtEndDeadPosition: "Material insuficiente",
+	txtEndKingCaptured: "Rey capturado",
+	txtEndKingOfHill:   "Rey de la colina",
+	txtEndThreeCheck:   "Tres jaques",
+	txtEndMoveLimit:    "Límite de jugadas",
+	txtEndResignation:  "Abandono",
+	txtEndSeventyFive:  "Regla de las 75 jugadas",
+	txtEndStalemate:    "Ahogado",
+	txtEndTimeForfeit:  "Tiempo agotado",
+	txtGameOverChoices: "(s) Guardar PGN  (m) Guardar resumen  (a) Analizar  (q) Salir  (Esc) Cerrar",
+	txtMaterial:        "Material: blancas %s, negras %s",
+	txtSummaryMoves:    "Jugadas: %d",
+	txtSummaryCaptures: "Capturas: blancas %d, negras %d",
+	txtSummaryChecks:   "Jaques: blancas %d, negras %d",
+	txtSummarySwing:    "Mayor vuelco: %s (%s %s)",
+	txtSummaryTime:     "Tiempo: blancas %s restante (%s usado), negras %s restante (%s usado)",
+	txtPawn:            "peón",
+	txtPawns:           "peones",
+	txtSaveFailed:      "No se pudo guardar: %v",
+	txtSaved:           "Guardado en %s.",
+
+	txtLetterKing:   "R",
+	txtLetterQueen:  "D",
+	txtLetterRook:   "T",
+	txtLetterBishop: "A",
+	txtLetterKnight: "C",
+
+	txtHelpTitle:          "Teclas",
+	txtHelpClose:          "Cualquier tecla cierra esta ayuda.",
+	txtHelpQuit:           "Salir; antes anula o abandona si la partida sigue",
+	txtHelpQuitNow:        "Salir al momento, anulando o abandonando sin preguntar",
+	txtHelpHelp:           "Mostrar esta ayuda",
+	txtHelpLeft:           "Mover el cursor a la izquierda",
+	txtHelpRight:          "Mover el cursor a la derecha",
+	txtHelpUp:             "Mover el cursor arriba",
+	txtHelpDown:           "Mover el cursor abajo",
+	txtHelpSelect:         "Elegir o mover, como un clic en la casilla del cursor",
+	txtHelpNextPiece:      "Llevar el cursor a tu siguiente pieza que puede mover",
+	txtHelpPrevPiece:      "Llevar el cursor a tu anterior pieza que puede mover",
+	txtHelpJump:           "Saltar a una casilla por su nombre",
+	txtHelpUndoHeld:       "Deshacer tu jugada antes de enviarla, con -send-delay",
+	txtHelpReady:          "Indicar que estás listo, cuando la partida lo pide",
+	txtHelpDraw:           "Ofrecer tablas o aceptar la oferta del rival",
+	txtHelpTakeback:       "Pedir deshacer tu jugada o aceptar la petición",
+	txtHelpDecline:        "Rechazar la oferta del rival",
+	txtHelpResign:         "Abandonar",
+	txtHelpChat:           "Chatear con el rival",
+	txtHelpAnalyze:        "Abrir un tablero de análisis con esta posición",
+	txtHelpThreats:        "Mostrar u ocultar tus piezas colgadas",
+	txtHelpControl:        "Mostrar u ocultar las casillas que controla la pieza elegida",
+	txtHelpFlip:           "Girar el tablero",
+	txtHelpTheme:          "Tema siguiente",
+	txtHelpThemePicker:    "Elegir un tema de una lista",
+	txtHelpMute:           "Silenciar o activar los sonidos",
+	txtHelpRedraw:         "Redibujar toda la pantalla",
+	txtHelpCopy:           "Copiar la lista de jugadas al portapapeles",
+	txtHelpPicture:        "Guardar una imagen del tablero como SVG o texto",
+	txtHelpExportPosition: "Exportar la posición como enlace de lichess",
+	txtHelpExportGame:     "Exportar la partida como enlace de lichess",
+}
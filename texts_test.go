@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+// TestLanguagesMatchDefaults checks that every built-in language
+// translates every message, with the fmt verbs the message takes.
+func TestLanguagesMatchDefaults(t *testing.T) {
+	for lang, table := range languages {
+		for key, text := range defaultTexts {
+			translated, ok := table[key]
+			if !ok {
+				t.Errorf("%s: no %q", lang, key)
+				continue
+			}
+			if countVerbs(translated) != countVerbs(text) {
+				t.Errorf("%s: %q has %d fmt verbs, want %d", lang, key, countVerbs(translated), countVerbs(text))
+			}
+		}
+		for key := range table {
+			if _, ok := defaultTexts[key]; !ok {
+				t.Errorf("%s: unknown key %q", lang, key)
+			}
+		}
+	}
+}
+
+// TestLocalizedMessages checks that messages built from parts, here a
+// piece name and a color, come out in the language in use.
+func TestLocalizedMessages(t *testing.T) {
+	t.Cleanup(func() { setLanguage("en") })
+	if err := setLanguage("de"); err != nil {
+		t.Fatal(err)
+	}
+	g := newTestGame(t, "4k3/8/8/8/8/8/8/1N2K3 w - - 0 1")
+	if got, want := g.mobility(7, 1), "Springer: 3 Züge. Klicke auf ein Zielfeld."; got != want {
+		t.Errorf("mobility = %q, want %q", got, want)
+	}
+	g.playerColor = "white"
+	g.currentPlayer = "black"
+	if got, want := g.waitingStatus()[2:], "Warte auf den Zug des Gegners"; got != want {
+		t.Errorf("waitingStatus = %q, want %q", got, want)
+	}
+}
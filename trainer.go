@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// openingLine is one line to drill: a name and its moves from the starting
+// position, in wire format.
+type openingLine struct {
+	name  string
+	moves []string
+	san   []string // The same moves in SAN, for feedback
+}
+
+// loadOpeningLines reads the lines to drill from a file with one line per
+// row, a name, a colon and the moves in SAN, e.g.
+//
+//	Ruy Lopez: 1. e4 e5 2. Nf3 Nc6 3. Bb5
+//
+// Move numbers are optional. Blank rows and rows starting with '#' are
+// ignored. Every line is played through, so a mistyped move is reported
+// with its row rather than found mid-drill.
+func loadOpeningLines(path string) ([]openingLine, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []openingLine
+	scanner := bufio.NewScanner(f)
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, moves, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want a name, a colon and the moves", row)
+		}
+		line := openingLine{name: strings.TrimSpace(name)}
+		g := NewGame()
+		for _, token := range strings.Fields(moves) {
+			if isMoveNumber(token) {
+				continue
+			}
+			moveStr, ok := g.parseSAN(token)
+			if !ok {
+				return nil, fmt.Errorf("line %d: %q is not a legal move in %s", row, token, line.name)
+			}
+			g.ApplyAlgebraic(moveStr, g.currentPlayer)
+			line.moves = append(line.moves, moveStr)
+			line.san = append(line.san, g.sanHistory[len(g.sanHistory)-1])
+		}
+		if len(line.moves) == 0 {
+			return nil, fmt.Errorf("line %d: %s has no moves", row, line.name)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: no lines to drill", path)
+	}
+	return lines, nil
+}
+
+// isMoveNumber reports whether token is a move number such as "3." or
+// "3...".
+func isMoveNumber(token string) bool {
+	digits := strings.TrimRight(token, ".")
+	return digits != token && digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// trainer is the state of a drill: the lines in the order they are drilled
+// and how the player is doing.
+type trainer struct {
+	lines    []openingLine
+	color    string // The side the player plays; the book plays the other
+	line     int    // Index into lines of the line being drilled
+	ply      int    // Moves of the line played so far
+	mistakes int    // Wrong moves in the current line
+	clean    int    // Lines finished without a mistake
+	done     int    // Lines finished
+}
+
+// runTrainer drills lines on g, in a random order if shuffle is set. The
+// book plays the moves of the side that is not color; the player must find
+// the rest. A wrong move is taken back and the book move named. 's' shows
+// the expected move, 'n' skips to the next line and Esc quits.
+func runTrainer(g *Game, lines []openingLine, color string, shuffle bool) {
+	t := &trainer{lines: lines, color: color}
+	if shuffle {
+		rand.Shuffle(len(t.lines), func(i, j int) { t.lines[i], t.lines[j] = t.lines[j], t.lines[i] })
+	}
+	g.autosaveDir = "" // Drills are not games worth keeping
+	g.flipped = color == "black"
+	t.start(g)
+
+	for {
+		g.drawBoard()
+		switch ev := termbox.PollEvent(); ev.Type {
+		case termbox.EventInterrupt:
+			if shuttingDown.Load() {
+				return
+			}
+		case termbox.EventKey:
+			switch {
+			case ev.Key == termbox.KeyEsc:
+				return
+			case ev.Key == termbox.KeyEnter || ev.Key == termbox.KeySpace:
+				t.click(g)
+			case ev.Key == termbox.KeyArrowLeft || ev.Ch == 'h':
+				g.moveCursor(-1, 0)
+			case ev.Key == termbox.KeyArrowRight || ev.Ch == 'l':
+				g.moveCursor(1, 0)
+			case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+				g.moveCursor(0, -1)
+			case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+				g.moveCursor(0, 1)
+			case ev.Ch == 's' || ev.Ch == 'S':
+				if t.ply < len(t.current().moves) {
+					g.message = tr(txtBookMove, localSAN(t.current().san[t.ply]))
+				}
+			case ev.Ch == 'n' || ev.Ch == 'N':
+				t.next(g)
+			case ev.Ch == 'c' || ev.Ch == 'C':
+				g.cycleTheme()
+			case ev.Ch == 'f' || ev.Ch == 'F':
+				g.flipped = !g.flipped
+			case ev.Key == termbox.KeyCtrlL:
+				g.repaint = true
+			}
+		case termbox.EventMouse:
+			if g.wheelTheme(ev) {
+				break
+			}
+			g.cursorX, g.cursorY = g.screenToSquare(ev.MouseX, ev.MouseY)
+			if ev.Key == termbox.MouseLeft {
+				t.click(g)
+			}
+		case termbox.EventError:
+			panic(ev.Err)
+		}
+	}
+}
+
+// current is the line being drilled.
+func (t *trainer) current() openingLine {
+	return t.lines[t.line]
+}
+
+// start sets up the current line from the starting position and plays the
+// book's first move if the player has black.
+func (t *trainer) start(g *Game) {
+	g.copyPosition(NewGame())
+	g.selectedX, g.selectedY = -1, -1
+	g.legalMoves = make(map[string]moveKind)
+	g.inputMode = modeNormal
+	t.ply, t.mistakes = 0, 0
+	g.message = tr(txtLineStart, t.line+1, len(t.lines), t.current().name, colorName(t.color))
+	t.playBook(g)
+}
+
+// playBook plays the book's reply, if the line has one, and finishes the
+// line once it runs out.
+func (t *trainer) playBook(g *Game) {
+	line := t.current()
+	if t.ply < len(line.moves) && g.currentPlayer != t.color {
+		g.ApplyAlgebraic(line.moves[t.ply], g.currentPlayer)
+		g.message = tr(txtBookReply, moveNumber(t.ply, opponent(t.color))+localSAN(line.san[t.ply]))
+		t.ply++
+	}
+	if t.ply < len(line.moves) {
+		return
+	}
+	t.done++
+	result := tr(txtNoMistakes)
+	switch t.mistakes {
+	case 0:
+		t.clean++
+	case 1:
+		result = tr(txtOneMistake)
+	default:
+		result = tr(txtMistakes, t.mistakes)
+	}
+	g.message = tr(txtLineDone, line.name, result, t.clean, t.done)
+}
+
+// click handles a click, or Enter, on the cursor's square: selecting a
+// piece, or playing a move and checking it against the book.
+func (t *trainer) click(g *Game) {
+	line := t.current()
+	if t.ply >= len(line.moves) {
+		g.message = tr(txtLineComplete)
+		return
+	}
+	before := &Game{}
+	before.copyPosition(g)
+	moveStr := g.handleMouseClick(t.color)
+	if moveStr == "" {
+		return
+	}
+	if expected := line.moves[t.ply]; moveStr != expected {
+		played := g.sanHistory[len(g.sanHistory)-1]
+		g.copyPosition(before)
+		g.inputMode = modeNormal // In case the wrong move ended the game
+		t.mistakes++
+		g.message = tr(txtNotBookMove, localSAN(played), localSAN(line.san[t.ply]))
+		return
+	}
+	t.ply++
+	t.playBook(g)
+}
+
+// next moves on to the next line, going round to the first after the last.
+func (t *trainer) next(g *Game) {
+	t.line = (t.line + 1) % len(t.lines)
+	t.start(g)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrKingsAdjacent reports kings on neighbouring squares, which no legal
+// move can lead to: whichever king moved there would have stepped into
+// check.
+var ErrKingsAdjacent = errors.New("the kings stand next to each other")
+
+// Home squares of the king and rook each castling right depends on.
+var castlingHomes = map[rune][2]string{
+	'K': {"e1", "h1"},
+	'Q': {"e1", "a1"},
+	'k': {"e8", "h8"},
+	'q': {"e8", "a8"},
+}
+
+// ValidatePosition checks that the current position could arise in a legal
+// game and returns an error describing the first problem found. Anything
+// that sets up a position other than the standard start should call it
+// before play begins.
+func (g *Game) ValidatePosition() error {
+	kings := map[string]int{}
+	kingAt := map[string][2]int{} // Where each side's king stands, as (x, y)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			piece := g.board[y][x]
+			if piece == nil {
+				continue
+			}
+			switch pieceKind(piece) {
+			case "king":
+				kings[piece.color]++
+				kingAt[piece.color] = [2]int{x, y}
+			case "pawn":
+				if y == 0 || y == 7 {
+					return fmt.Errorf("pawn on %s: pawns cannot stand on the first or eighth rank", squareName(x, y))
+				}
+			}
+		}
+	}
+	for _, color := range []string{"white", "black"} {
+		if kings[color] != 1 {
+			return fmt.Errorf("%s has %d kings, want exactly one", color, kings[color])
+		}
+	}
+	white, black := kingAt["white"], kingAt["black"]
+	if abs(white[0]-black[0]) <= 1 && abs(white[1]-black[1]) <= 1 {
+		return fmt.Errorf("kings on %s and %s: %w", squareName(white[0], white[1]), squareName(black[0], black[1]), ErrKingsAdjacent)
+	}
+
+	if waiting := opponent(g.currentPlayer); g.InCheck(waiting) {
+		return fmt.Errorf("%s is in check but it is %s's turn", waiting, g.currentPlayer)
+	}
+
+	for _, right := range g.castling {
+		homes, ok := castlingHomes[right]
+		if !ok {
+			return fmt.Errorf("unknown castling right %q", right)
+		}
+		color := "white"
+		if right == 'k' || right == 'q' {
+			color = "black"
+		}
+		if !g.hasPieceOn(homes[0], color+"_king") || !g.hasPieceOn(homes[1], color+"_rook") {
+			return fmt.Errorf("castling right %c needs the %s king on %s and a rook on %s", right, color, homes[0], homes[1])
+		}
+	}
+
+	// An en passant square must be the one just skipped by a double pawn
+	// push of the side that moved last.
+	if g.enPassant != "-" {
+		pushed := opponent(g.currentPlayer)
+		rank, pawnRank := 2, 3 // Rows of the sixth and fifth ranks
+		if pushed == "white" {
+			rank, pawnRank = 5, 4
+		}
+		x, y, ok := parseSquare(g.enPassant)
+		if !ok || y != rank || g.board[y][x] != nil || !g.hasPieceOn(squareName(x, pawnRank), pushed+"_pawn") {
+			return fmt.Errorf("en passant square %s does not follow a %s double pawn push", g.enPassant, pushed)
+		}
+	}
+	return nil
+}
+
+// hasPieceOn reports whether the named square holds the given piece, e.g.
+// hasPieceOn("e1", "white_king").
+func (g *Game) hasPieceOn(square, name string) bool {
+	x, y, ok := parseSquare(square)
+	return ok && g.board[y][x] != nil && g.board[y][x].symbol == pieces[name]
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKingsAdjacent(t *testing.T) {
+	tests := []struct {
+		fen     string
+		squares string // Named in the error, white's king first; "" when legal
+	}{
+		{"8/8/8/8/8/8/8/3kK3 w - - 0 1", "e1 and d1"},
+		{"8/8/8/8/8/8/8/3kK3 b - - 0 1", "e1 and d1"},
+		{"8/8/8/8/8/8/4k3/4K3 w - - 0 1", "e1 and e2"},
+		{"8/8/8/8/4K3/3k4/8/8 b - - 0 1", "e4 and d3"},
+		{"K7/1k6/8/8/8/8/8/8 w - - 0 1", "a8 and b7"},
+		{"8/8/8/8/8/8/8/2k1K3 w - - 0 1", ""},
+		{"8/8/8/8/4K3/8/2k5/8 w - - 0 1", ""},
+	}
+	for _, tt := range tests {
+		g := NewGame()
+		err := g.loadFEN(tt.fen)
+		if tt.squares == "" {
+			if err != nil {
+				t.Errorf("%s: %v, want it to load", tt.fen, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrKingsAdjacent) || !strings.Contains(err.Error(), "kings on "+tt.squares) {
+			t.Errorf("%s: got %v, want %v naming %s", tt.fen, err, ErrKingsAdjacent, tt.squares)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// variant decides when a game is over. Pieces move the same way in every
+// variant; what differs is which positions end the game and how.
+// standardChess is chess as FIDE plays it, and another set of win
+// conditions is one more implementation.
+type variant interface {
+	// ending looks at the position after mover's move, which took
+	// captured (nil if nothing), with the turn already passed on. It
+	// returns how the game ends, or a zero gameEnding if it goes on. The
+	// caller holds g.lock.
+	ending(g *Game, mover string, captured *Piece) gameEnding
+}
+
+// variants are the alternate rule sets -variant selects, by name.
+var variants = map[string]variant{
+	"koth":   kingOfTheHill{},
+	"3check": threeCheck{},
+}
+
+// variantNames lists the variants for usage and errors.
+func variantNames() string {
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// variantName is the name -variant gives rules, or "" for nil.
+func variantName(rules variant) string {
+	for name, v := range variants {
+		if v == rules {
+			return name
+		}
+	}
+	return ""
+}
+
+// gameEnding is a variant's verdict on a position: the result,
+// termination and message for endGame, or all empty while play goes on.
+type gameEnding struct {
+	result      string
+	termination string
+	message     string
+}
+
+// variant is the set of win conditions g is played under.
+func (g *Game) variant() variant {
+	switch {
+	case g.rules != nil:
+		return g.rules
+	case g.freestyle:
+		return freestyleChess{}
+	}
+	return standardChess{}
+}
+
+// standardChess ends the game by checkmate, stalemate, the draw rules and
+// the move limit. The rules are checked in order of precedence: a mate
+// ends the game even if it also leaves too little material or reaches a
+// draw limit, and a capture that leaves one side a lone king is no draw
+// while the other side can still mate. No legal reply is a loss only in
+// check; out of check it is stalemate, a draw.
+type standardChess struct{}
+
+func (standardChess) ending(g *Game, mover string, captured *Piece) gameEnding {
+	if !g.hasLegalMoves(g.currentPlayer) {
+		if g.InCheck(g.currentPlayer) {
+			return gameEnding{winResult(mover), "checkmate", tr(txtCheckmate, colorName(mover))}
+		}
+		return gameEnding{resultDraw, "stalemate", tr(txtStalemate)}
+	}
+	if termination, message := g.drawRule(); termination != "" {
+		return gameEnding{resultDraw, termination, message}
+	}
+	if g.moveLimitReached() {
+		return g.moveLimitEnding()
+	}
+	return gameEnding{}
+}
+
+// freestyleChess is won by taking the king, since any piece may move
+// anywhere; otherwise the standard endings apply.
+type freestyleChess struct{}
+
+func (freestyleChess) ending(g *Game, mover string, captured *Piece) gameEnding {
+	if captured != nil && pieceKind(captured) == "king" {
+		return gameEnding{winResult(mover), "king captured", tr(txtKingTaken, colorName(captured.color), colorName(mover))}
+	}
+	return standardChess{}.ending(g, mover, captured)
+}
+
+// hillSquares are the centre squares of king of the hill.
+var hillSquares = []string{"d4", "d5", "e4", "e5"}
+
+// kingOfTheHill is also won by bringing your king to one of the four
+// centre squares; checkmate and the other standard endings still apply,
+// except that too little material to mate is no draw while a king can
+// still walk to the centre.
+type kingOfTheHill struct{}
+
+func (kingOfTheHill) ending(g *Game, mover string, captured *Piece) gameEnding {
+	for _, square := range hillSquares {
+		if g.hasPieceOn(square, mover+"_king") {
+			return gameEnding{winResult(mover), "king of the hill", tr(txtKingOfTheHill, colorName(mover))}
+		}
+	}
+	ending := standardChess{}.ending(g, mover, captured)
+	if ending.termination == "insufficient material" {
+		return gameEnding{}
+	}
+	return ending
+}
+
+// checksToWin is how many checks win a game of three-check.
+const checksToWin = 3
+
+// threeCheck is also won by giving check a third time. A mate still ends
+// the game as checkmate, and too little material to mate is a draw only
+// once no side has anything left to give check with.
+type threeCheck struct{}
+
+func (threeCheck) ending(g *Game, mover string, captured *Piece) gameEnding {
+	ending := standardChess{}.ending(g, mover, captured)
+	switch {
+	case ending.termination == "checkmate":
+		return ending
+	case g.checks[mover] >= checksToWin:
+		return gameEnding{winResult(mover), "three-check", tr(txtThirdCheck, colorName(mover))}
+	case ending.termination == "insufficient material" && !g.onlyKings():
+		return gameEnding{}
+	}
+	return ending
+}
+
+// onlyKings reports whether the kings are the only pieces left.
+func (g *Game) onlyKings() bool {
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil && pieceKind(piece) != "king" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkStatus is the info bar's count of the checks each side has given,
+// e.g. "Checks: White 2, Black 0", or "" unless the game is three-check.
+// The caller holds g.lock.
+func (g *Game) checkStatus() string {
+	if _, ok := g.rules.(threeCheck); !ok {
+		return ""
+	}
+	return tr(txtSummaryChecks, g.checks["white"], g.checks["black"])
+}
@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+// variantGame is a headless game of the named variant set up from fen.
+func variantGame(t *testing.T, name, fen string) *Game {
+	g := newTestGame(t, fen)
+	g.rules = variants[name]
+	return g
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		moves       []string
+		result      string
+		termination string
+		message     string
+	}{
+		{"white king reaches e4", "7k/8/8/8/8/3K4/8/8 w - - 0 1", []string{"d3e4"},
+			resultWhiteWins, "king of the hill", tr(txtKingOfTheHill, colorName("white"))},
+		{"black king reaches d5", "8/8/2k5/8/8/8/8/K7 b - - 0 1", []string{"c6d5"},
+			resultBlackWins, "king of the hill", tr(txtKingOfTheHill, colorName("black"))},
+		{"mate still wins", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", []string{"a1a8"},
+			resultWhiteWins, "checkmate", tr(txtCheckmate, colorName("white"))},
+		{"stalemate still draws", "7k/7P/5K2/8/8/8/8/8 w - - 0 1", []string{"f6g6"},
+			resultDraw, "stalemate", tr(txtStalemate)},
+		{"bare kings play on", "7k/8/8/8/8/8/2K5/8 w - - 0 1", []string{"c2c3"},
+			resultOngoing, "", ""},
+		{"a king beside the hill plays on", "7k/8/8/8/8/8/2K5/8 w - - 0 1", []string{"c2c3", "h8g8", "c3c4"},
+			resultOngoing, "", ""},
+	}
+	for _, tt := range tests {
+		g := variantGame(t, "koth", tt.fen)
+		playMoves(t, g, tt.moves...)
+		if g.result != tt.result || g.termination != tt.termination {
+			t.Errorf("%s: result %q by %q, want %q by %q", tt.name, g.result, g.termination, tt.result, tt.termination)
+		}
+		if tt.message != "" && g.message != tt.message {
+			t.Errorf("%s: message %q, want %q", tt.name, g.message, tt.message)
+		}
+	}
+
+	// Without the variant a king in the centre is just a king.
+	g := newTestGame(t, "7k/8/8/8/8/3K4/8/8 w - - 0 1")
+	playMoves(t, g, "d3e4")
+	if g.termination == "king of the hill" {
+		t.Errorf("standard chess ended by %q", g.termination)
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		fen         string
+		given       int // Checks white has already given
+		moves       []string
+		checks      int // White's checks after the moves
+		result      string
+		termination string
+		message     string
+	}{
+		{"third check wins", "4k3/8/8/8/8/8/8/K2Q4 w - - 0 1", 0, []string{"d1e2", "e8d8", "e2d2", "d8e8", "d2e2"},
+			3, resultWhiteWins, "three-check", tr(txtThirdCheck, colorName("white"))},
+		{"two checks play on", "4k3/8/8/8/8/8/8/K2Q4 w - - 0 1", 0, []string{"d1e2", "e8d8", "e2d2"},
+			2, resultOngoing, "", ""},
+		{"double check counts once", "4k3/8/8/8/4N3/8/8/K3R3 w - - 0 1", 0, []string{"e4f6"},
+			1, resultOngoing, "", ""},
+		{"mate on the third check is checkmate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 2, []string{"a1a8"},
+			3, resultWhiteWins, "checkmate", tr(txtCheckmate, colorName("white"))},
+		{"a lone bishop can still check", "4k3/8/8/8/8/8/8/2B1K3 w - - 0 1", 0, []string{"e1d1"},
+			0, resultOngoing, "", ""},
+		{"bare kings draw", "4k3/8/8/8/8/8/3n4/4K3 w - - 0 1", 0, []string{"e1d2"},
+			0, resultDraw, "insufficient material", ""},
+	}
+	for _, tt := range tests {
+		g := variantGame(t, "3check", tt.fen)
+		g.checks = map[string]int{"white": tt.given}
+		playMoves(t, g, tt.moves...)
+		if g.checks["white"] != tt.checks {
+			t.Errorf("%s: white gave %d checks, want %d", tt.name, g.checks["white"], tt.checks)
+		}
+		if g.result != tt.result || g.termination != tt.termination {
+			t.Errorf("%s: result %q by %q, want %q by %q", tt.name, g.result, g.termination, tt.result, tt.termination)
+		}
+		if tt.message != "" && g.message != tt.message {
+			t.Errorf("%s: message %q, want %q", tt.name, g.message, tt.message)
+		}
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	g := variantGame(t, "3check", "4k3/8/8/8/8/8/8/K2Q4 w - - 0 1")
+	playMoves(t, g, "d1e2", "e8d8", "e2d2")
+	if got, want := g.checkStatus(), tr(txtSummaryChecks, 2, 0); got != want {
+		t.Errorf("checkStatus = %q, want %q", got, want)
+	}
+	if got := newTestGame(t, "").checkStatus(); got != "" {
+		t.Errorf("checkStatus in standard chess = %q, want none", got)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// spinnerFrames animate the waiting indicator, one frame per
+// spinnerInterval. They are plain ASCII so every glyph set can show them.
+const (
+	spinnerFrames   = `|/-\`
+	spinnerInterval = 250 * time.Millisecond
+)
+
+// spinnerFrame is the frame to show now, so every redraw advances the
+// spinner at the same pace however often it happens.
+func spinnerFrame() byte {
+	return spinnerFrames[time.Now().UnixMilli()/spinnerInterval.Milliseconds()%int64(len(spinnerFrames))]
+}
+
+// waitingFor names the message for what a networked game is waiting on
+// the opponent for, or returns "" when the next step is ours. The caller
+// holds g.lock.
+func (g *Game) waitingFor() textKey {
+	switch {
+	case g.playerColor == "" || g.analysis || g.gameOver:
+		return ""
+	case !g.readyToPlay():
+		if g.ready[g.playerColor] {
+			return txtWaitingReady
+		}
+		return ""
+	case g.offer == ctrlDraw && g.offerFrom == g.playerColor:
+		return txtWaitingDraw
+	case g.offer == ctrlTakeback && g.offerFrom == g.playerColor:
+		return txtWaitingTakeback
+	case g.currentPlayer != g.playerColor:
+		return txtWaitingMove
+	}
+	return ""
+}
+
+// waitingStatus is the message bar's waiting indicator, e.g.
+// "| Waiting for the opponent's move", or "" when nothing is awaited.
+func (g *Game) waitingStatus() string {
+	what := g.waitingFor()
+	if what == "" {
+		return ""
+	}
+	return fmt.Sprintf("%c %s", spinnerFrame(), tr(what))
+}
+
+// animateWaiting wakes the event loop every spinnerInterval while the game
+// is waiting on the opponent, so the indicator keeps turning, or while the
+// opponent has gone quiet or a clock is running, so the times shown keep
+// counting. It stops when stop is closed.
+func (g *Game) animateWaiting(stop <-chan struct{}) {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		g.lock.Lock()
+		// An open analysis board runs its own loop and reports interrupts
+		// as news from the live game, so leave it alone.
+		_, quiet := g.linkQuiet()
+		ticking := g.clock != nil && g.clock.Running()
+		waiting := (g.waitingFor() != "" || quiet || ticking) && !g.analysing
+		g.lock.Unlock()
+		if waiting {
+			termbox.Interrupt()
+		}
+	}
+}
+
+// consoleSpinner shows label with a turning spinner on the console until
+// the returned function is called. When stdout is not a terminal the label
+// is printed once instead.
+func consoleSpinner(label string) (stop func()) {
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Println(label + "...")
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			fmt.Printf("\r%s %c", label, spinnerFrame())
+			select {
+			case <-done:
+				fmt.Printf("\r%s   \n", label)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import "slices"
+
+// Zobrist hashing gives every position a 64-bit number: one random key per
+// piece on each square, per castling right, per en passant file where a
+// capture is possible and for black to move, XORed together for the
+// position. A move changes only a few of them, so applyMove keeps
+// Game.hash up to date by XORing those in and out instead of hashing the
+// whole board again.
+//
+// The keys come from a fixed seed rather than the clock, so a position
+// hashes the same on every run and in every build, and tools outside the
+// program can store the hashes (see Position.Hash).
+var (
+	zobristPieces    = map[rune]*[64]uint64{} // By piece symbol, then square (y*8 + x)
+	zobristCastling  = map[rune]uint64{}      // By FEN letter, e.g. 'K'
+	zobristEnPassant [8]uint64                // By file
+	zobristBlack     uint64                   // Black to move
+)
+
+func init() {
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 { // splitmix64
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		return z ^ z>>31
+	}
+	names := make([]string, 0, len(pieces))
+	for name := range pieces {
+		names = append(names, name)
+	}
+	slices.Sort(names) // Map order would give other keys on every run
+	for _, name := range names {
+		keys := new([64]uint64)
+		for i := range keys {
+			keys[i] = next()
+		}
+		zobristPieces[pieces[name]] = keys
+	}
+	for _, right := range "KQkq" {
+		zobristCastling[right] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	zobristBlack = next()
+}
+
+// zobristSquare is the key of piece standing on (y, x).
+func zobristSquare(piece *Piece, y, x int) uint64 {
+	return zobristPieces[piece.symbol][y*8+x]
+}
+
+// zobristRights is the key of the castling rights, as Game keeps them.
+func zobristRights(castling string) uint64 {
+	var h uint64
+	for _, right := range castling {
+		h ^= zobristCastling[right]
+	}
+	return h
+}
+
+// enPassantKey is the key of the en passant square, counted only while a
+// pawn of the side to move can legally take there. A double push that no
+// pawn can answer leaves the same moves open as a single one would, so
+// the positions are the same and must hash alike.
+func (g *Game) enPassantKey() uint64 {
+	x, y, ok := parseSquare(g.enPassant)
+	if !ok {
+		return 0
+	}
+	pawnY, pawn := y+1, pieces[g.currentPlayer+"_pawn"] // Takers stand beside the pushed pawn
+	if g.currentPlayer == "black" {
+		pawnY = y - 1
+	}
+	for _, px := range []int{x - 1, x + 1} {
+		if px < 0 || px > 7 || pawnY < 0 || pawnY > 7 {
+			continue
+		}
+		if piece := g.board[pawnY][px]; piece != nil && piece.symbol == pawn && g.movesFrom(pawnY, px)[squareKey(x, y)] == moveEnPassant {
+			return zobristEnPassant[x]
+		}
+	}
+	return 0
+}
+
+// computeHash hashes g's position from scratch. Game.hash must always
+// equal it; strict mode checks that it does after every move.
+func (g *Game) computeHash() uint64 {
+	h := zobristRights(g.castling) ^ g.enPassantKey()
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if piece := g.board[y][x]; piece != nil {
+				h ^= zobristSquare(piece, y, x)
+			}
+		}
+	}
+	if g.currentPlayer == "black" {
+		h ^= zobristBlack
+	}
+	return h
+}
+
+// Hash is a Zobrist hash of the position to move in: the placement of
+// every piece, the side to move, castling rights and the en passant
+// square when a pawn can take there, the same things that make a position
+// repeat. Equal positions hash alike whatever moves led to them, on every
+// run, so the hash can key caches and tables outside the program. It is
+// zero if FEN cannot be read.
+func (p Position) Hash() uint64 {
+	g := NewGame()
+	g.freestyle = true // Read whatever is there, legal or not
+	if err := g.loadFEN(p.FEN); err != nil {
+		return 0
+	}
+	g.freestyle = false // But take en passant by the standard rules
+	return g.computeHash()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestIncrementalHash plays random games and checks after every move that
+// the hash applyMove keeps equals one computed from scratch, and the one
+// Position.Hash gives for the FEN.
+func TestIncrementalHash(t *testing.T) {
+	for seed := int64(1); seed <= 30; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		g := newTestGame(t, "")
+		for ply := 0; ply < 200 && !g.gameOver; ply++ {
+			moves := legalMoves(g)
+			move := string(moves[r.Intn(len(moves))])
+			playMoves(t, g, move)
+			if want := g.computeHash(); g.hash != want {
+				t.Fatalf("seed %d: after %s (%s) the hash is %016x, want %016x", seed, move, g.FEN(), g.hash, want)
+			}
+			if want := (Position{FEN: g.FEN()}).Hash(); g.hash != want {
+				t.Fatalf("seed %d: after %s (%s) the hash is %016x, Position.Hash %016x", seed, move, g.FEN(), g.hash, want)
+			}
+		}
+	}
+}
+
+func TestTranspositionsHashAlike(t *testing.T) {
+	// 1. e4 sets an en passant square no black pawn can use, so it is the
+	// same position as the one the knights' round trip leads to.
+	direct := newTestGame(t, "")
+	playMoves(t, direct, "e2e4")
+	roundabout := newTestGame(t, "")
+	playMoves(t, roundabout, "g1f3", "g8f6", "f3g1", "f6g8", "e2e4")
+	if direct.enPassant != "e3" {
+		t.Fatalf("1. e4 left en passant square %q", direct.enPassant)
+	}
+	if direct.hash != roundabout.hash {
+		t.Errorf("1. e4 hashes %016x, by way of the knights %016x", direct.hash, roundabout.hash)
+	}
+	if n := roundabout.repetitions[roundabout.hash]; n != 1 {
+		t.Errorf("position after 3. e4 counted %d times, want once", n)
+	}
+	start := newTestGame(t, "")
+	playMoves(t, start, "g1f3", "g8f6", "f3g1", "f6g8")
+	if n := start.repetitions[start.hash]; n != 2 {
+		t.Errorf("start position counted %d times after the knights' round trip, want twice", n)
+	}
+}
+
+func TestEnPassantHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		fen    string // With en passant square e3, and black to move
+		counts bool
+	}{
+		{"no pawn beside", "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1", false},
+		{"white pawn beside", "4k3/8/8/8/3PP3/8/8/4K3 b - e3 0 1", false},
+		{"black pawn can take", "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1", true},
+		{"black pawn on the other side", "4k3/8/8/8/4Pp2/8/8/4K3 b - e3 0 1", true},
+		{"taking would expose the king", "4K3/8/8/8/k2pP2R/8/8/8 b - e3 0 1", false},
+	}
+	for _, tt := range tests {
+		with := Position{FEN: tt.fen}.Hash()
+		without := Position{FEN: strings.Replace(tt.fen, " e3 ", " - ", 1)}.Hash()
+		if with == 0 || without == 0 {
+			t.Fatalf("%s: cannot read %s", tt.name, tt.fen)
+		}
+		if (with != without) != tt.counts {
+			t.Errorf("%s: en passant square changes the hash: %v, want %v", tt.name, with != without, tt.counts)
+		}
+	}
+}